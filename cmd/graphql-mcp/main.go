@@ -0,0 +1,309 @@
+// Command graphql-mcp serves the queries and mutations of a GraphQL API as MCP tools, over
+// stdio for desktop MCP clients or over streamable HTTP
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/peterbeamish/go-mcp-graphql/pkg/graphqlmcp"
+)
+
+// headerEnvPrefix prefixes the environment variables that stand in for inbound request
+// headers in stdio mode, e.g. GRAPHQL_MCP_HEADER_X_TENANT_ID for X-Tenant-Id
+const headerEnvPrefix = "GRAPHQL_MCP_HEADER_"
+
+// config holds the command line configuration
+type config struct {
+	configFile      string
+	file            *graphqlmcp.Config
+	endpoint        string
+	headers         http.Header
+	schemaFile      string
+	transport       string
+	addr            string
+	allow           []string
+	block           []string
+	maxDepth        int
+	passthruHeaders []string
+	logLevel        slog.Level
+	shutdownTimeout time.Duration
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := parseConfig(os.Args[1:], os.Getenv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "graphql-mcp:", err)
+		os.Exit(2)
+	}
+
+	if err := run(ctx, cfg, os.Environ()); err != nil {
+		fmt.Fprintln(os.Stderr, "graphql-mcp:", err)
+		os.Exit(1)
+	}
+}
+
+// parseConfig reads the configuration from environment variables, overridden by flags
+func parseConfig(args []string, getenv func(string) string, output io.Writer) (*config, error) {
+	cfg := &config{
+		configFile:      getenv("GRAPHQL_MCP_CONFIG"),
+		endpoint:        getenv("GRAPHQL_ENDPOINT"),
+		headers:         make(http.Header),
+		schemaFile:      getenv("GRAPHQL_SCHEMA_FILE"),
+		transport:       getenv("GRAPHQL_MCP_TRANSPORT"),
+		addr:            getenv("GRAPHQL_MCP_ADDR"),
+		allow:           splitList(getenv("GRAPHQL_MCP_ALLOW")),
+		block:           splitList(getenv("GRAPHQL_MCP_BLOCK")),
+		passthruHeaders: splitList(getenv("GRAPHQL_MCP_PASSTHRU_HEADERS")),
+		shutdownTimeout: 10 * time.Second,
+	}
+
+	for _, line := range strings.Split(getenv("GRAPHQL_HEADERS"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if err := addHeader(cfg.headers, line); err != nil {
+			return nil, fmt.Errorf("invalid GRAPHQL_HEADERS: %w", err)
+		}
+	}
+	if token := getenv("GRAPHQL_TOKEN"); token != "" {
+		cfg.headers.Set("Authorization", "Bearer "+token)
+	}
+	if apiKey := getenv("GRAPHQL_API_KEY"); apiKey != "" {
+		cfg.headers.Set("X-API-Key", apiKey)
+	}
+	if value := getenv("GRAPHQL_MCP_MAX_DEPTH"); value != "" {
+		depth, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GRAPHQL_MCP_MAX_DEPTH %q: expected an integer", value)
+		}
+		cfg.maxDepth = depth
+	}
+	if value := getenv("GRAPHQL_MCP_LOG_LEVEL"); value != "" {
+		if err := cfg.logLevel.UnmarshalText([]byte(value)); err != nil {
+			return nil, fmt.Errorf("invalid GRAPHQL_MCP_LOG_LEVEL %q: %w", value, err)
+		}
+	}
+
+	fs := flag.NewFlagSet("graphql-mcp", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {
+		fmt.Fprintln(output, "Usage: graphql-mcp -endpoint URL [flags]")
+		fmt.Fprintln(output)
+		fmt.Fprintln(output, "Serves the queries and mutations of a GraphQL API as MCP tools.")
+		fmt.Fprintln(output, "Every flag can also be set with the environment variable named in its description.")
+		fmt.Fprintln(output)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&cfg.configFile, "config", cfg.configFile, "YAML or JSON config file, overridden by the other flags and environment variables (GRAPHQL_MCP_CONFIG)")
+	fs.StringVar(&cfg.endpoint, "endpoint", cfg.endpoint, "GraphQL endpoint URL (GRAPHQL_ENDPOINT)")
+	fs.Func("header", `header sent with every GraphQL request as "Name: value", repeatable (GRAPHQL_HEADERS, one per line; GRAPHQL_TOKEN; GRAPHQL_API_KEY)`, func(value string) error {
+		return addHeader(cfg.headers, value)
+	})
+	fs.StringVar(&cfg.schemaFile, "schema-file", cfg.schemaFile, "load the schema from an SDL or introspection JSON file instead of introspecting (GRAPHQL_SCHEMA_FILE)")
+	fs.StringVar(&cfg.transport, "transport", cfg.transport, "MCP transport, stdio or http; stdio by default (GRAPHQL_MCP_TRANSPORT)")
+	fs.StringVar(&cfg.addr, "addr", cfg.addr, "listen address of the http transport; 127.0.0.1:8081 by default (GRAPHQL_MCP_ADDR)")
+	fs.Func("allow", "comma-separated patterns of operations to expose (GRAPHQL_MCP_ALLOW)", func(value string) error {
+		cfg.allow = splitList(value)
+		return nil
+	})
+	fs.Func("block", "comma-separated patterns of operations to hide (GRAPHQL_MCP_BLOCK)", func(value string) error {
+		cfg.block = splitList(value)
+		return nil
+	})
+	fs.IntVar(&cfg.maxDepth, "max-depth", cfg.maxDepth, "maximum depth of generated selection sets (GRAPHQL_MCP_MAX_DEPTH)")
+	fs.Func("passthru-header", "comma-separated headers passed through to GraphQL requests (GRAPHQL_MCP_PASSTHRU_HEADERS)", func(value string) error {
+		cfg.passthruHeaders = splitList(value)
+		return nil
+	})
+	fs.TextVar(&cfg.logLevel, "log-level", cfg.logLevel, "log level, debug, info, warn or error (GRAPHQL_MCP_LOG_LEVEL)")
+	fs.DurationVar(&cfg.shutdownTimeout, "shutdown-timeout", cfg.shutdownTimeout, "how long the http transport waits for open requests on shutdown")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if cfg.configFile != "" {
+		file, err := graphqlmcp.LoadConfigFile(cfg.configFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.file = file
+		cfg.endpoint = firstNonEmpty(cfg.endpoint, file.Endpoint)
+		cfg.schemaFile = firstNonEmpty(cfg.schemaFile, file.Upstream.SchemaFile)
+		cfg.transport = firstNonEmpty(cfg.transport, file.Transport.Type)
+		cfg.addr = firstNonEmpty(cfg.addr, file.Transport.Addr)
+	}
+	cfg.transport = firstNonEmpty(cfg.transport, "stdio")
+	// Only listen on other interfaces when asked to, since the endpoint has no authentication
+	cfg.addr = firstNonEmpty(cfg.addr, "127.0.0.1:8081")
+
+	if cfg.endpoint == "" {
+		return nil, errors.New("a GraphQL endpoint is required, set -endpoint, GRAPHQL_ENDPOINT or endpoint in the config file")
+	}
+	if cfg.transport != "stdio" && cfg.transport != "http" {
+		return nil, fmt.Errorf("invalid transport %q: expected stdio or http", cfg.transport)
+	}
+	if cfg.maxDepth < 0 {
+		return nil, fmt.Errorf("invalid max depth %d: must not be negative", cfg.maxDepth)
+	}
+	for _, pattern := range cfg.allow {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid allow pattern %q: %w", pattern, err)
+		}
+	}
+	for _, pattern := range cfg.block {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid block pattern %q: %w", pattern, err)
+		}
+	}
+	return cfg, nil
+}
+
+// run creates the MCP GraphQL server and serves it until ctx is cancelled
+func run(ctx context.Context, cfg *config, environ []string) error {
+	// stdout carries the MCP messages in stdio mode, so logs always go to stderr
+	logger := logr.FromSlogHandler(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: cfg.logLevel}))
+
+	// The command line overrides the config file
+	file := &graphqlmcp.Config{}
+	if cfg.file != nil {
+		file = cfg.file
+	}
+	file.Endpoint = cfg.endpoint
+	file.Upstream.SchemaFile = cfg.schemaFile
+	if len(cfg.headers) > 0 && file.Upstream.Headers == nil {
+		file.Upstream.Headers = make(map[string]string)
+	}
+	for name := range cfg.headers {
+		file.Upstream.Headers[name] = strings.Join(cfg.headers.Values(name), ", ")
+	}
+	if auth := file.Upstream.Auth; auth != nil && auth.APIKey == "" && cfg.headers.Get("Authorization") != "" {
+		file.Upstream.Auth = nil
+	}
+
+	opts := []graphqlmcp.MCPGraphQLServerOption{graphqlmcp.WithLogger(logger)}
+	if len(cfg.allow) > 0 || len(cfg.block) > 0 {
+		opts = append(opts, graphqlmcp.WithMask(cfg.allow, cfg.block))
+	}
+	if cfg.maxDepth > 0 {
+		opts = append(opts, graphqlmcp.WithMaxDepth(cfg.maxDepth))
+	}
+	if len(cfg.passthruHeaders) > 0 {
+		opts = append(opts, graphqlmcp.WithPassthruHeaders(cfg.passthruHeaders))
+	}
+
+	server, err := graphqlmcp.NewMCPGraphQLServerFromConfig(file, opts...)
+	if err != nil {
+		return err
+	}
+
+	if cfg.transport == "stdio" {
+		logger.Info("Serving MCP over stdio", "endpoint", cfg.endpoint)
+		err := server.RunStdio(ctx, headersFromEnviron(environ))
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	return serveHTTP(ctx, cfg, server, logger)
+}
+
+// serveHTTP serves the MCP endpoints over HTTP and shuts down gracefully when ctx is cancelled
+func serveHTTP(ctx context.Context, cfg *config, server *graphqlmcp.MCPGraphQLServer, logger logr.Logger) error {
+	httpServer := &http.Server{
+		Addr:              cfg.addr,
+		Handler:           graphqlmcp.GetCompleteMux(server),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Info("Serving MCP over HTTP", "addr", cfg.addr, "endpoint", cfg.endpoint)
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("Shutting down", "timeout", cfg.shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down: %w", err)
+	}
+	return nil
+}
+
+// headersFromEnviron returns the stand-in request headers of stdio mode, read from
+// GRAPHQL_MCP_HEADER_* variables with underscores in the name replaced by dashes
+func headersFromEnviron(environ []string) http.Header {
+	header := make(http.Header)
+	for _, entry := range environ {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(name, headerEnvPrefix) || value == "" {
+			continue
+		}
+		name = strings.ReplaceAll(strings.TrimPrefix(name, headerEnvPrefix), "_", "-")
+		if name != "" {
+			header.Add(name, value)
+		}
+	}
+	return header
+}
+
+// addHeader parses a "Name: value" header and sets it
+func addHeader(header http.Header, line string) error {
+	name, value, ok := strings.Cut(line, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("invalid header %q: expected \"Name: value\"", line)
+	}
+	header.Set(name, strings.TrimSpace(value))
+	return nil
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// firstNonEmpty returns the first value that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
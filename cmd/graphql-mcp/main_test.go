@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	env := map[string]string{
+		"GRAPHQL_ENDPOINT":             "http://env/graphql",
+		"GRAPHQL_HEADERS":              "X-Client: env\nX-Tenant: acme\n",
+		"GRAPHQL_TOKEN":                "secret",
+		"GRAPHQL_MCP_TRANSPORT":        "http",
+		"GRAPHQL_MCP_ALLOW":            "^get.*, ^list.*",
+		"GRAPHQL_MCP_MAX_DEPTH":        "3",
+		"GRAPHQL_MCP_PASSTHRU_HEADERS": "X-Request-ID",
+		"GRAPHQL_MCP_LOG_LEVEL":        "debug",
+	}
+
+	cfg, err := parseConfig([]string{
+		"-endpoint", "http://flag/graphql",
+		"-header", "X-Client: flag",
+		"-block", ".*delete.*",
+		"-max-depth", "5",
+		"-shutdown-timeout", "3s",
+	}, func(key string) string { return env[key] }, io.Discard)
+	require.NoError(t, err)
+
+	assert.Equal(t, "http://flag/graphql", cfg.endpoint)
+	assert.Equal(t, http.Header{
+		"X-Client":      {"flag"},
+		"X-Tenant":      {"acme"},
+		"Authorization": {"Bearer secret"},
+	}, cfg.headers)
+	assert.Equal(t, "http", cfg.transport)
+	assert.Equal(t, "127.0.0.1:8081", cfg.addr)
+	assert.Equal(t, []string{"^get.*", "^list.*"}, cfg.allow)
+	assert.Equal(t, []string{".*delete.*"}, cfg.block)
+	assert.Equal(t, 5, cfg.maxDepth)
+	assert.Equal(t, []string{"X-Request-ID"}, cfg.passthruHeaders)
+	assert.Equal(t, slog.LevelDebug, cfg.logLevel)
+	assert.Equal(t, 3*time.Second, cfg.shutdownTimeout)
+}
+
+func TestParseConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		expected string
+	}{
+		{name: "missing endpoint", expected: "a GraphQL endpoint is required"},
+		{name: "unknown transport", args: []string{"-endpoint", "http://x", "-transport", "sse"}, expected: `invalid transport "sse"`},
+		{name: "malformed header", args: []string{"-endpoint", "http://x", "-header", "X-Client"}, expected: `invalid header "X-Client"`},
+		{name: "malformed env header", env: map[string]string{"GRAPHQL_ENDPOINT": "http://x", "GRAPHQL_HEADERS": "nope"}, expected: "invalid GRAPHQL_HEADERS"},
+		{name: "malformed depth", env: map[string]string{"GRAPHQL_ENDPOINT": "http://x", "GRAPHQL_MCP_MAX_DEPTH": "deep"}, expected: "invalid GRAPHQL_MCP_MAX_DEPTH"},
+		{name: "extra arguments", args: []string{"-endpoint", "http://x", "serve"}, expected: "unexpected arguments: serve"},
+		{name: "allow pattern", args: []string{"-endpoint", "http://x", "-allow", "^get.*,^list("}, expected: `invalid allow pattern "^list("`},
+		{name: "block pattern", env: map[string]string{"GRAPHQL_ENDPOINT": "http://x", "GRAPHQL_MCP_BLOCK": "[delete"}, expected: `invalid block pattern "[delete"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseConfig(tt.args, func(key string) string { return tt.env[key] }, io.Discard)
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestParseConfig_ConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "graphql-mcp.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+endpoint: http://file/graphql
+transport:
+  type: http
+  addr: ":9000"
+maxDepth: 2
+`), 0o600))
+
+	env := map[string]string{"GRAPHQL_MCP_CONFIG": path, "GRAPHQL_MCP_ADDR": ":9001"}
+	cfg, err := parseConfig(nil, func(key string) string { return env[key] }, io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, "http://file/graphql", cfg.endpoint)
+	assert.Equal(t, "http", cfg.transport)
+	assert.Equal(t, ":9001", cfg.addr)
+	assert.Equal(t, 2, cfg.file.MaxDepth)
+
+	cfg, err = parseConfig([]string{"-endpoint", "http://flag/graphql", "-transport", "stdio"}, func(key string) string { return env[key] }, io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, "http://flag/graphql", cfg.endpoint)
+	assert.Equal(t, "stdio", cfg.transport)
+
+	require.NoError(t, os.WriteFile(path, []byte("maxDepth: two\n"), 0o600))
+	_, err = parseConfig(nil, func(key string) string { return env[key] }, io.Discard)
+	assert.ErrorContains(t, err, "invalid config file "+path+": line 1:")
+}
+
+func TestHeadersFromEnviron(t *testing.T) {
+	header := headersFromEnviron([]string{
+		"GRAPHQL_MCP_HEADER_X_TENANT_ID=acme",
+		"GRAPHQL_MCP_HEADER_AUTHORIZATION=Bearer user-token",
+		"GRAPHQL_MCP_HEADER_X_EMPTY=",
+		"GRAPHQL_ENDPOINT=http://x",
+		"PATH=/usr/bin",
+	})
+
+	assert.Equal(t, http.Header{
+		"X-Tenant-Id":   {"acme"},
+		"Authorization": {"Bearer user-token"},
+	}, header)
+}
+
+func TestRun_HTTPShutdown(t *testing.T) {
+	// Introspection fails against the closed port; the server starts with an empty schema
+	cfg := &config{
+		endpoint:        "http://127.0.0.1:1/graphql",
+		headers:         make(http.Header),
+		transport:       "http",
+		addr:            "127.0.0.1:0",
+		shutdownTimeout: time.Second,
+		logLevel:        slog.LevelError,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- run(ctx, cfg, nil) }()
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+}
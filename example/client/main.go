@@ -6,12 +6,14 @@ import (
 	"log"
 	"time"
 
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/peterbeamish/go-mcp-graphql/pkg/graphqlmcp"
 )
 
 func main() {
-	// Create an HTTP client to communicate with the MCP server
-	client := graphqlmcp.CreateHTTPClient("http://localhost:8081/mcp")
+	// Create an MCP client for the /mcp endpoint of the server
+	client := graphqlmcp.CreateHTTPClient("http://localhost:8081")
+	defer client.Close()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -25,29 +27,29 @@ func main() {
 
 	fmt.Printf("Found %d available tools:\n", len(tools))
 	for _, tool := range tools {
-		fmt.Printf("  - %s (%s): %s\n",
-			tool["name"],
-			tool["type"],
-			tool["description"])
+		fmt.Printf("  - %s: %s\n", tool.Name, tool.Description)
 	}
 
 	// Example: Call a query tool (this depends on the GraphQL schema)
 	// For the countries API, we might have a "countries" query
 	if len(tools) > 0 {
-		firstTool := tools[0]
-		toolName := firstTool["name"].(string)
+		toolName := tools[0].Name
 
 		fmt.Printf("\nCalling tool: %s\n", toolName)
 
 		// Call the tool with some arguments
 		// Note: The actual arguments depend on the GraphQL schema
-		response, err := client.CallTool(ctx, toolName, map[string]interface{}{
+		result, err := client.CallTool(ctx, toolName, map[string]interface{}{
 			// Add appropriate arguments based on the tool
 		})
 		if err != nil {
 			log.Printf("Failed to call tool %s: %v", toolName, err)
 		} else {
-			fmt.Printf("Tool response: %+v\n", response)
+			for _, content := range result.Content {
+				if text, ok := content.(*mcp.TextContent); ok {
+					fmt.Printf("Tool response (error: %t): %s\n", result.IsError, text.Text)
+				}
+			}
 		}
 	}
 }
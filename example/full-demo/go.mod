@@ -4,13 +4,13 @@ go 1.24.0
 
 require (
 	github.com/99designs/gqlgen v0.17.81
+	github.com/go-logr/logr v1.4.3
 	github.com/peterbeamish/go-mcp-graphql v0.0.0
 	github.com/peterbeamish/go-mcp-graphql/example/gqlgen-server v0.0.0
 )
 
 require (
 	github.com/agnivade/levenshtein v1.2.1 // indirect
-	github.com/go-logr/logr v1.4.3 // indirect
 	github.com/go-viper/mapstructure/v2 v2.4.0 // indirect
 	github.com/google/jsonschema-go v0.3.0 // indirect
 	github.com/google/uuid v1.6.0 // indirect
@@ -20,6 +20,7 @@ require (
 	github.com/sosodev/duration v1.3.1 // indirect
 	github.com/vektah/gqlparser/v2 v2.5.30 // indirect
 	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 replace github.com/peterbeamish/go-mcp-graphql => ../../
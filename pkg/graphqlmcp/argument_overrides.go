@@ -0,0 +1,275 @@
+package graphqlmcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/peterbeamish/go-mcp-graphql/pkg/graphqlmcp/schema"
+)
+
+// AllTools matches every tool with the overridden argument in an ArgumentOverride
+const AllTools = "*"
+
+// ArgumentValueFunc computes the value of an overridden argument for a tool call
+// The context carries the principal and passthru headers of the caller
+type ArgumentValueFunc func(ctx context.Context, toolName string) (interface{}, error)
+
+// ArgumentOverride hides a tool argument from the model and fills it in on the server
+// Exactly one of Header, Claim, Value and Func provides the value
+type ArgumentOverride struct {
+	// Tool is the tool name, e.g. "query_equipment", or AllTools for every tool with the argument
+	Tool string
+	// Argument is the name of the GraphQL argument
+	Argument string
+
+	// Header takes the value from a passthru header of the request, named as it is sent upstream
+	Header string
+	// Claim takes the value from a claim of the verified principal; dots select nested claims
+	Claim string
+	// Value is a constant value
+	Value interface{}
+	// Func computes the value for each call
+	Func ArgumentValueFunc
+}
+
+// ArgumentFromHeader fills an argument from a passthru header
+func ArgumentFromHeader(tool, argument, header string) ArgumentOverride {
+	return ArgumentOverride{Tool: tool, Argument: argument, Header: header}
+}
+
+// ArgumentFromClaim fills an argument from a claim of the caller's verified token
+func ArgumentFromClaim(tool, argument, claim string) ArgumentOverride {
+	return ArgumentOverride{Tool: tool, Argument: argument, Claim: claim}
+}
+
+// ArgumentConstant fills an argument with a constant value
+func ArgumentConstant(tool, argument string, value interface{}) ArgumentOverride {
+	return ArgumentOverride{Tool: tool, Argument: argument, Value: value}
+}
+
+// ArgumentFromFunc fills an argument with the value computed by a callback
+func ArgumentFromFunc(tool, argument string, fn ArgumentValueFunc) ArgumentOverride {
+	return ArgumentOverride{Tool: tool, Argument: argument, Func: fn}
+}
+
+// String describes the override for errors and logs
+func (o ArgumentOverride) String() string {
+	return o.Tool + "." + o.Argument
+}
+
+// source describes where the value of the override comes from
+func (o ArgumentOverride) source() string {
+	switch {
+	case o.Header != "":
+		return fmt.Sprintf("header %s", o.Header)
+	case o.Claim != "":
+		return fmt.Sprintf("claim %s", o.Claim)
+	case o.Func != nil:
+		return "callback"
+	}
+	return "constant"
+}
+
+// validate checks that the override has exactly one value source
+func (o ArgumentOverride) validate(passthru *passthruRules) error {
+	if o.Tool == "" || o.Argument == "" {
+		return errors.New("tool and argument are required")
+	}
+
+	sources := 0
+	for _, set := range []bool{o.Header != "", o.Claim != "", o.Value != nil, o.Func != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return errors.New("exactly one of Header, Claim, Value and Func must be set")
+	}
+
+	if o.Header != "" && !passthru.forwards(o.Header) {
+		return fmt.Errorf("header %s is not a passthru header; add it with WithPassthruHeaders", o.Header)
+	}
+	return nil
+}
+
+// resolve computes the value of the override for a call
+func (o ArgumentOverride) resolve(ctx context.Context, toolName string) (interface{}, error) {
+	switch {
+	case o.Header != "":
+		for name, value := range GetPassthruHeaders(ctx) {
+			if http.CanonicalHeaderKey(name) == http.CanonicalHeaderKey(o.Header) && value != "" {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("the request has no %s header", o.Header)
+	case o.Claim != "":
+		principal := PrincipalFromContext(ctx)
+		if principal == nil {
+			return nil, errors.New("the caller is not authenticated")
+		}
+		var value interface{} = principal.Claims
+		for _, key := range strings.Split(o.Claim, ".") {
+			claims, ok := value.(map[string]interface{})
+			if !ok {
+				value = nil
+				break
+			}
+			value = claims[key]
+		}
+		if value == nil {
+			return nil, fmt.Errorf("the caller's token has no %s claim", o.Claim)
+		}
+		return value, nil
+	case o.Func != nil:
+		value, err := o.Func(ctx, toolName)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			return nil, errors.New("the callback returned no value")
+		}
+		return value, nil
+	}
+	return o.Value, nil
+}
+
+// resolveArgumentOverrides validates the configured overrides against the schema and groups
+// them by tool name. Overrides for specific tools take precedence over AllTools overrides
+func (s *MCPGraphQLServer) resolveArgumentOverrides() (map[string][]ArgumentOverride, error) {
+	if len(s.options.ArgumentOverrides) == 0 {
+		return nil, nil
+	}
+
+	fields := make(map[string]*schema.Field)
+	for _, query := range s.Schema.GetQueries() {
+		fields[toolNameFor("query", query.Name)] = query
+	}
+	for _, mutation := range s.Schema.GetMutations() {
+		fields[toolNameFor("mutation", mutation.Name)] = mutation
+	}
+
+	resolved := make(map[string][]ArgumentOverride)
+	specific := make(map[string]bool)
+	for _, override := range s.options.ArgumentOverrides {
+		if err := override.validate(s.passthru); err != nil {
+			return nil, fmt.Errorf("invalid argument override %s: %w", override, err)
+		}
+
+		if override.Tool != AllTools {
+			field, ok := fields[override.Tool]
+			if !ok {
+				return nil, fmt.Errorf("invalid argument override %s: the schema has no tool %s", override, override.Tool)
+			}
+			if findArgument(field, override.Argument) == nil {
+				return nil, fmt.Errorf("invalid argument override %s: %s has no argument %s", override, field.Name, override.Argument)
+			}
+			if specific[override.String()] {
+				return nil, fmt.Errorf("invalid argument override %s: the argument is overridden twice", override)
+			}
+			specific[override.String()] = true
+			resolved[override.Tool] = append(resolved[override.Tool], override)
+			continue
+		}
+
+		matched := false
+		for toolName, field := range fields {
+			if findArgument(field, override.Argument) != nil {
+				matched = true
+				resolved[toolName] = append(resolved[toolName], override)
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("invalid argument override %s: no tool has an argument %s", override, override.Argument)
+		}
+	}
+
+	// Drop AllTools overrides for arguments that a tool overrides specifically
+	for toolName, overrides := range resolved {
+		kept := overrides[:0]
+		for _, override := range overrides {
+			if override.Tool == AllTools && specific[toolName+"."+override.Argument] {
+				continue
+			}
+			kept = append(kept, override)
+		}
+		resolved[toolName] = kept
+	}
+	return resolved, nil
+}
+
+// findArgument returns the argument of a field with the given name, or nil
+func findArgument(field *schema.Field, name string) *schema.Argument {
+	for _, arg := range field.Args {
+		if arg.Name == name {
+			return arg
+		}
+	}
+	return nil
+}
+
+// withoutOverriddenArguments returns the arguments of a tool call without those the overrides
+// filled in, for showing them to the model
+func (s *MCPGraphQLServer) withoutOverriddenArguments(toolName string, args map[string]interface{}) map[string]interface{} {
+	overrides := s.argumentOverrides[toolName]
+	if len(overrides) == 0 {
+		return args
+	}
+
+	visible := make(map[string]interface{}, len(args))
+	for name, value := range args {
+		visible[name] = value
+	}
+	for _, override := range overrides {
+		delete(visible, override.Argument)
+	}
+	return visible
+}
+
+// hideOverriddenArguments removes the overridden arguments of a tool from its input schema
+func (s *MCPGraphQLServer) hideOverriddenArguments(toolName string, inputSchema map[string]interface{}) {
+	overrides := s.argumentOverrides[toolName]
+	if len(overrides) == 0 {
+		return
+	}
+
+	properties, _ := inputSchema["properties"].(map[string]interface{})
+	required, _ := inputSchema["required"].([]string)
+	for _, override := range overrides {
+		delete(properties, override.Argument)
+		kept := required[:0]
+		for _, name := range required {
+			if name != override.Argument {
+				kept = append(kept, name)
+			}
+		}
+		required = kept
+	}
+	if len(required) > 0 {
+		inputSchema["required"] = required
+	} else {
+		delete(inputSchema, "required")
+	}
+}
+
+// applyArgumentOverrides replaces the overridden arguments of a call with their server-side values
+// A value that cannot be determined fails the call, so a missing tenant never widens access
+func (s *MCPGraphQLServer) applyArgumentOverrides(ctx context.Context, toolName string, input map[string]interface{}) error {
+	for _, override := range s.argumentOverrides[toolName] {
+		if _, ok := input[override.Argument]; ok {
+			s.logger.Info("Ignoring model-supplied value for overridden argument",
+				"tool_name", toolName,
+				"argument", override.Argument,
+			)
+		}
+
+		value, err := override.resolve(ctx, toolName)
+		if err != nil {
+			return fmt.Errorf("cannot determine %s from %s: %w", override.Argument, override.source(), err)
+		}
+		input[override.Argument] = value
+	}
+	return nil
+}
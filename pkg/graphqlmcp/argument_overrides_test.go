@@ -0,0 +1,186 @@
+package graphqlmcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPGraphQLServer_ArgumentOverrideValidation(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	tests := []struct {
+		name     string
+		override ArgumentOverride
+		expected string
+	}{
+		{
+			name:     "unknown tool",
+			override: ArgumentConstant("query_tenants", "id", "1"),
+			expected: "invalid argument override query_tenants.id: the schema has no tool query_tenants",
+		},
+		{
+			name:     "unknown argument",
+			override: ArgumentConstant("query_equipmentById", "tenantId", "1"),
+			expected: "invalid argument override query_equipmentById.tenantId: equipmentById has no argument tenantId",
+		},
+		{
+			name:     "no tool has the argument",
+			override: ArgumentConstant(AllTools, "tenantId", "1"),
+			expected: "invalid argument override *.tenantId: no tool has an argument tenantId",
+		},
+		{
+			name:     "several sources",
+			override: ArgumentOverride{Tool: "query_equipmentById", Argument: "id", Value: "1", Claim: "sub"},
+			expected: "exactly one of Header, Claim, Value and Func must be set",
+		},
+		{
+			name:     "header is not passed through",
+			override: ArgumentFromHeader("query_equipmentById", "id", "X-Equipment-ID"),
+			expected: "header X-Equipment-ID is not a passthru header",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockExecutor := new(MockGraphQLExecutor)
+			mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+
+			_, err := NewMCPGraphQLServerWithExecutor(mockExecutor, WithArgumentOverrides(tt.override))
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestMCPGraphQLServer_ArgumentOverrides(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"result": nil}}, nil)
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor,
+		WithPassthruHeaders([]string{"X-Facility-ID"}),
+		WithArgumentOverrides(
+			ArgumentFromHeader("query_facilityStatus", "facilityId", "x-facility-id"),
+			ArgumentFromClaim(AllTools, "id", "org.equipment"),
+			ArgumentFromClaim("query_facilityById", "id", "org.facility"),
+			ArgumentConstant("mutation_updateEquipmentStatus", "notes", "Changed through MCP"),
+			ArgumentFromFunc("query_maintenanceRecordsByEquipment", "equipmentId", func(ctx context.Context, toolName string) (interface{}, error) {
+				if PrincipalFromContext(ctx) == nil {
+					return nil, errors.New("anonymous callers have no equipment")
+				}
+				return "eq-" + PrincipalFromContext(ctx).Subject, nil
+			}),
+		),
+	)
+	require.NoError(t, err)
+
+	// Overridden arguments are hidden from the model
+	facilityStatus := findField(t, testSchema.GetQueries(), "facilityStatus")
+	inputSchema := server.toolInputSchema(facilityStatus, "query")
+	assert.Empty(t, inputSchema["properties"])
+	assert.NotContains(t, inputSchema, "required")
+
+	updateStatus := findField(t, testSchema.GetMutations(), "updateEquipmentStatus")
+	inputSchema = server.toolInputSchema(updateStatus, "mutation")
+	assert.NotContains(t, inputSchema["properties"], "id")
+	assert.NotContains(t, inputSchema["properties"], "notes")
+	assert.Equal(t, []string{"status"}, inputSchema["required"])
+
+	principal := &Principal{Subject: "alice", Claims: map[string]interface{}{
+		"org": map[string]interface{}{"equipment": "eq-7", "facility": "fac-3"},
+	}}
+	tenantCtx := ContextWithPrincipal(AddPassthruHeadersToContext(context.Background(), map[string]string{"X-Facility-ID": "fac-1"}), principal)
+
+	tests := []struct {
+		name      string
+		operation string
+		fieldName string
+		ctx       context.Context
+		arguments string
+		expected  map[string]interface{}
+		errMsg    string
+	}{
+		{
+			name:      "header",
+			operation: "query", fieldName: "facilityStatus", ctx: tenantCtx,
+			arguments: `{"facilityId": "fac-2"}`,
+			expected:  map[string]interface{}{"facilityId": "fac-1"},
+		},
+		{
+			name:      "missing header",
+			operation: "query", fieldName: "facilityStatus", ctx: context.Background(),
+			arguments: `{}`,
+			errMsg:    "Cannot call query_facilityStatus: cannot determine facilityId from header x-facility-id: the request has no x-facility-id header",
+		},
+		{
+			name:      "nested claim for all tools",
+			operation: "query", fieldName: "equipmentById", ctx: tenantCtx,
+			arguments: `{}`,
+			expected:  map[string]interface{}{"id": "eq-7"},
+		},
+		{
+			name:      "specific override wins over all tools",
+			operation: "query", fieldName: "facilityById", ctx: tenantCtx,
+			arguments: `{}`,
+			expected:  map[string]interface{}{"id": "fac-3"},
+		},
+		{
+			name:      "unauthenticated caller",
+			operation: "query", fieldName: "equipmentById", ctx: context.Background(),
+			arguments: `{"id": "eq-1"}`,
+			errMsg:    "cannot determine id from claim org.equipment: the caller is not authenticated",
+		},
+		{
+			name:      "constant",
+			operation: "mutation", fieldName: "updateEquipmentStatus", ctx: tenantCtx,
+			arguments: `{"status": "OPERATIONAL", "notes": "ignored"}`,
+			expected:  map[string]interface{}{"id": "eq-7", "status": "OPERATIONAL", "notes": "Changed through MCP"},
+		},
+		{
+			name:      "callback",
+			operation: "query", fieldName: "maintenanceRecordsByEquipment", ctx: tenantCtx,
+			arguments: `{}`,
+			expected:  map[string]interface{}{"equipmentId": "eq-alice"},
+		},
+		{
+			name:      "callback error",
+			operation: "query", fieldName: "maintenanceRecordsByEquipment", ctx: context.Background(),
+			arguments: `{}`,
+			errMsg:    "cannot determine equipmentId from callback: anonymous callers have no equipment",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockExecutor.Calls = nil
+
+			fields := testSchema.GetQueries()
+			if tt.operation == "mutation" {
+				fields = testSchema.GetMutations()
+			}
+			handler := server.newToolHandler(findField(t, fields, tt.fieldName), tt.operation)
+			result, err := handler(tt.ctx, &mcp.CallToolRequest{
+				Params: &mcp.CallToolParamsRaw{Name: tt.operation + "_" + tt.fieldName, Arguments: json.RawMessage(tt.arguments)},
+			})
+			require.NoError(t, err)
+
+			if tt.errMsg != "" {
+				assert.True(t, result.IsError)
+				assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, tt.errMsg)
+				mockExecutor.AssertNotCalled(t, "ExecuteQuery", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.False(t, result.IsError)
+			mockExecutor.AssertCalled(t, "ExecuteQuery", mock.Anything, mock.Anything, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,377 @@
+package graphqlmcp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Audit outcomes
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeError   = "error"
+	AuditOutcomeDenied  = "denied"
+)
+
+// Audit error classes
+const (
+	AuditErrorUnauthorized         = "unauthorized"
+	AuditErrorInvalidArguments     = "invalid_arguments"
+	AuditErrorArgumentOverride     = "argument_override"
+	AuditErrorRateLimited          = "rate_limited"
+	AuditErrorConfirmationRequired = "confirmation_required"
+	AuditErrorConfirmationRejected = "confirmation_rejected"
+	AuditErrorOperationTypeDenied  = "operation_type_denied"
+	AuditErrorCostLimit            = "cost_limit"
+	AuditErrorUpstream             = "upstream_error"
+	AuditErrorGraphQL              = "graphql_error"
+	AuditErrorInternal             = "internal_error"
+)
+
+// AuditRecord describes one tool call
+type AuditRecord struct {
+	Time      time.Time `json:"time"`
+	SessionID string    `json:"sessionId,omitempty"`
+	Principal string    `json:"principal,omitempty"`
+	Roles     []string  `json:"roles,omitempty"`
+	Tool      string    `json:"tool"`
+	// Arguments holds a keyed hash of each argument, or its redacted value with AuditArgumentValues
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	// OperationHash is the SHA-256 of the GraphQL document sent upstream
+	OperationHash string `json:"operationHash,omitempty"`
+	// Outcome is AuditOutcomeSuccess, AuditOutcomeError or AuditOutcomeDenied
+	Outcome string `json:"outcome"`
+	// ErrorClass classifies failed and denied calls, e.g. AuditErrorRateLimited
+	ErrorClass string `json:"errorClass,omitempty"`
+	// UpstreamStatus is the HTTP status of the GraphQL server, when it was called over HTTP
+	UpstreamStatus int   `json:"upstreamStatus,omitempty"`
+	DurationMS     int64 `json:"durationMs"`
+	// ResponseBytes is the size of the text returned to the client
+	ResponseBytes int `json:"responseBytes"`
+}
+
+// AuditSink stores audit records
+// Write is called once per tool call, including calls that were denied
+type AuditSink interface {
+	Write(ctx context.Context, record *AuditRecord) error
+}
+
+// AuditArgumentMode controls how tool arguments appear in audit records
+type AuditArgumentMode int
+
+const (
+	// AuditArgumentHashes records an HMAC-SHA256 of each argument value, keyed with the audit hash
+	// key so that low-entropy values cannot be recovered by hashing candidates
+	AuditArgumentHashes AuditArgumentMode = iota
+	// AuditArgumentValues records argument values after applying the value redaction rules
+	AuditArgumentValues
+)
+
+// MemoryAuditSink keeps audit records in memory, for tests
+type MemoryAuditSink struct {
+	mu      sync.Mutex
+	records []AuditRecord
+}
+
+// NewMemoryAuditSink creates an empty in-memory audit sink
+func NewMemoryAuditSink() *MemoryAuditSink {
+	return &MemoryAuditSink{}
+}
+
+// Write stores a copy of the record
+func (m *MemoryAuditSink) Write(ctx context.Context, record *AuditRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = append(m.records, *record)
+	return nil
+}
+
+// Records returns the stored records in the order they were written
+func (m *MemoryAuditSink) Records() []AuditRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]AuditRecord(nil), m.records...)
+}
+
+// JSONLFileAuditSinkOptions configures the rotation of a JSONL audit file
+type JSONLFileAuditSinkOptions struct {
+	// MaxBytes rotates the file before it grows beyond this size; 0 disables rotation
+	MaxBytes int64
+	// MaxBackups is the number of rotated files to keep, named path.1 (newest) to path.N
+	MaxBackups int
+}
+
+// JSONLFileAuditSink appends audit records to a file, one JSON object per line
+type JSONLFileAuditSink struct {
+	path string
+	opts JSONLFileAuditSinkOptions
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewJSONLFileAuditSink opens or creates a JSONL audit file
+func NewJSONLFileAuditSink(path string, opts JSONLFileAuditSinkOptions) (*JSONLFileAuditSink, error) {
+	sink := &JSONLFileAuditSink{path: path, opts: opts}
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+// Write appends the record, rotating the file first if it would grow too large
+func (f *JSONLFileAuditSink) Write(ctx context.Context, record *AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	line = append(line, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return errors.New("audit file is closed")
+	}
+	if f.opts.MaxBytes > 0 && f.size > 0 && f.size+int64(len(line)) > f.opts.MaxBytes {
+		if err := f.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := f.file.Write(line)
+	f.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	return nil
+}
+
+// Close closes the audit file
+func (f *JSONLFileAuditSink) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// open opens the audit file for appending
+func (f *JSONLFileAuditSink) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotate shifts the backups, moves the current file to path.1 and starts a new file
+func (f *JSONLFileAuditSink) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to rotate audit file: %w", err)
+	}
+	f.file = nil
+
+	if f.opts.MaxBackups <= 0 {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate audit file: %w", err)
+		}
+		return f.open()
+	}
+
+	os.Remove(fmt.Sprintf("%s.%d", f.path, f.opts.MaxBackups))
+	for i := f.opts.MaxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+	}
+	if err := os.Rename(f.path, f.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate audit file: %w", err)
+	}
+	return f.open()
+}
+
+// auditRecordKey is the context key for the audit record of the current tool call
+type auditRecordKey struct{}
+
+// auditRecordFromContext returns the audit record of the current tool call, or nil
+func auditRecordFromContext(ctx context.Context) *AuditRecord {
+	record, _ := ctx.Value(auditRecordKey{}).(*AuditRecord)
+	return record
+}
+
+// auditFailure classifies the outcome of the current tool call
+func auditFailure(ctx context.Context, outcome, errorClass string) {
+	if record := auditRecordFromContext(ctx); record != nil {
+		record.Outcome = outcome
+		record.ErrorClass = errorClass
+	}
+}
+
+// auditArguments sets the arguments of the current tool call on its audit record
+func (s *MCPGraphQLServer) auditArguments(ctx context.Context, args map[string]interface{}) {
+	if record := auditRecordFromContext(ctx); record != nil {
+		record.Arguments = s.recordedArguments(args)
+	}
+}
+
+// recordedArguments returns the arguments as audit records and logs hold them: a keyed hash of
+// each value, or its redacted value with AuditArgumentValues
+func (s *MCPGraphQLServer) recordedArguments(args map[string]interface{}) map[string]interface{} {
+	recorded := make(map[string]interface{}, len(args))
+	for name, value := range args {
+		if s.options.AuditArguments == AuditArgumentValues {
+			recorded[name] = s.redactArgumentValue(value)
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		mac := hmac.New(sha256.New, s.options.AuditHashKey)
+		mac.Write(data)
+		recorded[name] = "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+	}
+	return recorded
+}
+
+// newAuditHashKey creates a random key for the argument hashes of a server
+func newAuditHashKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to create audit hash key: %w", err)
+	}
+	return key, nil
+}
+
+// redactArgumentValue applies the value redaction rules to a copy of an argument value
+func (s *MCPGraphQLServer) redactArgumentValue(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var copied interface{}
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil
+	}
+	if s.redaction == nil {
+		return copied
+	}
+	r := &redactor{rules: &redactionRules{values: s.redaction.values}}
+	return r.walk(copied, "", nil)
+}
+
+// auditOperation sets the hash of the GraphQL document of the current tool call
+func auditOperation(ctx context.Context, document string) {
+	if record := auditRecordFromContext(ctx); record != nil {
+		hash := sha256.Sum256([]byte(document))
+		record.OperationHash = "sha256:" + hex.EncodeToString(hash[:])
+	}
+}
+
+// auditUpstreamStatus sets the HTTP status of the GraphQL server for the current tool call
+func auditUpstreamStatus(ctx context.Context, resp *GraphQLResponse, err error) {
+	record := auditRecordFromContext(ctx)
+	if record == nil {
+		return
+	}
+	var statusErr *HTTPStatusError
+	switch {
+	case errors.As(err, &statusErr):
+		record.UpstreamStatus = statusErr.StatusCode
+	case resp != nil:
+		record.UpstreamStatus = resp.StatusCode
+	}
+}
+
+// newAuditRecord starts the audit record of a tool call
+func newAuditRecord(ctx context.Context, req *mcp.CallToolRequest) *AuditRecord {
+	record := &AuditRecord{Time: time.Now().UTC(), Tool: req.Params.Name, Outcome: AuditOutcomeSuccess}
+	if req.Session != nil {
+		record.SessionID = req.Session.ID()
+	}
+	if principal := requestPrincipal(ctx, req.Extra); principal != nil {
+		record.Principal = principal.Subject
+		record.Roles = principal.Roles
+	}
+	return record
+}
+
+// writeAuditRecord completes the record of a tool call and writes it to the audit sink
+func (s *MCPGraphQLServer) writeAuditRecord(ctx context.Context, record *AuditRecord, result *mcp.CallToolResult) {
+	record.DurationMS = time.Since(record.Time).Milliseconds()
+	if result != nil {
+		for _, content := range result.Content {
+			if text, ok := content.(*mcp.TextContent); ok {
+				record.ResponseBytes += len(text.Text)
+			}
+		}
+		if result.IsError && record.Outcome == AuditOutcomeSuccess {
+			record.Outcome = AuditOutcomeError
+			record.ErrorClass = AuditErrorInternal
+		}
+	}
+
+	if err := s.options.AuditSink.Write(ctx, record); err != nil {
+		s.logger.Error(err, "Failed to write audit record",
+			"tool_name", record.Tool,
+			"session_id", record.SessionID,
+		)
+	}
+}
+
+// withAudit wraps a tool handler so that every call is written to the audit sink
+func (s *MCPGraphQLServer) withAudit(handler mcp.ToolHandler) mcp.ToolHandler {
+	if s.options.AuditSink == nil {
+		return handler
+	}
+	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		record := newAuditRecord(ctx, req)
+		result, err := handler(context.WithValue(ctx, auditRecordKey{}, record), req)
+		if err != nil {
+			record.Outcome = AuditOutcomeError
+			record.ErrorClass = AuditErrorInternal
+		}
+		s.writeAuditRecord(ctx, record, result)
+		return result, err
+	}
+}
+
+// auditDeniedCall writes the audit record of a tool call that was rejected before its handler ran
+func (s *MCPGraphQLServer) auditDeniedCall(ctx context.Context, req mcp.Request, params *mcp.CallToolParamsRaw, errorClass string) {
+	if s.options.AuditSink == nil {
+		return
+	}
+	callReq := &mcp.CallToolRequest{Params: params, Extra: req.GetExtra()}
+	if session, ok := req.GetSession().(*mcp.ServerSession); ok {
+		callReq.Session = session
+	}
+	record := newAuditRecord(ctx, callReq)
+	record.Outcome = AuditOutcomeDenied
+	record.ErrorClass = errorClass
+
+	var args map[string]interface{}
+	if len(params.Arguments) > 0 && json.Unmarshal(params.Arguments, &args) == nil {
+		s.auditArguments(context.WithValue(ctx, auditRecordKey{}, record), args)
+	}
+	s.writeAuditRecord(ctx, record, nil)
+}
@@ -0,0 +1,246 @@
+package graphqlmcp
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPGraphQLServer_Audit(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"equipmentById": nil}, StatusCode: 200}, nil)
+
+	policy, err := NewRolePolicy(map[string]RoleRules{"viewer": {Allow: []string{"^query_"}}}, "viewer")
+	require.NoError(t, err)
+
+	sink := NewMemoryAuditSink()
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor,
+		WithAuthorizationPolicy(policy),
+		WithAuditSink(sink, AuditArgumentHashes),
+		WithAuditHashKey([]byte("audit-key")),
+	)
+	require.NoError(t, err)
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	_, err = server.GetMCPServer().Connect(context.Background(), serverTransport, nil)
+	require.NoError(t, err)
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(context.Background(), clientTransport, nil)
+	require.NoError(t, err)
+	defer session.Close()
+
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "query_equipmentById",
+		Arguments: map[string]interface{}{"id": "eq-1"},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	_, err = session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "mutation_deleteEquipment",
+		Arguments: map[string]interface{}{"id": "eq-1"},
+	})
+	require.Error(t, err)
+
+	records := sink.Records()
+	require.Len(t, records, 2)
+
+	success := records[0]
+	assert.Equal(t, "query_equipmentById", success.Tool)
+	assert.Equal(t, AuditOutcomeSuccess, success.Outcome)
+	assert.Empty(t, success.ErrorClass)
+	assert.Equal(t, 200, success.UpstreamStatus)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", success.OperationHash)
+	mac := hmac.New(sha256.New, []byte("audit-key"))
+	mac.Write([]byte(`"eq-1"`))
+	assert.Equal(t, "hmac-sha256:"+hex.EncodeToString(mac.Sum(nil)), success.Arguments["id"])
+	assert.Equal(t, len(result.Content[0].(*mcp.TextContent).Text), success.ResponseBytes)
+
+	// The raw argument value never reaches the audit log
+	encoded, err := json.Marshal(success)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encoded), "eq-1")
+
+	denied := records[1]
+	assert.Equal(t, "mutation_deleteEquipment", denied.Tool)
+	assert.Equal(t, AuditOutcomeDenied, denied.Outcome)
+	assert.Equal(t, AuditErrorUnauthorized, denied.ErrorClass)
+	assert.Equal(t, success.Arguments["id"], denied.Arguments["id"])
+	assert.Empty(t, denied.OperationHash)
+	assert.Equal(t, success.SessionID, denied.SessionID)
+}
+
+func TestMCPGraphQLServer_AuditFailures(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+		Return((*GraphQLResponse)(nil), &HTTPStatusError{StatusCode: 502, Body: "bad gateway"})
+
+	sink := NewMemoryAuditSink()
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor,
+		WithReadOnly(),
+		WithRedaction(RedactValues(`\d{3}-\d{4}`)),
+		WithAuditSink(sink, AuditArgumentValues),
+	)
+	require.NoError(t, err)
+
+	callTool := func(operationType, fieldName, arguments string) {
+		fields := testSchema.GetQueries()
+		if operationType == "mutation" {
+			fields = testSchema.GetMutations()
+		}
+		handler := server.withAudit(server.newToolHandler(findField(t, fields, fieldName), operationType))
+		_, err := handler(context.Background(), &mcp.CallToolRequest{
+			Params: &mcp.CallToolParamsRaw{Name: toolNameFor(operationType, fieldName), Arguments: json.RawMessage(arguments)},
+		})
+		require.NoError(t, err)
+	}
+
+	callTool("query", "equipmentById", `{"id": "call 555-0100"}`)
+	callTool("query", "equipmentById", `{}`)
+	callTool("mutation", "deleteEquipment", `{"id": "eq-1"}`)
+
+	records := sink.Records()
+	require.Len(t, records, 3)
+
+	assert.Equal(t, AuditOutcomeError, records[0].Outcome)
+	assert.Equal(t, AuditErrorUpstream, records[0].ErrorClass)
+	assert.Equal(t, 502, records[0].UpstreamStatus)
+	assert.Equal(t, map[string]interface{}{"id": "call [REDACTED]"}, records[0].Arguments)
+	assert.Positive(t, records[0].ResponseBytes)
+
+	assert.Equal(t, AuditOutcomeError, records[1].Outcome)
+	assert.Equal(t, AuditErrorInvalidArguments, records[1].ErrorClass)
+
+	assert.Equal(t, AuditOutcomeDenied, records[2].Outcome)
+	assert.Equal(t, AuditErrorOperationTypeDenied, records[2].ErrorClass)
+	assert.NotEmpty(t, records[2].OperationHash)
+	mockExecutor.AssertNumberOfCalls(t, "ExecuteQuery", 1)
+}
+
+func TestMCPGraphQLServer_AuditCoercedArguments(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"updateEquipmentStatus": nil}}, nil)
+
+	sink := NewMemoryAuditSink()
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor,
+		WithArgumentOverrides(ArgumentConstant("mutation_updateEquipmentStatus", "notes", "Changed through MCP")),
+		WithAuditSink(sink, AuditArgumentValues),
+	)
+	require.NoError(t, err)
+
+	handler := server.withAudit(server.newToolHandler(findField(t, testSchema.GetMutations(), "updateEquipmentStatus"), "mutation"))
+	_, err = handler(context.Background(), &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{Name: "mutation_updateEquipmentStatus", Arguments: json.RawMessage(`{"id": 7, "status": "OPERATIONAL", "notes": "ignored"}`)},
+	})
+	require.NoError(t, err)
+
+	// The record holds the arguments sent upstream, after overrides and coercion
+	records := sink.Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, AuditOutcomeSuccess, records[0].Outcome)
+	assert.Equal(t, map[string]interface{}{"id": "7", "status": "OPERATIONAL", "notes": "Changed through MCP"}, records[0].Arguments)
+}
+
+func TestJSONLFileAuditSink_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewJSONLFileAuditSink(path, JSONLFileAuditSinkOptions{MaxBytes: 300, MaxBackups: 2})
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, sink.Write(context.Background(), &AuditRecord{Tool: fmt.Sprintf("query_%d", i), Outcome: AuditOutcomeSuccess}))
+	}
+	require.NoError(t, sink.Close())
+	assert.EqualError(t, sink.Write(context.Background(), &AuditRecord{}), "audit file is closed")
+
+	readTools := func(path string) []string {
+		file, err := os.Open(path)
+		require.NoError(t, err)
+		defer file.Close()
+
+		var tools []string
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var record AuditRecord
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+			tools = append(tools, record.Tool)
+		}
+		return tools
+	}
+
+	current := readTools(path)
+	newest := readTools(path + ".1")
+	oldest := readTools(path + ".2")
+	assert.NoFileExists(t, path+".3")
+
+	// Each file stays within the limit and the files hold consecutive records
+	for _, file := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(300))
+	}
+	all := append(append(oldest, newest...), current...)
+	assert.Equal(t, "query_9", all[len(all)-1])
+	for i := 1; i < len(all); i++ {
+		assert.NotEqual(t, all[i-1], all[i])
+	}
+
+	// Reopening appends to the current file
+	sink, err = NewJSONLFileAuditSink(path, JSONLFileAuditSinkOptions{})
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(context.Background(), &AuditRecord{Tool: "query_10"}))
+	require.NoError(t, sink.Close())
+	assert.Equal(t, append(current, "query_10"), readTools(path))
+}
+
+func TestMCPGraphQLServer_DebugLogArguments(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"updateEquipmentStatus": nil}}, nil)
+
+	var logs strings.Builder
+	logger := funcr.New(func(prefix, args string) { logs.WriteString(args + "\n") }, funcr.Options{Verbosity: 1})
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor,
+		WithLogger(logger),
+		WithArgumentOverrides(ArgumentConstant("mutation_updateEquipmentStatus", "notes", "injected-note")),
+	)
+	require.NoError(t, err)
+
+	_, err = server.newToolHandler(findField(t, testSchema.GetMutations(), "updateEquipmentStatus"), "mutation")(context.Background(), &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{Name: "mutation_updateEquipmentStatus", Arguments: json.RawMessage(`{"id": 7, "status": "OPERATIONAL"}`)},
+	})
+	require.NoError(t, err)
+
+	// Debug logs hold the arguments as audit records do, hashed unless values are configured
+	assert.Contains(t, logs.String(), "Generated GraphQL operation")
+	assert.Contains(t, logs.String(), `"notes"="hmac-sha256:`)
+	assert.NotContains(t, logs.String(), "injected-note")
+	assert.NotContains(t, logs.String(), "OPERATIONAL")
+}
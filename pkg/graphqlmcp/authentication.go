@@ -0,0 +1,260 @@
+package graphqlmcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/modelcontextprotocol/go-sdk/oauthex"
+)
+
+// ProtectedResourcePath is where the OAuth protected resource metadata of the server is served
+const ProtectedResourcePath = "/.well-known/oauth-protected-resource"
+
+// principalTokenInfoKey is the TokenInfo.Extra key that carries the verified principal
+const principalTokenInfoKey = "principal"
+
+// subjectTokenInfoKey is the TokenInfo.Extra key that carries a bearer token verified as a JWT
+// Only those are passed on as the subject token of a token exchange, never static API keys
+const subjectTokenInfoKey = "subject_token"
+
+// Authenticator verifies the bearer token of an HTTP request
+// Verification failures should wrap auth.ErrInvalidToken so the caller gets a 401 response
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string, r *http.Request) (*Principal, *auth.TokenInfo, error)
+}
+
+// ProtectedResourceMetadata describes the server as an OAuth 2.0 protected resource (RFC 9728)
+type ProtectedResourceMetadata = oauthex.ProtectedResourceMetadata
+
+// verifyToken tries every configured authenticator and records the principal in the token info
+func (s *MCPGraphQLServer) verifyToken(ctx context.Context, token string, r *http.Request) (*auth.TokenInfo, error) {
+	var failures []string
+	for _, authenticator := range s.options.Authenticators {
+		principal, tokenInfo, err := authenticator.Authenticate(ctx, token, r)
+		if err != nil {
+			if !errors.Is(err, auth.ErrInvalidToken) {
+				return nil, err
+			}
+			failures = append(failures, err.Error())
+			continue
+		}
+
+		if tokenInfo == nil {
+			tokenInfo = &auth.TokenInfo{}
+		}
+		if tokenInfo.Extra == nil {
+			tokenInfo.Extra = make(map[string]any)
+		}
+		tokenInfo.Extra[principalTokenInfoKey] = principal
+		return tokenInfo, nil
+	}
+
+	s.logger.Info("Rejected MCP request with invalid credentials",
+		"remote_addr", r.RemoteAddr,
+		"errors", failures,
+	)
+	return nil, fmt.Errorf("%w: credentials were not accepted", auth.ErrInvalidToken)
+}
+
+// requireAuthentication rejects requests without valid credentials when authenticators are
+// configured, and adds the verified principal to the request context
+func (s *MCPGraphQLServer) requireAuthentication(handler http.Handler) http.Handler {
+	if len(s.options.Authenticators) == 0 {
+		return handler
+	}
+
+	withPrincipal := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if principal := principalFromTokenInfo(auth.TokenInfoFromContext(r.Context())); principal != nil {
+			r = r.WithContext(ContextWithPrincipal(r.Context(), principal))
+		}
+		handler.ServeHTTP(w, r)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opts := &auth.RequireBearerTokenOptions{}
+		if s.options.ProtectedResource != nil {
+			opts.ResourceMetadataURL = s.requestBaseURL(r) + s.protectedResourcePath()
+		}
+		auth.RequireBearerToken(s.verifyToken, opts)(withPrincipal).ServeHTTP(w, r)
+	})
+}
+
+// principalFromTokenInfo returns the principal recorded by verifyToken, or nil
+func principalFromTokenInfo(tokenInfo *auth.TokenInfo) *Principal {
+	if tokenInfo == nil {
+		return nil
+	}
+	principal, _ := tokenInfo.Extra[principalTokenInfoKey].(*Principal)
+	return principal
+}
+
+// subjectTokenFromTokenInfo returns the bearer token if it was verified as a JWT, or ""
+func subjectTokenFromTokenInfo(tokenInfo *auth.TokenInfo) string {
+	if tokenInfo == nil {
+		return ""
+	}
+	token, _ := tokenInfo.Extra[subjectTokenInfoKey].(string)
+	return token
+}
+
+// mcpPath returns the path of the MCP endpoint of the server
+func (s *MCPGraphQLServer) mcpPath() string {
+	if s.mountPath == "" {
+		return "/mcp"
+	}
+	return s.mountPath
+}
+
+// protectedResourcePath returns where the protected resource metadata of the server is served;
+// profiles append their path, as RFC 9728 does for resources with a path component
+func (s *MCPGraphQLServer) protectedResourcePath() string {
+	return ProtectedResourcePath + s.mountPath
+}
+
+// requestBaseURL returns the scheme and host the request was sent to
+// X-Forwarded-Proto is only trusted when the server is configured to run behind a proxy, and only
+// for the http and https schemes
+func (s *MCPGraphQLServer) requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if s.options.TrustForwardedProto {
+		if forwarded := strings.ToLower(r.Header.Get("X-Forwarded-Proto")); forwarded == "http" || forwarded == "https" {
+			scheme = forwarded
+		}
+	}
+	return scheme + "://" + r.Host
+}
+
+// GetProtectedResourceMetadataHandler returns a handler serving the OAuth protected resource metadata
+func GetProtectedResourceMetadataHandler(server *MCPGraphQLServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if server.options.ProtectedResource == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		metadata := *server.options.ProtectedResource
+		if metadata.Resource == "" {
+			metadata.Resource = server.requestBaseURL(r) + server.mcpPath()
+		}
+		if len(metadata.BearerMethodsSupported) == 0 {
+			metadata.BearerMethodsSupported = []string{"header"}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		json.NewEncoder(w).Encode(metadata)
+	}
+}
+
+// APIKey is a static API key, stored as the hex SHA-256 hash of the key
+type APIKey struct {
+	// Name identifies the key holder and becomes the subject of the principal
+	Name string `json:"name"`
+	// SHA256 is the hex-encoded SHA-256 hash of the key
+	SHA256 string `json:"sha256"`
+	// Roles are granted to callers presenting the key
+	Roles []string `json:"roles,omitempty"`
+}
+
+// APIKeyAuthenticator accepts bearer tokens that match one of a set of hashed API keys
+type APIKeyAuthenticator struct {
+	keys   []APIKey
+	hashes [][]byte
+}
+
+// NewAPIKeyAuthenticator creates an authenticator for the given hashed API keys
+func NewAPIKeyAuthenticator(keys []APIKey) (*APIKeyAuthenticator, error) {
+	authenticator := &APIKeyAuthenticator{keys: keys}
+	for i, key := range keys {
+		if key.Name == "" {
+			return nil, fmt.Errorf("API key %d: missing name", i)
+		}
+		hash, err := hex.DecodeString(key.SHA256)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("API key %q: sha256 must be a hex-encoded SHA-256 hash", key.Name)
+		}
+		authenticator.hashes = append(authenticator.hashes, hash)
+	}
+	return authenticator, nil
+}
+
+// LoadAPIKeyFile loads hashed API keys from a JSON file such as:
+//
+//	{"keys": [{"name": "reporting", "sha256": "9f86d0...", "roles": ["viewer"]}]}
+func LoadAPIKeyFile(path string) (*APIKeyAuthenticator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API key file: %w", err)
+	}
+
+	var file struct {
+		Keys []APIKey `json:"keys"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse API key file %s: %w", path, err)
+	}
+
+	authenticator, err := NewAPIKeyAuthenticator(file.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API key file %s: %w", path, err)
+	}
+	return authenticator, nil
+}
+
+// HashAPIKey returns the hex-encoded SHA-256 hash of an API key, as stored in API key files
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// Authenticate compares the hash of the token with every key in constant time
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, token string, r *http.Request) (*Principal, *auth.TokenInfo, error) {
+	hash := sha256.Sum256([]byte(token))
+
+	match := -1
+	for i, keyHash := range a.hashes {
+		if subtle.ConstantTimeCompare(hash[:], keyHash) == 1 {
+			match = i
+		}
+	}
+	if match < 0 {
+		return nil, nil, fmt.Errorf("%w: unknown API key", auth.ErrInvalidToken)
+	}
+
+	key := a.keys[match]
+	principal := &Principal{
+		Subject: key.Name,
+		Roles:   key.Roles,
+		Claims:  map[string]interface{}{"auth_method": "api_key"},
+	}
+	// API keys do not expire; the token info only lives as long as the request
+	return principal, &auth.TokenInfo{Expiration: time.Now().Add(time.Hour)}, nil
+}
+
+// requestPrincipal returns the principal of an MCP request, preferring the per-request token
+// info over the principal the session was created with
+func requestPrincipal(ctx context.Context, extra *mcp.RequestExtra) *Principal {
+	if extra != nil {
+		if principal := principalFromTokenInfo(extra.TokenInfo); principal != nil {
+			return principal
+		}
+	}
+	return PrincipalFromContext(ctx)
+}
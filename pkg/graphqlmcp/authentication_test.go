@@ -0,0 +1,519 @@
+package graphqlmcp
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "https://mcp.example.com/mcp"
+)
+
+// rsaJWKS returns a JWKS document with the public part of an RSA key
+func rsaJWKS(t *testing.T, kid string, key *rsa.PrivateKey) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{
+		"keys": []interface{}{map[string]interface{}{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	require.NoError(t, err)
+	return data
+}
+
+// signJWT creates a JWT signed with RS256 or ES256 depending on the key
+func signJWT(t *testing.T, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	t.Helper()
+	alg := "RS256"
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		alg = "ES256"
+	}
+
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := encode(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		require.NoError(t, err)
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// validClaims returns claims accepted by the test JWT authenticator
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":   testIssuer,
+		"aud":   []string{testAudience},
+		"sub":   "alice",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"viewer"},
+		"scope": "graphql:read graphql:write",
+	}
+}
+
+// newTestJWTAuthenticator creates a JWT authenticator trusting a new RSA key with ID key-1
+func newTestJWTAuthenticator(t *testing.T) (*JWTAuthenticator, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, rsaJWKS(t, "key-1", key), 0o600))
+	authenticator, err := NewJWTAuthenticator(JWTAuthenticatorOptions{
+		Issuer:   testIssuer,
+		Audience: testAudience,
+		JWKSFile: jwksFile,
+	})
+	require.NoError(t, err)
+	return authenticator, key
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, rsaJWKS(t, "key-1", key), 0o600))
+
+	authenticator, err := NewJWTAuthenticator(JWTAuthenticatorOptions{
+		Issuer:   testIssuer,
+		Audience: testAudience,
+		JWKSFile: jwksFile,
+	})
+	require.NoError(t, err)
+
+	withClaim := func(name string, value interface{}) map[string]interface{} {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		expected string
+	}{
+		{name: "valid", token: signJWT(t, "key-1", key, validClaims())},
+		{name: "not a JWT", token: "opaque-api-key", expected: "token is not a JWT"},
+		{name: "unknown signer", token: signJWT(t, "key-1", otherKey, validClaims()), expected: `JWT signature could not be verified (alg "RS256", kid "key-1")`},
+		{name: "expired", token: signJWT(t, "key-1", key, withClaim("exp", time.Now().Add(-time.Hour).Unix())), expected: "JWT expired at"},
+		{name: "missing exp", token: signJWT(t, "key-1", key, withClaim("exp", nil)), expected: "JWT has no exp claim"},
+		{name: "wrong issuer", token: signJWT(t, "key-1", key, withClaim("iss", "https://evil.example.com")), expected: `JWT issuer "https://evil.example.com" is not trusted`},
+		{name: "wrong audience", token: signJWT(t, "key-1", key, withClaim("aud", "https://other.example.com")), expected: `JWT audience does not include "https://mcp.example.com/mcp"`},
+		{name: "single audience", token: signJWT(t, "key-1", key, withClaim("aud", testAudience))},
+		{name: "audience with spaces", token: signJWT(t, "key-1", key, withClaim("aud", "https://other.example.com "+testAudience)), expected: "JWT audience does not include"},
+		{
+			name:     "alg none",
+			token:    base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + strings.Split(signJWT(t, "key-1", key, validClaims()), ".")[1] + ".",
+			expected: `JWT signature could not be verified (alg "none", kid "")`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, tokenInfo, err := authenticator.Authenticate(context.Background(), tt.token, nil)
+			if tt.expected != "" {
+				assert.ErrorIs(t, err, auth.ErrInvalidToken)
+				assert.ErrorContains(t, err, tt.expected)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "alice", principal.Subject)
+			assert.Equal(t, []string{"viewer"}, principal.Roles)
+			assert.Equal(t, []string{"graphql:read", "graphql:write"}, tokenInfo.Scopes)
+			assert.True(t, tokenInfo.Expiration.After(time.Now()))
+			assert.Equal(t, tt.token, subjectTokenFromTokenInfo(tokenInfo))
+		})
+	}
+}
+
+func TestJWTAuthenticator_JWKSURL(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	fetches := 0
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []interface{}{map[string]interface{}{
+				"kty": "EC",
+				"kid": "ec-1",
+				"crv": "P-256",
+				"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+				"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+			}},
+		})
+	}))
+	defer jwksServer.Close()
+
+	authenticator, err := NewJWTAuthenticator(JWTAuthenticatorOptions{
+		Issuer:   testIssuer,
+		Audience: testAudience,
+		JWKSURL:  jwksServer.URL,
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		principal, _, err := authenticator.Authenticate(context.Background(), signJWT(t, "ec-1", key, validClaims()), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", principal.Subject)
+	}
+	assert.Equal(t, 1, fetches, "keys should be cached")
+
+	// Unknown key IDs only trigger a refetch once the minimum interval has passed
+	_, _, err = authenticator.Authenticate(context.Background(), signJWT(t, "ec-2", key, validClaims()), nil)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	assert.Equal(t, 1, fetches)
+
+	authenticator.now = func() time.Time { return time.Now().Add(2 * jwksMinRefetchInterval) }
+	_, _, err = authenticator.Authenticate(context.Background(), signJWT(t, "ec-2", key, validClaims()), nil)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	assert.Equal(t, 2, fetches)
+}
+
+func TestJWTAuthenticator_SharedJWKSFetch(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		w.Write(rsaJWKS(t, "key-1", key))
+	}))
+	defer jwksServer.Close()
+
+	authenticator, err := NewJWTAuthenticator(JWTAuthenticatorOptions{
+		Issuer:   testIssuer,
+		Audience: testAudience,
+		JWKSURL:  jwksServer.URL,
+	})
+	require.NoError(t, err)
+
+	// Concurrent requests wait for a single fetch, which does not hold the lock of the key cache
+	// and keeps running when the request that started it is cancelled
+	token := signJWT(t, "key-1", key, validClaims())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, _, err := authenticator.Authenticate(ctx, token, nil)
+		cancelled <- err
+	}()
+	require.Eventually(t, func() bool { return fetches.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.True(t, authenticator.mu.TryLock())
+	authenticator.mu.Unlock()
+
+	errs := make(chan error, 5)
+	for range 5 {
+		go func() {
+			_, _, err := authenticator.Authenticate(context.Background(), token, nil)
+			errs <- err
+		}()
+	}
+	cancel()
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+
+	close(release)
+	for range 5 {
+		assert.NoError(t, <-errs)
+	}
+	assert.Equal(t, int32(1), fetches.Load())
+}
+
+func TestJWTAuthenticator_JWKSOutage(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var fetches atomic.Int32
+	var failing atomic.Bool
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(rsaJWKS(t, "key-1", key))
+	}))
+	defer jwksServer.Close()
+
+	authenticator, err := NewJWTAuthenticator(JWTAuthenticatorOptions{
+		Issuer:              testIssuer,
+		Audience:            testAudience,
+		JWKSURL:             jwksServer.URL,
+		JWKSRefreshInterval: 5 * time.Minute,
+	})
+	require.NoError(t, err)
+
+	token := signJWT(t, "key-1", key, validClaims())
+	_, _, err = authenticator.Authenticate(context.Background(), token, nil)
+	require.NoError(t, err)
+
+	// While the issuer is unavailable the stale keys are used, and a failed fetch is not retried
+	// for every request, even for unknown key IDs
+	failing.Store(true)
+	start := time.Now()
+	authenticator.now = func() time.Time { return start.Add(10 * time.Minute) }
+	for range 3 {
+		_, _, err = authenticator.Authenticate(context.Background(), token, nil)
+		assert.NoError(t, err)
+	}
+	_, _, err = authenticator.Authenticate(context.Background(), signJWT(t, "key-2", key, validClaims()), nil)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	assert.Equal(t, int32(2), fetches.Load())
+
+	authenticator.now = func() time.Time { return start.Add(10*time.Minute + 2*jwksMinRefetchInterval) }
+	_, _, err = authenticator.Authenticate(context.Background(), token, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), fetches.Load())
+}
+
+func TestJWTAuthenticator_ECDSACurve(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwks, err := json.Marshal(map[string]interface{}{
+		"keys": []interface{}{map[string]interface{}{
+			"kty": "EC",
+			"kid": "ec-1",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		}},
+	})
+	require.NoError(t, err)
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, jwks, 0o600))
+
+	authenticator, err := NewJWTAuthenticator(JWTAuthenticatorOptions{
+		Issuer:   testIssuer,
+		Audience: testAudience,
+		JWKSFile: jwksFile,
+	})
+	require.NoError(t, err)
+
+	// A P-256 key only verifies ES256 signatures, even when a signature with another hash fits it
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := encode(map[string]string{"alg": "ES512", "kid": "ec-1"}) + "." + encode(validClaims())
+	digest := sha512.Sum512([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	_, _, err = authenticator.Authenticate(context.Background(), signed+"."+base64.RawURLEncoding.EncodeToString(signature), nil)
+	assert.ErrorContains(t, err, `JWT signature could not be verified (alg "ES512", kid "ec-1")`)
+
+	_, _, err = authenticator.Authenticate(context.Background(), signJWT(t, "ec-1", key, validClaims()), nil)
+	assert.NoError(t, err)
+}
+
+func TestLoadAPIKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"keys": [
+		{"name": "reporting", "sha256": "`+HashAPIKey("secret-1")+`", "roles": ["viewer"]},
+		{"name": "automation", "sha256": "`+HashAPIKey("secret-2")+`", "roles": ["operator"]}
+	]}`), 0o600))
+
+	authenticator, err := LoadAPIKeyFile(path)
+	require.NoError(t, err)
+
+	principal, tokenInfo, err := authenticator.Authenticate(context.Background(), "secret-2", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "automation", principal.Subject)
+	assert.Equal(t, []string{"operator"}, principal.Roles)
+	assert.False(t, tokenInfo.Expiration.IsZero())
+
+	_, _, err = authenticator.Authenticate(context.Background(), "secret-3", nil)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"keys": [{"name": "plain", "sha256": "secret-1"}]}`), 0o600))
+	_, err = LoadAPIKeyFile(path)
+	assert.ErrorContains(t, err, `API key "plain": sha256 must be a hex-encoded SHA-256 hash`)
+}
+
+func TestMCPGraphQLServer_Authentication(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	apiKeys, err := NewAPIKeyAuthenticator([]APIKey{{Name: "reporting", SHA256: HashAPIKey("secret"), Roles: []string{"viewer"}}})
+	require.NoError(t, err)
+	policy, err := NewRolePolicy(map[string]RoleRules{"viewer": {Allow: []string{"^query_"}}})
+	require.NoError(t, err)
+
+	// The verified principal reaches the executor through the context
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.MatchedBy(func(ctx context.Context) bool {
+		principal := PrincipalFromContext(ctx)
+		return principal != nil && principal.Subject == "reporting"
+	}), mock.AnythingOfType("string"), mock.Anything).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"facilities": []interface{}{}}}, nil).Once()
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor,
+		WithAuthenticators(apiKeys),
+		WithAuthorizationPolicy(policy),
+		WithProtectedResourceMetadata(ProtectedResourceMetadata{AuthorizationServers: []string{testIssuer}}),
+	)
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(GetCompleteMux(server))
+	t.Cleanup(httpServer.Close)
+
+	// Unauthenticated callers are pointed to the protected resource metadata
+	resp, err := http.Post(httpServer.URL+"/mcp", "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "Bearer resource_metadata="+httpServer.URL+ProtectedResourcePath, resp.Header.Get("WWW-Authenticate"))
+
+	resp, err = http.Get(httpServer.URL + "/tools")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = http.Get(httpServer.URL + ProtectedResourcePath)
+	require.NoError(t, err)
+	var metadata ProtectedResourceMetadata
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&metadata))
+	resp.Body.Close()
+	assert.Equal(t, httpServer.URL+"/mcp", metadata.Resource)
+	assert.Equal(t, []string{testIssuer}, metadata.AuthorizationServers)
+
+	// Authenticated callers get the tools of their roles
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(context.Background(), &mcp.StreamableClientTransport{
+		Endpoint: httpServer.URL + "/mcp",
+		HTTPClient: &http.Client{Transport: &headerTransport{
+			header: http.Header{"Authorization": []string{"Bearer secret"}},
+		}},
+	}, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { session.Close() })
+
+	tools, err := session.ListTools(context.Background(), nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, tools.Tools)
+	for _, tool := range tools.Tools {
+		assert.True(t, strings.HasPrefix(tool.Name, "query_"), tool.Name)
+	}
+
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "query_facilities", Arguments: map[string]interface{}{}})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	mockExecutor.AssertExpectations(t)
+}
+
+func TestMCPGraphQLServer_RequestBaseURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		trusted   bool
+		forwarded string
+		expected  string
+	}{
+		{name: "untrusted proxy header", forwarded: "https", expected: "http://mcp.example.com"},
+		{name: "trusted proxy header", trusted: true, forwarded: "HTTPS", expected: "https://mcp.example.com"},
+		{name: "unsupported scheme", trusted: true, forwarded: "javascript", expected: "http://mcp.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &MCPGraphQLServer{options: &MCPGraphQLServerOptions{TrustForwardedProto: tt.trusted}}
+			r := httptest.NewRequest(http.MethodGet, "http://mcp.example.com/mcp", nil)
+			r.Header.Set("X-Forwarded-Proto", tt.forwarded)
+			assert.Equal(t, tt.expected, server.requestBaseURL(r))
+		})
+	}
+}
+
+func TestMCPGraphQLServer_SubjectToken(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	jwtAuthenticator, key := newTestJWTAuthenticator(t)
+	apiKeys, err := NewAPIKeyAuthenticator([]APIKey{{Name: "reporting", SHA256: HashAPIKey("secret")}})
+	require.NoError(t, err)
+
+	var subjectTokens []string
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+		Run(func(args mock.Arguments) {
+			subjectTokens = append(subjectTokens, SubjectTokenFromContext(args.Get(0).(context.Context)))
+		}).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"facilities": []interface{}{}}}, nil)
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor, WithAuthenticators(apiKeys, jwtAuthenticator))
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(GetCompleteMux(server))
+	t.Cleanup(httpServer.Close)
+
+	// Only bearer tokens verified as a JWT are passed on for token exchange
+	jwt := signJWT(t, "key-1", key, validClaims())
+	for _, token := range []string{jwt, "secret"} {
+		client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+		session, err := client.Connect(context.Background(), &mcp.StreamableClientTransport{
+			Endpoint: httpServer.URL + "/mcp",
+			HTTPClient: &http.Client{Transport: &headerTransport{
+				header: http.Header{"Authorization": []string{"Bearer " + token}},
+			}},
+		}, nil)
+		require.NoError(t, err)
+
+		result, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "query_facilities", Arguments: map[string]interface{}{}})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		require.NoError(t, session.Close())
+	}
+	assert.Equal(t, []string{jwt, ""}, subjectTokens)
+}
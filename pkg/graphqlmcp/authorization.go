@@ -0,0 +1,301 @@
+package graphqlmcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Principal is the verified identity of the caller of an MCP request
+type Principal struct {
+	// Subject identifies the caller, such as the "sub" claim of a JWT or the name of an API key
+	Subject string `json:"subject"`
+	// Roles are the roles granted to the caller
+	Roles []string `json:"roles,omitempty"`
+	// Claims holds additional attributes of the caller, such as the claims of a JWT
+	Claims map[string]interface{} `json:"claims,omitempty"`
+}
+
+// principalContextKey is the context key for the verified principal
+type principalContextKey struct{}
+
+// ContextWithPrincipal adds the verified principal to the context
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the verified principal from the context, or nil if the caller
+// was not authenticated
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalContextKey{}).(*Principal)
+	return principal
+}
+
+// AuthorizationRequest describes the caller of an MCP request for an AuthorizationPolicy
+type AuthorizationRequest struct {
+	// SessionID is the MCP session ID, empty for transports without sessions
+	SessionID string
+	// Principal is the verified identity of the caller, nil if the caller was not authenticated
+	Principal *Principal
+	// PassthruHeaders holds the configured passthru headers of the request
+	PassthruHeaders map[string]string
+	// Header holds all HTTP headers of the request, nil for transports other than HTTP
+	Header http.Header
+}
+
+// AuthorizationPolicy decides which tools a caller may list and call
+// It is consulted for every tools/list and tools/call request, so decisions can differ per session
+type AuthorizationPolicy interface {
+	// CanListTool reports whether the tool is included in tools/list for the caller
+	CanListTool(ctx context.Context, req *AuthorizationRequest, toolName string) bool
+	// AuthorizeToolCall returns an error explaining why the caller may not call the tool, or nil
+	AuthorizeToolCall(ctx context.Context, req *AuthorizationRequest, toolName string) error
+}
+
+// RoleRules lists the tool name patterns granted and withheld by a role
+type RoleRules struct {
+	// Allow holds regular expressions for the tool names the role may use
+	Allow []string `json:"allow"`
+	// Deny holds regular expressions for tool names the role may never use, even if allowed
+	// by another role
+	Deny []string `json:"deny,omitempty"`
+}
+
+// RolePolicy is an AuthorizationPolicy that maps roles to tool name patterns
+// A tool is permitted when one of the caller's roles allows it and none of them denies it
+type RolePolicy struct {
+	// Roles maps role names to their rules
+	Roles map[string]RoleRules `json:"roles"`
+	// DefaultRoles are granted to every caller, including unauthenticated ones
+	DefaultRoles []string `json:"defaultRoles,omitempty"`
+	// RoleHeader names a request header with comma-separated roles, used when the caller has no
+	// verified principal. Only set it when a trusted proxy in front of the server sets the header
+	RoleHeader string `json:"roleHeader,omitempty"`
+
+	allow map[string][]*regexp.Regexp
+	deny  map[string][]*regexp.Regexp
+}
+
+// NewRolePolicy creates a role policy, compiling the patterns of every role
+func NewRolePolicy(roles map[string]RoleRules, defaultRoles ...string) (*RolePolicy, error) {
+	policy := &RolePolicy{
+		Roles:        roles,
+		DefaultRoles: defaultRoles,
+	}
+	if err := policy.compile(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// LoadRolePolicyFile loads a role policy from a JSON file such as:
+//
+//	{
+//	  "roleHeader": "X-User-Roles",
+//	  "defaultRoles": ["viewer"],
+//	  "roles": {
+//	    "viewer": {"allow": ["^query_"]},
+//	    "operator": {"allow": ["^query_", "^mutation_update"], "deny": ["^mutation_delete"]}
+//	  }
+//	}
+func LoadRolePolicyFile(path string) (*RolePolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read role policy file: %w", err)
+	}
+
+	var policy RolePolicy
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("failed to parse role policy file %s: %w", path, err)
+	}
+	if err := policy.compile(); err != nil {
+		return nil, fmt.Errorf("invalid role policy file %s: %w", path, err)
+	}
+	return &policy, nil
+}
+
+// compile compiles the patterns of every role and checks that default roles are defined
+func (p *RolePolicy) compile() error {
+	p.allow = make(map[string][]*regexp.Regexp, len(p.Roles))
+	p.deny = make(map[string][]*regexp.Regexp, len(p.Roles))
+	for role, rules := range p.Roles {
+		for _, pattern := range rules.Allow {
+			compiled, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("role %q: invalid allow pattern %q: %w", role, pattern, err)
+			}
+			p.allow[role] = append(p.allow[role], compiled)
+		}
+		for _, pattern := range rules.Deny {
+			compiled, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("role %q: invalid deny pattern %q: %w", role, pattern, err)
+			}
+			p.deny[role] = append(p.deny[role], compiled)
+		}
+	}
+
+	for _, role := range p.DefaultRoles {
+		if _, ok := p.Roles[role]; !ok {
+			return fmt.Errorf("default role %q is not defined", role)
+		}
+	}
+	return nil
+}
+
+// RolesFor returns the sorted roles of the caller: the default roles plus the roles of the
+// principal, or of the role header for callers without a principal
+func (p *RolePolicy) RolesFor(req *AuthorizationRequest) []string {
+	seen := make(map[string]bool)
+	var roles []string
+	add := func(role string) {
+		role = strings.TrimSpace(role)
+		if role != "" && !seen[role] {
+			seen[role] = true
+			roles = append(roles, role)
+		}
+	}
+
+	for _, role := range p.DefaultRoles {
+		add(role)
+	}
+	switch {
+	case req.Principal != nil:
+		for _, role := range req.Principal.Roles {
+			add(role)
+		}
+	case p.RoleHeader != "":
+		value := req.Header.Get(p.RoleHeader)
+		if value == "" {
+			value = req.PassthruHeaders[http.CanonicalHeaderKey(p.RoleHeader)]
+		}
+		for _, role := range strings.Split(value, ",") {
+			add(role)
+		}
+	}
+
+	sort.Strings(roles)
+	return roles
+}
+
+// CanListTool reports whether the caller's roles permit the tool
+func (p *RolePolicy) CanListTool(ctx context.Context, req *AuthorizationRequest, toolName string) bool {
+	return p.AuthorizeToolCall(ctx, req, toolName) == nil
+}
+
+// AuthorizeToolCall returns an error unless one of the caller's roles allows the tool and none denies it
+func (p *RolePolicy) AuthorizeToolCall(ctx context.Context, req *AuthorizationRequest, toolName string) error {
+	roles := p.RolesFor(req)
+	if len(roles) == 0 {
+		return fmt.Errorf("no roles are assigned to the caller")
+	}
+
+	allowed := false
+	for _, role := range roles {
+		for _, pattern := range p.deny[role] {
+			if pattern.MatchString(toolName) {
+				return fmt.Errorf("denied for role %q", role)
+			}
+		}
+		for _, pattern := range p.allow[role] {
+			if pattern.MatchString(toolName) {
+				allowed = true
+			}
+		}
+	}
+
+	if !allowed {
+		return fmt.Errorf("not granted to roles %s", strings.Join(roles, ", "))
+	}
+	return nil
+}
+
+// authorizationRequest describes the caller of an MCP request
+func (s *MCPGraphQLServer) authorizationRequest(ctx context.Context, req mcp.Request) *AuthorizationRequest {
+	authReq := &AuthorizationRequest{
+		Principal:       requestPrincipal(ctx, req.GetExtra()),
+		PassthruHeaders: GetPassthruHeaders(ctx),
+	}
+	if session := req.GetSession(); session != nil {
+		authReq.SessionID = session.ID()
+	}
+	if extra := req.GetExtra(); extra != nil && extra.Header != nil {
+		authReq.Header = extra.Header
+		authReq.PassthruHeaders = s.passthruHeadersFrom(extra.Header)
+	}
+	return authReq
+}
+
+// authorizationMiddleware filters tools/list and rejects unauthorized tools/call requests
+func (s *MCPGraphQLServer) authorizationMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		policy := s.options.AuthorizationPolicy
+
+		switch method {
+		case "tools/call":
+			params, ok := req.GetParams().(*mcp.CallToolParamsRaw)
+			if !ok {
+				break
+			}
+			authReq := s.authorizationRequest(ctx, req)
+			if err := policy.AuthorizeToolCall(ctx, authReq, params.Name); err != nil {
+				s.logger.Info("Tool call rejected by authorization policy",
+					"tool_name", params.Name,
+					"session_id", authReq.SessionID,
+					"reason", err.Error(),
+				)
+				s.auditDeniedCall(ctx, req, params, AuditErrorUnauthorized)
+				return nil, fmt.Errorf("tool %q is not authorized for this session: %w", params.Name, err)
+			}
+
+		case "tools/list":
+			result, err := next(ctx, method, req)
+			if err != nil {
+				return result, err
+			}
+			listResult, ok := result.(*mcp.ListToolsResult)
+			if !ok {
+				return result, nil
+			}
+			authReq := s.authorizationRequest(ctx, req)
+			tools := make([]*mcp.Tool, 0, len(listResult.Tools))
+			for _, tool := range listResult.Tools {
+				if policy.CanListTool(ctx, authReq, tool.Name) {
+					tools = append(tools, tool)
+				}
+			}
+			listResult.Tools = tools
+			return listResult, nil
+		}
+
+		return next(ctx, method, req)
+	}
+}
+
+// toolListFilter returns a function reporting whether the caller of an HTTP request may see a tool
+func (s *MCPGraphQLServer) toolListFilter(r *http.Request) func(toolName string) bool {
+	policy := s.options.AuthorizationPolicy
+	if policy == nil {
+		return func(string) bool { return true }
+	}
+
+	authReq := &AuthorizationRequest{
+		SessionID:       r.Header.Get("Mcp-Session-Id"),
+		Principal:       PrincipalFromContext(r.Context()),
+		PassthruHeaders: s.ExtractPassthruHeaders(r),
+		Header:          r.Header,
+	}
+	return func(toolName string) bool {
+		return policy.CanListTool(r.Context(), authReq, toolName)
+	}
+}
@@ -0,0 +1,185 @@
+package graphqlmcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// headerTransport adds fixed headers to every request of an HTTP client
+type headerTransport struct {
+	header http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range t.header {
+		req.Header[name] = values
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// writePolicyFile writes a role policy file to a temporary directory
+func writePolicyFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+const testRolePolicy = `{
+	"roleHeader": "X-User-Roles",
+	"roles": {
+		"viewer": {"allow": ["^query_"]},
+		"operator": {"allow": ["^query_", "^mutation_update"], "deny": ["^query_operationalMetrics$"]}
+	}
+}`
+
+func TestRolePolicy(t *testing.T) {
+	policy, err := LoadRolePolicyFile(writePolicyFile(t, testRolePolicy))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	headerRoles := func(roles string) *AuthorizationRequest {
+		return &AuthorizationRequest{Header: http.Header{"X-User-Roles": []string{roles}}}
+	}
+
+	tests := []struct {
+		name     string
+		req      *AuthorizationRequest
+		toolName string
+		expected string
+	}{
+		{name: "viewer query", req: headerRoles("viewer"), toolName: "query_equipment"},
+		{name: "viewer mutation", req: headerRoles("viewer"), toolName: "mutation_updateEquipment", expected: "not granted to roles viewer"},
+		{name: "operator mutation", req: headerRoles("operator"), toolName: "mutation_updateEquipment"},
+		{name: "deny wins over allow", req: headerRoles("viewer, operator"), toolName: "query_operationalMetrics", expected: `denied for role "operator"`},
+		{name: "no roles", req: &AuthorizationRequest{}, toolName: "query_equipment", expected: "no roles are assigned to the caller"},
+		{
+			name:     "principal roles replace the header",
+			req:      &AuthorizationRequest{Principal: &Principal{Subject: "svc", Roles: []string{"viewer"}}, Header: http.Header{"X-User-Roles": []string{"operator"}}},
+			toolName: "mutation_updateEquipment",
+			expected: "not granted to roles viewer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.AuthorizeToolCall(ctx, tt.req, tt.toolName)
+			if tt.expected == "" {
+				assert.NoError(t, err)
+				assert.True(t, policy.CanListTool(ctx, tt.req, tt.toolName))
+				return
+			}
+			assert.EqualError(t, err, tt.expected)
+			assert.False(t, policy.CanListTool(ctx, tt.req, tt.toolName))
+		})
+	}
+}
+
+func TestLoadRolePolicyFile_Invalid(t *testing.T) {
+	_, err := LoadRolePolicyFile(writePolicyFile(t, `{"roles": {"viewer": {"allow": ["("]}}}`))
+	assert.ErrorContains(t, err, `role "viewer": invalid allow pattern "("`)
+
+	_, err = LoadRolePolicyFile(writePolicyFile(t, `{"roles": {}, "defaultRole": "viewer"}`))
+	assert.ErrorContains(t, err, `unknown field "defaultRole"`)
+
+	_, err = LoadRolePolicyFile(writePolicyFile(t, `{"roles": {}, "defaultRoles": ["viewer"]}`))
+	assert.ErrorContains(t, err, `default role "viewer" is not defined`)
+}
+
+func TestMCPGraphQLServer_AuthorizationPolicy(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"equipment": []interface{}{}}}, nil)
+
+	policy, err := LoadRolePolicyFile(writePolicyFile(t, testRolePolicy))
+	require.NoError(t, err)
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor, WithAuthorizationPolicy(policy))
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(GetCompleteMux(server))
+	t.Cleanup(httpServer.Close)
+
+	connect := func(roles string) *mcp.ClientSession {
+		client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+		session, err := client.Connect(context.Background(), &mcp.StreamableClientTransport{
+			Endpoint: httpServer.URL + "/mcp",
+			HTTPClient: &http.Client{Transport: &headerTransport{
+				header: http.Header{"X-User-Roles": []string{roles}},
+			}},
+		}, nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { session.Close() })
+		return session
+	}
+
+	viewer := connect("viewer")
+	operator := connect("operator")
+
+	toolNames := func(session *mcp.ClientSession) []string {
+		result, err := session.ListTools(context.Background(), nil)
+		require.NoError(t, err)
+		names := make([]string, len(result.Tools))
+		for i, tool := range result.Tools {
+			names[i] = tool.Name
+		}
+		return names
+	}
+
+	// Each session only sees the tools its roles grant
+	viewerTools := toolNames(viewer)
+	assert.Contains(t, viewerTools, "query_equipment")
+	assert.Contains(t, viewerTools, "query_operationalMetrics")
+	assert.NotContains(t, viewerTools, "mutation_updateEquipment")
+
+	operatorTools := toolNames(operator)
+	assert.Contains(t, operatorTools, "mutation_updateEquipment")
+	assert.NotContains(t, operatorTools, "query_operationalMetrics")
+	assert.NotContains(t, operatorTools, "mutation_deleteEquipment")
+
+	// Calls are checked as well, not just the listing
+	_, err = viewer.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "mutation_updateEquipmentStatus",
+		Arguments: map[string]interface{}{"id": "1", "status": "OPERATIONAL"},
+	})
+	assert.ErrorContains(t, err, `tool "mutation_updateEquipmentStatus" is not authorized for this session: not granted to roles viewer`)
+
+	result, err := viewer.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "query_equipment",
+		Arguments: map[string]interface{}{},
+	})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+
+	// The /tools endpoint applies the same policy
+	req, err := http.NewRequest(http.MethodGet, httpServer.URL+"/tools", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-User-Roles", "viewer")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var toolsResponse struct {
+		Tools []struct {
+			Name string `json:"name"`
+		} `json:"tools"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&toolsResponse))
+	assert.NotEmpty(t, toolsResponse.Tools)
+	for _, tool := range toolsResponse.Tools {
+		assert.Regexp(t, "^query_", tool.Name)
+	}
+}
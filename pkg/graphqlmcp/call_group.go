@@ -0,0 +1,56 @@
+package graphqlmcp
+
+import (
+	"context"
+	"sync"
+)
+
+// callGroup runs one call per key at a time and shares its result with the callers that ask for
+// the same key while it runs, like golang.org/x/sync/singleflight
+type callGroup[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*groupCall[T]
+}
+
+// groupCall is a running or finished call of a callGroup
+type groupCall[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// do runs fn for key unless a call for key is already running, and waits for the call's result
+// or for ctx to end. The call runs in its own goroutine and is not cancelled when the caller that
+// started it stops waiting, so fn must not depend on that caller's ctx
+func (g *callGroup[T]) do(ctx context.Context, key string, fn func() (T, error)) (T, error) {
+	g.mu.Lock()
+	call, ok := g.calls[key]
+	if !ok {
+		if g.calls == nil {
+			g.calls = make(map[string]*groupCall[T])
+		}
+		call = &groupCall[T]{done: make(chan struct{})}
+		g.calls[key] = call
+		go g.run(key, call, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// run runs the call for key and shares its result with the callers waiting for it
+func (g *callGroup[T]) run(key string, call *groupCall[T], fn func() (T, error)) {
+	value, err := fn()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	call.value, call.err = value, err
+	close(call.done)
+}
@@ -0,0 +1,648 @@
+package graphqlmcp
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peterbeamish/go-mcp-graphql/pkg/graphqlmcp/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the declarative configuration of an MCP GraphQL server, loaded from a YAML or JSON
+// file with LoadConfigFile. For example:
+//
+//	endpoint: https://api.example.com/graphql
+//	upstream:
+//	  headers:
+//	    X-Client: mcp
+//	  auth:
+//	    bearerToken: ${API_TOKEN}
+//	transport:
+//	  type: http
+//	  addr: ":8081"
+//	mask:
+//	  allow: ["^get", "^list"]
+//	maxDepth: 3
+//	passthru:
+//	  headers: [X-Request-ID]
+//	tools:
+//	  query_equipment:
+//	    description: List the equipment of a facility
+//	    arguments:
+//	      tenantId: {header: X-Tenant-ID}
+//	scalars:
+//	  DateTime: {format: date-time}
+type Config struct {
+	// Endpoint is the URL of the GraphQL server
+	Endpoint string `yaml:"endpoint"`
+	// Upstream configures the requests to the GraphQL server
+	Upstream UpstreamConfig `yaml:"upstream"`
+	// Transport selects how the MCP server is served; it is read by the graphql-mcp command
+	Transport TransportConfig `yaml:"transport"`
+
+	// Mask selects the queries and mutations exposed as tools
+	Mask OperationMaskConfig `yaml:"mask"`
+	// ReadOnly blocks every mutation
+	ReadOnly bool `yaml:"readOnly"`
+	// MaxDepth is the maximum depth of generated selection sets
+	MaxDepth int `yaml:"maxDepth"`
+	// Passthru configures the headers forwarded between MCP and GraphQL requests
+	Passthru PassthruConfig `yaml:"passthru"`
+	// Tools overrides the description, arguments and response budget of tools by tool name;
+	// "*" sets the arguments and response budget of every tool
+	Tools map[string]ToolConfig `yaml:"tools"`
+	// Scalars maps custom scalar names to the schema of their values in tool input schemas
+	Scalars map[string]schema.ScalarSchema `yaml:"scalars"`
+	// Hidden lists the types and fields hidden from everything the model can see
+	Hidden HiddenConfig `yaml:"hidden"`
+}
+
+// UpstreamConfig configures the requests to the GraphQL server
+type UpstreamConfig struct {
+	// Headers are sent with every GraphQL request
+	Headers map[string]string `yaml:"headers"`
+	// Timeout bounds every GraphQL request, e.g. "30s"
+	Timeout time.Duration `yaml:"timeout"`
+	// SchemaFile is loaded with LoadSchemaFile instead of introspecting the GraphQL server
+	SchemaFile string `yaml:"schemaFile"`
+	// Auth supplies the credentials of GraphQL requests
+	Auth *UpstreamAuthConfig `yaml:"auth"`
+}
+
+// UpstreamAuthConfig supplies the credentials of GraphQL requests
+// Exactly one of BearerToken, APIKey, TokenFile and ClientCredentials must be set
+type UpstreamAuthConfig struct {
+	// BearerToken is sent as "Authorization: Bearer <token>"
+	BearerToken string `yaml:"bearerToken"`
+	// APIKey is sent in the APIKeyHeader header
+	APIKey string `yaml:"apiKey"`
+	// APIKeyHeader names the API key header, X-API-Key by default
+	APIKeyHeader string `yaml:"apiKeyHeader"`
+	// TokenFile is a file holding a bearer token, reread when it changes
+	TokenFile string `yaml:"tokenFile"`
+	// ClientCredentials fetches tokens with the OAuth2 client credentials grant
+	ClientCredentials *ClientCredentialsAuthConfig `yaml:"clientCredentials"`
+}
+
+// ClientCredentialsAuthConfig configures the OAuth2 client credentials grant
+type ClientCredentialsAuthConfig struct {
+	TokenURL     string   `yaml:"tokenURL"`
+	ClientID     string   `yaml:"clientID"`
+	ClientSecret string   `yaml:"clientSecret"`
+	Scopes       []string `yaml:"scopes"`
+	Audience     string   `yaml:"audience"`
+}
+
+// TransportConfig selects how the MCP server is served
+type TransportConfig struct {
+	// Type is "stdio" or "http"
+	Type string `yaml:"type"`
+	// Addr is the listen address of the http transport
+	Addr string `yaml:"addr"`
+}
+
+// OperationMaskConfig lists the patterns of the operation mask, see WithMask
+type OperationMaskConfig struct {
+	Allow []string `yaml:"allow"`
+	Block []string `yaml:"block"`
+}
+
+// PassthruConfig configures the headers forwarded between MCP and GraphQL requests
+type PassthruConfig struct {
+	// Headers are forwarded unchanged, see WithPassthruHeaders
+	Headers []string `yaml:"headers"`
+	// Rules forward renamed, templated and cookie values, see WithPassthruRules
+	Rules []PassthruRule `yaml:"rules"`
+	// Deny lists headers that are never passed through, see WithPassthruDeny
+	Deny []string `yaml:"deny"`
+	// ResponseHeaders are copied from GraphQL responses into tool results, see WithResponseHeaders
+	ResponseHeaders []string `yaml:"responseHeaders"`
+}
+
+// ToolConfig overrides the description, arguments and response budget of a tool
+type ToolConfig struct {
+	// Description replaces the description from the GraphQL schema
+	Description string `yaml:"description"`
+	// Arguments fills arguments on the server by argument name, hiding them from the model
+	Arguments map[string]ArgumentConfig `yaml:"arguments"`
+	// ResponseBudget limits the size of the tool results
+	ResponseBudget *ResponseBudget `yaml:"responseBudget"`
+}
+
+// ArgumentConfig fills an argument from exactly one of a passthru header, a token claim and a
+// constant value, see ArgumentOverride
+type ArgumentConfig struct {
+	Header string      `yaml:"header"`
+	Claim  string      `yaml:"claim"`
+	Value  interface{} `yaml:"value"`
+}
+
+// HiddenConfig lists the types and fields hidden from everything the model can see
+type HiddenConfig struct {
+	// Types are hidden along with the fields and operations that depend on them
+	Types []string `yaml:"types"`
+	// Fields are given as "Type.field"
+	Fields []string `yaml:"fields"`
+}
+
+// LoadConfigFile loads a configuration from a YAML or JSON file, expanding ${VAR} references
+// from the environment
+func LoadConfigFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	config, err := ParseConfig(data, os.LookupEnv)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// ParseConfig parses a YAML or JSON configuration. String values may reference environment
+// variables, looked up with lookupEnv, as ${VAR} or ${VAR:-default}; "$${" is a literal "${".
+// Unknown keys, values of the wrong type and invalid settings are reported with their line
+func ParseConfig(data []byte, lookupEnv func(string) (string, bool)) (*Config, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return nil, errors.New("the configuration is empty")
+	}
+	root := document.Content[0]
+
+	if err := expandConfigEnv(root, lookupEnv); err != nil {
+		return nil, err
+	}
+	if err := checkConfigFields(root, reflect.TypeOf(Config{}), ""); err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := root.Decode(&config); err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			return nil, errors.New(strings.Join(typeErr.Errors, "; "))
+		}
+		return nil, err
+	}
+	if err := config.validate(root); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// expandConfigEnv expands the environment variable references in the values of a node tree
+func expandConfigEnv(node *yaml.Node, lookupEnv func(string) (string, bool)) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := expandConfigEnv(node.Content[i], lookupEnv); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if err := expandConfigEnv(item, lookupEnv); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "${") {
+			return nil
+		}
+		value, err := expandEnvReferences(node.Value, lookupEnv)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		node.Value = value
+		// Unquoted values are resolved again, so "${DEPTH}" can fill an integer
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+	}
+	return nil
+}
+
+// envReferencePattern matches valid environment variable names
+var envReferencePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// expandEnvReferences replaces ${VAR} and ${VAR:-default} references in a value
+func expandEnvReferences(value string, lookupEnv func(string) (string, bool)) (string, error) {
+	var expanded strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			expanded.WriteString(value)
+			return expanded.String(), nil
+		}
+		if start > 0 && value[start-1] == '$' {
+			expanded.WriteString(value[:start-1] + "${")
+			value = value[start+2:]
+			continue
+		}
+
+		end := strings.IndexByte(value[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated environment variable reference in %q", value)
+		}
+		reference := value[start+2 : start+end]
+		name, fallback, hasFallback := strings.Cut(reference, ":-")
+		if !envReferencePattern.MatchString(name) {
+			return "", fmt.Errorf("invalid environment variable reference ${%s}", reference)
+		}
+
+		resolved, ok := lookupEnv(name)
+		switch {
+		case hasFallback && resolved == "":
+			resolved = fallback
+		case !ok:
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		expanded.WriteString(value[:start] + resolved)
+		value = value[start+end+1:]
+	}
+}
+
+// checkConfigFields reports keys of a mapping that do not match a field of the target type
+func checkConfigFields(node *yaml.Node, t reflect.Type, path string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			field, ok := configField(t, key.Value)
+			if !ok {
+				return fmt.Errorf("line %d: unknown field %q in %s", key.Line, key.Value, configSection(path))
+			}
+			if err := checkConfigFields(node.Content[i+1], field.Type, joinConfigPath(path, key.Value)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := checkConfigFields(node.Content[i+1], t.Elem(), joinConfigPath(path, node.Content[i].Value)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for i, item := range node.Content {
+			if err := checkConfigFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// configField finds the struct field with the given yaml key
+func configField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		if name == key {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// joinConfigPath appends a key to a dotted configuration path
+func joinConfigPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// configSection names a configuration path in errors
+func configSection(path string) string {
+	if path == "" {
+		return "the configuration"
+	}
+	return path
+}
+
+// configNode returns the node at a path of mapping keys (strings) and sequence indices (ints),
+// or the deepest node on the path that exists
+func configNode(node *yaml.Node, path ...interface{}) *yaml.Node {
+	for _, elem := range path {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		var next *yaml.Node
+		switch elem := elem.(type) {
+		case string:
+			if node.Kind == yaml.MappingNode {
+				for i := 0; i+1 < len(node.Content); i += 2 {
+					if node.Content[i].Value == elem {
+						next = node.Content[i+1]
+						break
+					}
+				}
+			}
+		case int:
+			if node.Kind == yaml.SequenceNode && elem < len(node.Content) {
+				next = node.Content[elem]
+			}
+		}
+		if next == nil {
+			return node
+		}
+		node = next
+	}
+	return node
+}
+
+// configErrorf reports an invalid setting with its line and path
+func configErrorf(root *yaml.Node, path []interface{}, format string, args ...interface{}) error {
+	var name strings.Builder
+	for _, elem := range path {
+		switch elem := elem.(type) {
+		case string:
+			if name.Len() > 0 {
+				name.WriteString(".")
+			}
+			name.WriteString(elem)
+		case int:
+			name.WriteString("[" + strconv.Itoa(elem) + "]")
+		}
+	}
+	return fmt.Errorf("line %d: %s: %s", configNode(root, path...).Line, name.String(), fmt.Sprintf(format, args...))
+}
+
+// configPath builds a configuration path
+func configPath(path ...interface{}) []interface{} {
+	return path
+}
+
+// validate checks the settings that YAML decoding cannot, reporting the line of the first
+// invalid one
+func (c *Config) validate(root *yaml.Node) error {
+	if c.Endpoint != "" {
+		if u, err := url.Parse(c.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return configErrorf(root, configPath("endpoint"), "expected an http or https URL")
+		}
+	}
+	if err := c.Upstream.validate(root); err != nil {
+		return err
+	}
+	switch c.Transport.Type {
+	case "", "stdio", "http":
+	default:
+		return configErrorf(root, configPath("transport", "type"), "unknown transport %q, expected stdio or http", c.Transport.Type)
+	}
+
+	for i, pattern := range c.Mask.Allow {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return configErrorf(root, configPath("mask", "allow", i), "invalid pattern: %v", err)
+		}
+	}
+	for i, pattern := range c.Mask.Block {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return configErrorf(root, configPath("mask", "block", i), "invalid pattern: %v", err)
+		}
+	}
+	if c.MaxDepth < 0 {
+		return configErrorf(root, configPath("maxDepth"), "must not be negative")
+	}
+
+	for i, rule := range c.Passthru.Rules {
+		if err := rule.validate(); err != nil {
+			return configErrorf(root, configPath("passthru", "rules", i), "%v", err)
+		}
+	}
+	options := NewMCPGraphQLServerOptions()
+	for _, opt := range c.Options() {
+		opt(options)
+	}
+	if _, err := compilePassthruRules(options); err != nil {
+		return configErrorf(root, configPath("passthru"), "%v", err)
+	}
+
+	for _, toolName := range sortedKeys(c.Tools) {
+		if err := c.Tools[toolName].validate(root, toolName); err != nil {
+			return err
+		}
+	}
+	for _, scalarName := range sortedKeys(c.Scalars) {
+		if err := (schema.JSONSchemaOptions{Scalars: map[string]schema.ScalarSchema{scalarName: c.Scalars[scalarName]}}).ValidateScalars(); err != nil {
+			return configErrorf(root, configPath("scalars", scalarName), "%v", err)
+		}
+	}
+	return nil
+}
+
+// validate checks the upstream headers, timeout and credentials
+func (u *UpstreamConfig) validate(root *yaml.Node) error {
+	for _, name := range sortedKeys(u.Headers) {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			return configErrorf(root, configPath("upstream", "headers", name), "invalid header name %q", name)
+		}
+	}
+	if u.Timeout < 0 {
+		return configErrorf(root, configPath("upstream", "timeout"), "must not be negative")
+	}
+	if u.Auth == nil {
+		return nil
+	}
+
+	set := 0
+	for _, value := range []bool{u.Auth.BearerToken != "", u.Auth.APIKey != "", u.Auth.TokenFile != "", u.Auth.ClientCredentials != nil} {
+		if value {
+			set++
+		}
+	}
+	if set != 1 {
+		return configErrorf(root, configPath("upstream", "auth"), "exactly one of bearerToken, apiKey, tokenFile and clientCredentials must be set")
+	}
+	if u.Auth.APIKeyHeader != "" && u.Auth.APIKey == "" {
+		return configErrorf(root, configPath("upstream", "auth", "apiKeyHeader"), "requires apiKey")
+	}
+	if cc := u.Auth.ClientCredentials; cc != nil && (cc.TokenURL == "" || cc.ClientID == "") {
+		return configErrorf(root, configPath("upstream", "auth", "clientCredentials"), "tokenURL and clientID are required")
+	}
+	return nil
+}
+
+// validate checks the tool name, arguments and response budget of a tool override
+func (t ToolConfig) validate(root *yaml.Node, toolName string) error {
+	if toolName != AllTools && !strings.HasPrefix(toolName, "query_") && !strings.HasPrefix(toolName, "mutation_") {
+		return configErrorf(root, configPath("tools", toolName), `expected a tool name starting with "query_" or "mutation_", or "*"`)
+	}
+	if toolName == AllTools && t.Description != "" {
+		return configErrorf(root, configPath("tools", toolName, "description"), "only individual tools can have a description")
+	}
+	for _, argument := range sortedKeys(t.Arguments) {
+		a := t.Arguments[argument]
+		set := 0
+		for _, value := range []bool{a.Header != "", a.Claim != "", a.Value != nil} {
+			if value {
+				set++
+			}
+		}
+		if set != 1 {
+			return configErrorf(root, configPath("tools", toolName, "arguments", argument), "exactly one of header, claim and value must be set")
+		}
+	}
+	if b := t.ResponseBudget; b != nil && (b.MaxBytes < 0 || b.MaxTokens < 0) {
+		return configErrorf(root, configPath("tools", toolName, "responseBudget"), "limits must not be negative")
+	}
+	return nil
+}
+
+// Options returns the server options of the configuration, in a fixed order
+func (c *Config) Options() []MCPGraphQLServerOption {
+	var opts []MCPGraphQLServerOption
+	if len(c.Mask.Allow) > 0 || len(c.Mask.Block) > 0 {
+		opts = append(opts, WithMask(c.Mask.Allow, c.Mask.Block))
+	}
+	if c.ReadOnly {
+		opts = append(opts, WithReadOnly())
+	}
+	if c.MaxDepth > 0 {
+		opts = append(opts, WithMaxDepth(c.MaxDepth))
+	}
+
+	if len(c.Passthru.Headers) > 0 {
+		opts = append(opts, WithPassthruHeaders(c.Passthru.Headers))
+	}
+	if len(c.Passthru.Rules) > 0 {
+		opts = append(opts, WithPassthruRules(c.Passthru.Rules...))
+	}
+	if len(c.Passthru.Deny) > 0 {
+		opts = append(opts, WithPassthruDeny(c.Passthru.Deny...))
+	}
+	if len(c.Passthru.ResponseHeaders) > 0 {
+		opts = append(opts, WithResponseHeaders(c.Passthru.ResponseHeaders...))
+	}
+
+	for _, toolName := range sortedKeys(c.Tools) {
+		tool := c.Tools[toolName]
+		if tool.Description != "" {
+			opts = append(opts, WithToolDescription(toolName, tool.Description))
+		}
+		for _, argument := range sortedKeys(tool.Arguments) {
+			a := tool.Arguments[argument]
+			opts = append(opts, WithArgumentOverrides(ArgumentOverride{
+				Tool: toolName, Argument: argument, Header: a.Header, Claim: a.Claim, Value: a.Value,
+			}))
+		}
+		if tool.ResponseBudget != nil {
+			if toolName == AllTools {
+				opts = append(opts, WithResponseBudget(*tool.ResponseBudget))
+			} else {
+				opts = append(opts, WithToolResponseBudget(toolName, *tool.ResponseBudget))
+			}
+		}
+	}
+	for _, scalarName := range sortedKeys(c.Scalars) {
+		opts = append(opts, WithScalarSchema(scalarName, c.Scalars[scalarName]))
+	}
+
+	if len(c.Hidden.Types) > 0 {
+		opts = append(opts, WithHiddenTypes(c.Hidden.Types...))
+	}
+	if len(c.Hidden.Fields) > 0 {
+		opts = append(opts, WithHiddenFields(c.Hidden.Fields...))
+	}
+	return opts
+}
+
+// NewExecutor creates the GraphQL client of the configuration, with the upstream headers,
+// timeout and credentials, reporting the schema file instead of introspecting if one is set
+func (c *Config) NewExecutor() (GraphQLExecutor, error) {
+	if c.Endpoint == "" {
+		return nil, errors.New("the configuration has no endpoint")
+	}
+
+	client := NewGraphQLClient(c.Endpoint)
+	for name, value := range c.Upstream.Headers {
+		client.SetHeader(name, value)
+	}
+	if c.Upstream.Timeout > 0 {
+		client.SetTimeout(c.Upstream.Timeout)
+	}
+
+	if auth := c.Upstream.Auth; auth != nil {
+		switch {
+		case auth.BearerToken != "":
+			client.SetHeader("Authorization", "Bearer "+auth.BearerToken)
+		case auth.APIKey != "":
+			header := auth.APIKeyHeader
+			if header == "" {
+				header = "X-API-Key"
+			}
+			client.SetHeader(header, auth.APIKey)
+		case auth.TokenFile != "":
+			provider, err := NewFileTokenProvider(auth.TokenFile, "")
+			if err != nil {
+				return nil, err
+			}
+			client.SetCredentialProvider(provider)
+		case auth.ClientCredentials != nil:
+			cc := auth.ClientCredentials
+			provider, err := NewClientCredentialsProvider(ClientCredentialsConfig{
+				TokenURL:     cc.TokenURL,
+				ClientID:     cc.ClientID,
+				ClientSecret: cc.ClientSecret,
+				Scopes:       cc.Scopes,
+				Audience:     cc.Audience,
+			})
+			if err != nil {
+				return nil, err
+			}
+			client.SetCredentialProvider(provider)
+		}
+	}
+
+	if c.Upstream.SchemaFile == "" {
+		return client, nil
+	}
+	s, err := LoadSchemaFile(c.Upstream.SchemaFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewStaticSchemaExecutor(client, s), nil
+}
+
+// NewMCPGraphQLServerFromConfig creates an MCP GraphQL server from a configuration
+// The options are applied after those of the configuration, so they replace single settings
+// such as the mask and max depth, and add to lists such as passthru rules
+func NewMCPGraphQLServerFromConfig(config *Config, opts ...MCPGraphQLServerOption) (*MCPGraphQLServer, error) {
+	executor, err := config.NewExecutor()
+	if err != nil {
+		return nil, err
+	}
+	return NewMCPGraphQLServerWithExecutor(executor, append(config.Options(), opts...)...)
+}
@@ -0,0 +1,223 @@
+package graphqlmcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/peterbeamish/go-mcp-graphql/pkg/graphqlmcp/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testEnv returns a lookup function for a fixed environment
+func testEnv(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	data := []byte(`
+endpoint: https://api.example.com/graphql
+upstream:
+  headers:
+    X-Client: mcp
+    X-Literal: "$${NOT_EXPANDED}"
+  timeout: 5s
+  auth:
+    bearerToken: ${API_TOKEN}
+transport:
+  type: http
+  addr: ":9090"
+mask:
+  allow: ["^equipment", "^facilit"]
+  block: ["${BLOCKED:-^delete}"]
+readOnly: true
+maxDepth: ${MAX_DEPTH}
+passthru:
+  headers: [X-Request-ID]
+  rules:
+    - header: X-User-Token
+      as: Authorization
+      template: "Bearer {value}"
+    - cookie: session
+  responseHeaders: [X-RateLimit-*]
+tools:
+  query_equipment:
+    description: List the equipment of a facility
+    arguments:
+      tenantId: {header: X-Request-ID}
+    responseBudget: {maxBytes: 2048}
+  "*":
+    responseBudget: {maxTokens: 1000}
+scalars:
+  DateTime: {format: date-time}
+hidden:
+  types: [Personnel]
+  fields: [Facility.budget]
+`)
+	config, err := ParseConfig(data, testEnv(map[string]string{"API_TOKEN": "secret", "MAX_DEPTH": "4"}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://api.example.com/graphql", config.Endpoint)
+	assert.Equal(t, map[string]string{"X-Client": "mcp", "X-Literal": "${NOT_EXPANDED}"}, config.Upstream.Headers)
+	assert.Equal(t, 5*time.Second, config.Upstream.Timeout)
+	assert.Equal(t, "secret", config.Upstream.Auth.BearerToken)
+	assert.Equal(t, TransportConfig{Type: "http", Addr: ":9090"}, config.Transport)
+	assert.Equal(t, []string{"^delete"}, config.Mask.Block)
+	assert.Equal(t, 4, config.MaxDepth)
+	assert.Equal(t, []PassthruRule{PassthruRename("X-User-Token", "Authorization", "Bearer {value}"), PassthruCookie("session")}, config.Passthru.Rules)
+	assert.Equal(t, map[string]schema.ScalarSchema{"DateTime": {Format: "date-time"}}, config.Scalars)
+
+	options := NewMCPGraphQLServerOptions()
+	for _, opt := range config.Options() {
+		opt(options)
+	}
+	assert.Equal(t, []string{"^equipment", "^facilit"}, options.Mask.AllowList)
+	assert.True(t, options.OperationTypes.DenyMutations)
+	assert.Equal(t, 4, options.MaxDepth)
+	assert.Equal(t, []string{"X-Request-ID"}, options.PassthruHeaders)
+	assert.Equal(t, []string{"X-RateLimit-*"}, options.ResponseHeaders)
+	assert.Equal(t, "List the equipment of a facility", options.ToolDescriptions["query_equipment"])
+	assert.Equal(t, []ArgumentOverride{ArgumentFromHeader("query_equipment", "tenantId", "X-Request-ID")}, options.ArgumentOverrides)
+	assert.Equal(t, ResponseBudget{MaxBytes: 2048}, options.ToolResponseBudgets["query_equipment"])
+	assert.Equal(t, ResponseBudget{MaxTokens: 1000}, options.ResponseBudget)
+	assert.Equal(t, schema.VisibilityFilter{HiddenTypes: []string{"Personnel"}, HiddenFields: []string{"Facility.budget"}}, options.Visibility)
+}
+
+func TestParseConfig_JSON(t *testing.T) {
+	config, err := ParseConfig([]byte(`{
+  "endpoint": "http://localhost:8080/query",
+  "maxDepth": 2,
+  "tools": {"mutation_deleteEquipment": {"arguments": {"confirm": {"value": true}}}}
+}`), testEnv(nil))
+	require.NoError(t, err)
+	assert.Equal(t, 2, config.MaxDepth)
+	assert.Equal(t, true, config.Tools["mutation_deleteEquipment"].Arguments["confirm"].Value)
+
+	_, err = ParseConfig([]byte(`{
+  "endpoint": "http://localhost:8080/query",
+  "maxdepth": 2
+}`), testEnv(nil))
+	assert.ErrorContains(t, err, `line 3: unknown field "maxdepth" in the configuration`)
+}
+
+func TestParseConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{name: "empty", config: "", expected: "the configuration is empty"},
+		{name: "syntax", config: "mask:\n  allow: [\n", expected: "yaml: line"},
+		{name: "unknown nested field", config: "passthru:\n  rules:\n    - header: X-Token\n      rename: Authorization\n", expected: `line 4: unknown field "rename" in passthru.rules[0]`},
+		{name: "wrong type", config: "maxDepth: deep\n", expected: "line 1: cannot unmarshal !!str `deep` into int"},
+		{name: "unset variable", config: "endpoint: ${GRAPHQL_URL}\n", expected: "line 1: environment variable GRAPHQL_URL is not set"},
+		{name: "invalid reference", config: "endpoint: ${GRAPHQL URL}\n", expected: "line 1: invalid environment variable reference ${GRAPHQL URL}"},
+		{name: "endpoint", config: "endpoint: api.example.com\n", expected: "line 1: endpoint: expected an http or https URL"},
+		{name: "transport", config: "transport:\n  type: sse\n", expected: `line 2: transport.type: unknown transport "sse"`},
+		{name: "mask pattern", config: "mask:\n  allow:\n    - ^ok\n    - \"(\"\n", expected: "line 4: mask.allow[1]: invalid pattern"},
+		{name: "max depth", config: "maxDepth: -1\n", expected: "line 1: maxDepth: must not be negative"},
+		{name: "passthru rule", config: "passthru:\n  rules:\n    - {header: X-Token, cookie: session}\n", expected: "line 3: passthru.rules[0]: exactly one of Header and Cookie must be set"},
+		{name: "passthru deny-list", config: "passthru:\n  headers: [Host]\n", expected: "line 2: passthru: invalid passthru rule header Host: Host is on the deny-list"},
+		{name: "auth", config: "upstream:\n  auth:\n    bearerToken: a\n    apiKey: b\n", expected: "line 3: upstream.auth: exactly one of bearerToken, apiKey, tokenFile and clientCredentials must be set"},
+		{name: "client credentials", config: "upstream:\n  auth:\n    clientCredentials:\n      clientID: mcp\n", expected: "line 4: upstream.auth.clientCredentials: tokenURL and clientID are required"},
+		{name: "header name", config: "upstream:\n  headers:\n    \"X Client\": mcp\n", expected: `line 3: upstream.headers.X Client: invalid header name "X Client"`},
+		{name: "tool name", config: "tools:\n  equipment:\n    description: x\n", expected: `line 3: tools.equipment: expected a tool name starting with "query_" or "mutation_"`},
+		{name: "argument source", config: "tools:\n  query_equipment:\n    arguments:\n      tenantId: {header: X-Tenant, claim: org}\n", expected: "line 4: tools.query_equipment.arguments.tenantId: exactly one of header, claim and value must be set"},
+		{name: "scalar", config: "scalars:\n  Int: {type: string}\n", expected: "line 2: scalars.Int: invalid scalar mapping Int: built-in scalars cannot be mapped"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfig([]byte(tt.config), testEnv(nil))
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "graphql-mcp.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("maxDepth: x\n"), 0o600))
+	_, err := LoadConfigFile(path)
+	assert.ErrorContains(t, err, "invalid config file "+path+": line 1:")
+
+	_, err = LoadConfigFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read config file")
+}
+
+func TestNewMCPGraphQLServerFromConfig(t *testing.T) {
+	introspection, err := os.ReadFile("testdata/real_introspection_response.json")
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var received http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GraphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if strings.Contains(req.Query, "__schema") {
+			_, _ = w.Write(introspection)
+			return
+		}
+		mu.Lock()
+		received = r.Header.Clone()
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"data": {"equipment": []}}`))
+	}))
+	defer upstream.Close()
+
+	config, err := ParseConfig([]byte(`
+endpoint: ${UPSTREAM}
+upstream:
+  headers: {X-Client: mcp}
+  auth: {apiKey: key-1}
+mask:
+  allow: ["^facilities$"]
+maxDepth: 2
+tools:
+  query_equipment:
+    description: Equipment from the config file
+`), testEnv(map[string]string{"UPSTREAM": upstream.URL}))
+	require.NoError(t, err)
+
+	// Options in code override the mask and description of the file
+	server, err := NewMCPGraphQLServerFromConfig(config,
+		WithMask([]string{"^equipment$"}, nil),
+		WithToolDescription("query_equipment", "Equipment from code"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 2, server.GetSchema().MaxDepth)
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	_, err = server.GetMCPServer().Connect(context.Background(), serverTransport, nil)
+	require.NoError(t, err)
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(context.Background(), clientTransport, nil)
+	require.NoError(t, err)
+	defer session.Close()
+
+	tools, err := session.ListTools(context.Background(), nil)
+	require.NoError(t, err)
+	require.Len(t, tools.Tools, 1)
+	assert.Equal(t, "query_equipment", tools.Tools[0].Name)
+	assert.Equal(t, "Equipment from code", tools.Tools[0].Description)
+
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "query_equipment"})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "mcp", received.Get("X-Client"))
+	assert.Equal(t, "key-1", received.Get("X-API-Key"))
+}
@@ -0,0 +1,204 @@
+package graphqlmcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/peterbeamish/go-mcp-graphql/pkg/graphqlmcp/schema"
+)
+
+// ConfirmationTokenArgument is the tool argument that carries a confirmation token
+const ConfirmationTokenArgument = "confirmationToken"
+
+// pendingConfirmation is an issued confirmation token waiting to be redeemed
+type pendingConfirmation struct {
+	toolName  string
+	argsHash  string
+	sessionID string
+	expiresAt time.Time
+}
+
+// confirmationStore issues single-use confirmation tokens
+type confirmationStore struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]pendingConfirmation
+}
+
+// newConfirmationStore creates an empty confirmation token store
+func newConfirmationStore() *confirmationStore {
+	return &confirmationStore{now: time.Now, pending: make(map[string]pendingConfirmation)}
+}
+
+// issue creates a token for executing a tool with the given arguments in a session
+func (c *confirmationStore) issue(toolName, argsHash, sessionID string, ttl time.Duration) (string, time.Time, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for key, pending := range c.pending {
+		if !now.Before(pending.expiresAt) {
+			delete(c.pending, key)
+		}
+	}
+
+	expiresAt := now.Add(ttl)
+	c.pending[token] = pendingConfirmation{toolName: toolName, argsHash: argsHash, sessionID: sessionID, expiresAt: expiresAt}
+	return token, expiresAt, nil
+}
+
+// redeem consumes a token, which must have been issued for the same tool, arguments and session
+func (c *confirmationStore) redeem(token, toolName, argsHash, sessionID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending, ok := c.pending[token]
+	if !ok {
+		return errors.New("the confirmation token is invalid or has already been used")
+	}
+	// Tokens are single use, even when they are rejected below
+	delete(c.pending, token)
+
+	switch {
+	case !c.now().Before(pending.expiresAt):
+		return errors.New("the confirmation token has expired")
+	case pending.toolName != toolName || pending.sessionID != sessionID:
+		return errors.New("the confirmation token was issued for a different tool or session")
+	case pending.argsHash != argsHash:
+		return errors.New("the arguments differ from the ones that were previewed")
+	}
+	return nil
+}
+
+// hashArguments hashes the coerced arguments of a tool call
+func hashArguments(args map[string]interface{}) (string, error) {
+	// encoding/json sorts map keys, so equal arguments hash equally
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// addConfirmationArgument adds the optional confirmation token to a tool input schema
+func addConfirmationArgument(inputSchema map[string]interface{}, strict bool) {
+	properties, ok := inputSchema["properties"].(map[string]interface{})
+	if !ok {
+		properties = make(map[string]interface{})
+		inputSchema["properties"] = properties
+	}
+
+	tokenSchema := map[string]interface{}{
+		"type":        "string",
+		"description": "Confirmation token from the preview of this mutation. Omit it to request a preview.",
+	}
+	if strict {
+		// Strict schemas require every property, so the token is nullable instead of optional
+		tokenSchema["type"] = []interface{}{"string", "null"}
+		required, _ := inputSchema["required"].([]string)
+		inputSchema["required"] = append(required, ConfirmationTokenArgument)
+	}
+	properties[ConfirmationTokenArgument] = tokenSchema
+}
+
+// confirmMutation decides whether a mutation that requires confirmation may run now
+// It returns nil when the call is confirmed, or the result to return instead of executing
+func (s *MCPGraphQLServer) confirmMutation(ctx context.Context, req *mcp.CallToolRequest, field *schema.Field, args map[string]interface{}, token string) *mcp.CallToolResult {
+	toolName := req.Params.Name
+	sessionID := ""
+	if req.Session != nil {
+		sessionID = req.Session.ID()
+	}
+
+	argsHash, err := hashArguments(args)
+	if err != nil {
+		return toolErrorResult(fmt.Sprintf("Failed to prepare confirmation: %v", err))
+	}
+
+	if token != "" {
+		if err := s.confirmations.redeem(token, toolName, argsHash, sessionID); err != nil {
+			s.logger.Info("Mutation confirmation rejected",
+				"tool_name", toolName,
+				"session_id", sessionID,
+				"reason", err.Error(),
+			)
+			return toolErrorResult(fmt.Sprintf("Cannot execute %s: %v. Call the tool without %s to get a new preview.", toolName, err, ConfirmationTokenArgument))
+		}
+		return nil
+	}
+
+	operation, err := field.GenerateMutationStringWithSchema(s.Schema)
+	if err != nil {
+		return toolErrorResult(fmt.Sprintf("Failed to generate mutation string: %v", err))
+	}
+	// The token is bound to every argument, but the preview leaves out the overridden ones,
+	// which are hidden from the model
+	previewArgs := s.withoutOverriddenArguments(toolName, args)
+	variables, err := json.MarshalIndent(previewArgs, "", "  ")
+	if err != nil {
+		return toolErrorResult(fmt.Sprintf("Failed to prepare confirmation: %v", err))
+	}
+
+	// Ask the user directly when the client supports elicitation
+	if req.Session != nil && req.Session.InitializeParams() != nil &&
+		req.Session.InitializeParams().Capabilities != nil && req.Session.InitializeParams().Capabilities.Elicitation != nil {
+		result, err := req.Session.Elicit(ctx, &mcp.ElicitParams{
+			Message: fmt.Sprintf("Confirm execution of %s with these variables:\n%s", toolName, variables),
+			RequestedSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"confirm": map[string]interface{}{
+						"type":        "boolean",
+						"title":       "Execute " + field.Name,
+						"description": "Run the mutation against the GraphQL server",
+					},
+				},
+				"required": []string{"confirm"},
+			},
+		})
+		if err == nil {
+			if result.Action == "accept" && result.Content["confirm"] == true {
+				return nil
+			}
+			s.logger.Info("Mutation declined by user", "tool_name", toolName, "session_id", sessionID, "action", result.Action)
+			return toolErrorResult(fmt.Sprintf("The user did not confirm %s, so it was not executed.", toolName))
+		}
+		s.logger.Info("Elicitation failed, falling back to a confirmation token", "tool_name", toolName, "error", err.Error())
+	}
+
+	ttl := s.options.Confirmation.TTL
+	token, expiresAt, err := s.confirmations.issue(toolName, argsHash, sessionID, ttl)
+	if err != nil {
+		return toolErrorResult(err.Error())
+	}
+
+	text := fmt.Sprintf("Confirmation required: %s was not executed.\n\nOperation:\n%s\n\nVariables:\n%s\n\n"+
+		"To execute it, call %s again with the same arguments and \"%s\": %q within %s.",
+		toolName, operation, variables, toolName, ConfirmationTokenArgument, token, ttl)
+	return &mcp.CallToolResult{
+		Meta: map[string]interface{}{
+			"confirmationRequired": true,
+			"confirmationToken":    token,
+			"expiresAt":            expiresAt.UTC().Format(time.RFC3339),
+			"operation":            operation,
+			"variables":            previewArgs,
+		},
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}
+}
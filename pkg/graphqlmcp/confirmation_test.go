@@ -0,0 +1,194 @@
+package graphqlmcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPGraphQLServer_MutationConfirmation(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), map[string]interface{}{"id": "7"}).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"deleteEquipment": true}}, nil)
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor, WithMutationConfirmation([]string{"^delete"}, time.Minute))
+	require.NoError(t, err)
+
+	deleteEquipment := findField(t, testSchema.GetMutations(), "deleteEquipment")
+	inputSchema := server.toolInputSchema(deleteEquipment, "mutation")
+	assert.Contains(t, inputSchema["properties"], ConfirmationTokenArgument)
+	assert.NotContains(t, server.toolInputSchema(findField(t, testSchema.GetMutations(), "updateEquipmentStatus"), "mutation")["properties"], ConfirmationTokenArgument)
+
+	handler := server.newToolHandler(deleteEquipment, "mutation")
+	callTool := func(arguments map[string]interface{}) *mcp.CallToolResult {
+		data, err := json.Marshal(arguments)
+		require.NoError(t, err)
+		result, err := handler(context.Background(), &mcp.CallToolRequest{
+			Params: &mcp.CallToolParamsRaw{Name: "mutation_deleteEquipment", Arguments: data},
+		})
+		require.NoError(t, err)
+		return result
+	}
+	preview := func(id string) string {
+		result := callTool(map[string]interface{}{"id": id})
+		assert.False(t, result.IsError)
+		assert.Equal(t, true, result.Meta["confirmationRequired"])
+		return result.Meta["confirmationToken"].(string)
+	}
+
+	// The first call only previews the mutation
+	result := callTool(map[string]interface{}{"id": 7})
+	assert.False(t, result.IsError)
+	text := result.Content[0].(*mcp.TextContent).Text
+	assert.Contains(t, text, "Confirmation required: mutation_deleteEquipment was not executed.")
+	assert.Contains(t, text, "deleteEquipment(id: $id)")
+	assert.Contains(t, text, `"id": "7"`)
+	mockExecutor.AssertNotCalled(t, "ExecuteQuery", mock.Anything, mock.Anything, mock.Anything)
+
+	// The token executes the previewed mutation once
+	token := result.Meta["confirmationToken"].(string)
+	result = callTool(map[string]interface{}{"id": "7", ConfirmationTokenArgument: token})
+	assert.False(t, result.IsError)
+	mockExecutor.AssertNumberOfCalls(t, "ExecuteQuery", 1)
+
+	tests := []struct {
+		name      string
+		arguments func() map[string]interface{}
+		expected  string
+	}{
+		{
+			name: "replayed token",
+			arguments: func() map[string]interface{} {
+				return map[string]interface{}{"id": "7", ConfirmationTokenArgument: token}
+			},
+			expected: "the confirmation token is invalid or has already been used",
+		},
+		{
+			name: "different arguments",
+			arguments: func() map[string]interface{} {
+				return map[string]interface{}{"id": "8", ConfirmationTokenArgument: preview("7")}
+			},
+			expected: "the arguments differ from the ones that were previewed",
+		},
+		{
+			name: "expired token",
+			arguments: func() map[string]interface{} {
+				token := preview("7")
+				server.confirmations.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+				return map[string]interface{}{"id": "7", ConfirmationTokenArgument: token}
+			},
+			expected: "the confirmation token has expired",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() { server.confirmations.now = time.Now }()
+			result := callTool(tt.arguments())
+			assert.True(t, result.IsError)
+			assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, tt.expected)
+		})
+	}
+	mockExecutor.AssertNumberOfCalls(t, "ExecuteQuery", 1)
+}
+
+func TestMCPGraphQLServer_MutationConfirmationElicitation(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), map[string]interface{}{"id": "7"}).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"deleteEquipment": true}}, nil).Once()
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor, WithMutationConfirmation([]string{"^delete"}, 0))
+	require.NoError(t, err)
+
+	connect := func(confirm bool) *mcp.ClientSession {
+		serverTransport, clientTransport := mcp.NewInMemoryTransports()
+		_, err := server.GetMCPServer().Connect(context.Background(), serverTransport, nil)
+		require.NoError(t, err)
+
+		client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, &mcp.ClientOptions{
+			ElicitationHandler: func(ctx context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+				assert.Contains(t, req.Params.Message, "Confirm execution of mutation_deleteEquipment")
+				if !confirm {
+					return &mcp.ElicitResult{Action: "decline"}, nil
+				}
+				return &mcp.ElicitResult{Action: "accept", Content: map[string]any{"confirm": true}}, nil
+			},
+		})
+		session, err := client.Connect(context.Background(), clientTransport, nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { session.Close() })
+		return session
+	}
+
+	params := &mcp.CallToolParams{Name: "mutation_deleteEquipment", Arguments: map[string]interface{}{"id": "7"}}
+
+	result, err := connect(false).CallTool(context.Background(), params)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "The user did not confirm mutation_deleteEquipment")
+	mockExecutor.AssertNotCalled(t, "ExecuteQuery", mock.Anything, mock.Anything, mock.Anything)
+
+	result, err = connect(true).CallTool(context.Background(), params)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	mockExecutor.AssertExpectations(t)
+}
+
+func TestMCPGraphQLServer_MutationConfirmationOverrides(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"),
+		map[string]interface{}{"id": "eq-7", "status": "OPERATIONAL", "notes": "internal note"}).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"updateEquipmentStatus": nil}}, nil).Once()
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor,
+		WithMutationConfirmation([]string{"^updateEquipmentStatus$"}, 0),
+		WithArgumentOverrides(
+			ArgumentConstant("mutation_updateEquipmentStatus", "id", "eq-7"),
+			ArgumentConstant("mutation_updateEquipmentStatus", "notes", "internal note"),
+		),
+	)
+	require.NoError(t, err)
+
+	handler := server.newToolHandler(findField(t, testSchema.GetMutations(), "updateEquipmentStatus"), "mutation")
+	callTool := func(arguments string) *mcp.CallToolResult {
+		result, err := handler(context.Background(), &mcp.CallToolRequest{
+			Params: &mcp.CallToolParamsRaw{Name: "mutation_updateEquipmentStatus", Arguments: json.RawMessage(arguments)},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	// The preview leaves out the overridden arguments, which the token is still bound to
+	result := callTool(`{"status": "OPERATIONAL"}`)
+	require.False(t, result.IsError)
+	text := result.Content[0].(*mcp.TextContent).Text
+	assert.Contains(t, text, `"status": "OPERATIONAL"`)
+	assert.NotContains(t, text, "internal note")
+	assert.NotContains(t, text, "eq-7")
+	assert.Equal(t, map[string]interface{}{"status": "OPERATIONAL"}, result.Meta["variables"])
+
+	token := result.Meta["confirmationToken"].(string)
+	result = callTool(`{"status": "OPERATIONAL", "confirmationToken": "` + token + `"}`)
+	assert.False(t, result.IsError)
+	mockExecutor.AssertExpectations(t)
+}
+
+func TestNewMCPGraphQLServer_InvalidConfirmationPattern(t *testing.T) {
+	_, err := NewMCPGraphQLServerWithExecutor(new(MockGraphQLExecutor), WithMutationConfirmation([]string{"^delete", "^remove("}, 0))
+	assert.ErrorContains(t, err, `invalid mutation confirmation pattern "^remove("`)
+}
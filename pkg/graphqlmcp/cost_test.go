@@ -0,0 +1,47 @@
+package graphqlmcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/peterbeamish/go-mcp-graphql/pkg/graphqlmcp/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPGraphQLServer_CostLimits(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"equipmentById": nil}}, nil)
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor, WithCostLimits(schema.CostOptions{MaxCost: 100}))
+	require.NoError(t, err)
+
+	callTool := func(field *schema.Field, toolName, arguments string) *mcp.CallToolResult {
+		result, err := server.newToolHandler(field, "query")(context.Background(), &mcp.CallToolRequest{
+			Params: &mcp.CallToolParamsRaw{Name: toolName, Arguments: []byte(arguments)},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	// Listing every facility with its nested lists exceeds the budget
+	result := callTool(findField(t, testSchema.GetQueries(), "facilities"), "query_facilities", "{}")
+	assert.True(t, result.IsError)
+	text := result.Content[0].(*mcp.TextContent).Text
+	assert.Contains(t, text, "the operation is too expensive: estimated cost")
+	assert.Contains(t, text, "exceeds the limit of 100")
+	assert.Contains(t, text, "facilities (assumed 10 items)")
+	assert.Contains(t, text, "Narrow the request")
+	mockExecutor.AssertNotCalled(t, "ExecuteQuery", mock.Anything, mock.Anything, mock.Anything)
+
+	// Fetching a single item by ID stays within it
+	result = callTool(findField(t, testSchema.GetQueries(), "equipmentById"), "query_equipmentById", `{"id": "1"}`)
+	assert.False(t, result.IsError)
+	mockExecutor.AssertNumberOfCalls(t, "ExecuteQuery", 1)
+}
@@ -0,0 +1,439 @@
+package graphqlmcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultRefreshBefore is how long before expiry cached tokens are refreshed
+const defaultRefreshBefore = time.Minute
+
+// tokenRequestTimeout bounds a token request, which is shared by the callers waiting for it and
+// does not end when one of them is cancelled
+const tokenRequestTimeout = 30 * time.Second
+
+// Token is an access token for the GraphQL server
+type Token struct {
+	AccessToken string
+	// TokenType is the authorization scheme, "Bearer" if empty
+	TokenType string
+	// Expiry is when the token expires, zero if it does not expire
+	Expiry time.Time
+}
+
+// AuthorizationHeader returns the value of the Authorization header for the token
+func (t *Token) AuthorizationHeader() string {
+	tokenType := t.TokenType
+	// Token exchange responses use "N_A" for tokens that are not access tokens of a known scheme
+	if tokenType == "" || tokenType == "N_A" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	return tokenType + " " + t.AccessToken
+}
+
+// validFor reports whether the token is still valid after the given duration
+func (t *Token) validFor(d time.Duration, now time.Time) bool {
+	return t != nil && (t.Expiry.IsZero() || now.Add(d).Before(t.Expiry))
+}
+
+// CredentialProvider supplies the credentials of requests to the GraphQL server
+type CredentialProvider interface {
+	// Token returns the token for a request, refreshing it if needed
+	Token(ctx context.Context) (*Token, error)
+	// Invalidate discards the cached token for the request context after the server rejected it
+	Invalidate(ctx context.Context)
+}
+
+// subjectTokenKey is the context key for the bearer token of the incoming MCP request
+type subjectTokenKey struct{}
+
+// ContextWithSubjectToken adds the bearer token the caller presented to the context
+func ContextWithSubjectToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, subjectTokenKey{}, token)
+}
+
+// SubjectTokenFromContext returns the bearer token the caller presented, or an empty string
+func SubjectTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(subjectTokenKey{}).(string)
+	return token
+}
+
+// ClientCredentialsConfig configures an OAuth2 client credentials provider
+type ClientCredentialsConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// Audience is sent as the "audience" parameter when set, as some providers require
+	Audience string
+	// HTTPClient requests tokens, http.DefaultClient if nil
+	HTTPClient *http.Client
+	// RefreshBefore is how long before expiry the token is refreshed, 1 minute if zero
+	RefreshBefore time.Duration
+}
+
+// ClientCredentialsProvider obtains tokens with the OAuth2 client credentials grant and caches
+// them until shortly before they expire
+type ClientCredentialsProvider struct {
+	config ClientCredentialsConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	token    *Token
+	requests callGroup[*Token]
+}
+
+// NewClientCredentialsProvider creates an OAuth2 client credentials provider
+func NewClientCredentialsProvider(config ClientCredentialsConfig) (*ClientCredentialsProvider, error) {
+	if config.TokenURL == "" || config.ClientID == "" {
+		return nil, errors.New("client credentials provider requires a token URL and client ID")
+	}
+	if config.RefreshBefore == 0 {
+		config.RefreshBefore = defaultRefreshBefore
+	}
+	return &ClientCredentialsProvider{config: config, now: time.Now}, nil
+}
+
+// Token returns the cached token, requesting a new one when it is about to expire
+// Callers that find the token expired share a single request, which runs without the lock
+func (p *ClientCredentialsProvider) Token(ctx context.Context) (*Token, error) {
+	if token := p.cachedToken(); token != nil {
+		return token, nil
+	}
+
+	return p.requests.do(ctx, "", func() (*Token, error) {
+		// A request that finished since the check above has cached its token
+		if token := p.cachedToken(); token != nil {
+			return token, nil
+		}
+
+		form := url.Values{"grant_type": {"client_credentials"}}
+		if len(p.config.Scopes) > 0 {
+			form.Set("scope", strings.Join(p.config.Scopes, " "))
+		}
+		if p.config.Audience != "" {
+			form.Set("audience", p.config.Audience)
+		}
+
+		requestCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tokenRequestTimeout)
+		defer cancel()
+		token, err := requestToken(requestCtx, p.config.HTTPClient, p.config.TokenURL, p.config.ClientID, p.config.ClientSecret, form, p.now())
+		if err != nil {
+			return nil, err
+		}
+		p.mu.Lock()
+		p.token = token
+		p.mu.Unlock()
+		return token, nil
+	})
+}
+
+// cachedToken returns the cached token if it is not about to expire, or nil
+func (p *ClientCredentialsProvider) cachedToken() *Token {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.token.validFor(p.config.RefreshBefore, p.now()) {
+		return nil
+	}
+	return p.token
+}
+
+// Invalidate discards the cached token
+func (p *ClientCredentialsProvider) Invalidate(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = nil
+}
+
+// FileTokenProvider reads a token from a file and rereads it whenever the file changes, as when
+// Kubernetes updates a mounted secret
+type FileTokenProvider struct {
+	path      string
+	tokenType string
+
+	mu      sync.Mutex
+	token   *Token
+	modTime time.Time
+}
+
+// NewFileTokenProvider creates a provider for the token in the file, sent with the given
+// authorization scheme ("Bearer" if empty)
+func NewFileTokenProvider(path, tokenType string) (*FileTokenProvider, error) {
+	provider := &FileTokenProvider{path: path, tokenType: tokenType}
+	if _, err := provider.Token(context.Background()); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// Token returns the token from the file, rereading it if the file was modified
+func (p *FileTokenProvider) Token(ctx context.Context) (*Token, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+	if p.token != nil && info.ModTime().Equal(p.modTime) {
+		return p.token, nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return nil, fmt.Errorf("token file %s is empty", p.path)
+	}
+
+	p.token = &Token{AccessToken: value, TokenType: p.tokenType}
+	p.modTime = info.ModTime()
+	return p.token, nil
+}
+
+// Invalidate forces the file to be reread on the next request
+func (p *FileTokenProvider) Invalidate(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = nil
+}
+
+// TokenExchangeConfig configures an OAuth2 token exchange (RFC 8693) provider
+type TokenExchangeConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	// Audience and Resource identify the GraphQL server to the authorization server
+	Audience string
+	Resource string
+	Scopes   []string
+	// SubjectTokenType is the type of the caller's token, an access token if empty
+	SubjectTokenType string
+	// SubjectToken returns the caller's token, SubjectTokenFromContext if nil, and
+	// ErrNoSubjectToken if the request has none
+	SubjectToken func(ctx context.Context) (string, error)
+	// Fallback supplies the credentials of requests without a caller token, such as the schema
+	// introspection when the server starts and RefreshSchema; without it they fail
+	Fallback CredentialProvider
+	// HTTPClient requests tokens, http.DefaultClient if nil
+	HTTPClient *http.Client
+	// RefreshBefore is how long before expiry a token is exchanged again, 1 minute if zero
+	RefreshBefore time.Duration
+}
+
+// exchangedTokenTTL is how long exchanged tokens without an expiry are reused
+const exchangedTokenTTL = 5 * time.Minute
+
+// ErrNoSubjectToken is returned when a request to exchange has no caller token
+var ErrNoSubjectToken = errors.New("the request has no bearer token to exchange")
+
+// accessTokenType is the RFC 8693 token type of OAuth2 access tokens
+const accessTokenType = "urn:ietf:params:oauth:token-type:access_token"
+
+// TokenExchangeProvider exchanges the token the MCP caller presented for a token for the GraphQL
+// server, caching the result per caller token
+type TokenExchangeProvider struct {
+	config TokenExchangeConfig
+	now    func() time.Time
+
+	mu        sync.Mutex
+	tokens    map[string]*Token
+	exchanges callGroup[*Token]
+}
+
+// NewTokenExchangeProvider creates an OAuth2 token exchange provider
+// Requests without an MCP caller, such as the schema introspection, have no token to exchange;
+// set Fallback to a provider for them or load the schema from a file, or the server has no tools
+func NewTokenExchangeProvider(config TokenExchangeConfig) (*TokenExchangeProvider, error) {
+	if config.TokenURL == "" {
+		return nil, errors.New("token exchange provider requires a token URL")
+	}
+	if config.SubjectTokenType == "" {
+		config.SubjectTokenType = accessTokenType
+	}
+	if config.SubjectToken == nil {
+		config.SubjectToken = func(ctx context.Context) (string, error) {
+			if token := SubjectTokenFromContext(ctx); token != "" {
+				return token, nil
+			}
+			return "", ErrNoSubjectToken
+		}
+	}
+	if config.RefreshBefore == 0 {
+		config.RefreshBefore = defaultRefreshBefore
+	}
+	return &TokenExchangeProvider{config: config, now: time.Now, tokens: make(map[string]*Token)}, nil
+}
+
+// Token exchanges the caller's token, reusing an earlier exchange until it is about to expire
+// Concurrent requests of the same caller share a single exchange, which runs without the lock
+func (p *TokenExchangeProvider) Token(ctx context.Context) (*Token, error) {
+	subjectToken, err := p.config.SubjectToken(ctx)
+	if errors.Is(err, ErrNoSubjectToken) && p.config.Fallback != nil {
+		return p.config.Fallback.Token(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("token exchange failed: %w", err)
+	}
+	key := subjectTokenCacheKey(subjectToken)
+	if token := p.cachedToken(key); token != nil {
+		return token, nil
+	}
+
+	return p.exchanges.do(ctx, key, func() (*Token, error) {
+		// An exchange that finished since the check above has cached its token
+		if token := p.cachedToken(key); token != nil {
+			return token, nil
+		}
+
+		form := url.Values{
+			"grant_type":           {"urn:ietf:params:oauth:grant-type:token-exchange"},
+			"subject_token":        {subjectToken},
+			"subject_token_type":   {p.config.SubjectTokenType},
+			"requested_token_type": {accessTokenType},
+		}
+		if p.config.Audience != "" {
+			form.Set("audience", p.config.Audience)
+		}
+		if p.config.Resource != "" {
+			form.Set("resource", p.config.Resource)
+		}
+		if len(p.config.Scopes) > 0 {
+			form.Set("scope", strings.Join(p.config.Scopes, " "))
+		}
+
+		now := p.now()
+		requestCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tokenRequestTimeout)
+		defer cancel()
+		token, err := requestToken(requestCtx, p.config.HTTPClient, p.config.TokenURL, p.config.ClientID, p.config.ClientSecret, form, now)
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Expiry.IsZero() {
+			// Exchanges that do not expire would otherwise stay cached for as long as the process runs
+			token.Expiry = now.Add(exchangedTokenTTL)
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		// Drop expired exchanges so the cache does not grow with every caller token
+		for cachedKey, cached := range p.tokens {
+			if !cached.validFor(0, now) {
+				delete(p.tokens, cachedKey)
+			}
+		}
+		p.tokens[key] = token
+		return token, nil
+	})
+}
+
+// cachedToken returns the exchanged token of a caller if it is not about to expire, or nil
+func (p *TokenExchangeProvider) cachedToken(key string) *Token {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	token := p.tokens[key]
+	if !token.validFor(p.config.RefreshBefore, p.now()) {
+		return nil
+	}
+	return token
+}
+
+// Invalidate discards the exchanged token of the caller
+func (p *TokenExchangeProvider) Invalidate(ctx context.Context) {
+	subjectToken, err := p.config.SubjectToken(ctx)
+	if errors.Is(err, ErrNoSubjectToken) && p.config.Fallback != nil {
+		p.config.Fallback.Invalidate(ctx)
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.tokens, subjectTokenCacheKey(subjectToken))
+}
+
+// subjectTokenCacheKey hashes a caller token so it is not kept in memory as a map key
+func subjectTokenCacheKey(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// requestToken requests a token from an OAuth2 token endpoint, authenticating the client with
+// HTTP basic authentication when a client ID is configured
+func requestToken(ctx context.Context, client *http.Client, tokenURL, clientID, clientSecret string, form url.Values, now time.Time) (*Token, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if clientID != "" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var tokenResp struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("failed to parse token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || tokenResp.Error != "" {
+		if tokenResp.Error != "" {
+			return nil, fmt.Errorf("token request failed with status %d: %s %s", resp.StatusCode, tokenResp.Error, tokenResp.ErrorDescription)
+		}
+		return nil, fmt.Errorf("token request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	if tokenResp.AccessToken == "" {
+		return nil, errors.New("token response has no access_token")
+	}
+
+	token := &Token{AccessToken: tokenResp.AccessToken, TokenType: tokenResp.TokenType}
+	if tokenResp.ExpiresIn > 0 {
+		token.Expiry = now.Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// bearerToken returns the token of a bearer Authorization header value, or an empty string
+func bearerToken(authorization string) string {
+	fields := strings.Fields(authorization)
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "bearer") {
+		return ""
+	}
+	return fields[1]
+}
@@ -0,0 +1,318 @@
+package graphqlmcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTokenServer serves numbered access tokens ("token-1", "token-2", ...) that expire in an hour
+func newTokenServer(t *testing.T, check func(r *http.Request)) (*httptest.Server, *int32) {
+	t.Helper()
+	var issued int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if check != nil {
+			check(r)
+		}
+		n := atomic.AddInt32(&issued, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "bearer", "expires_in": 3600}`, n)
+	}))
+	t.Cleanup(server.Close)
+	return server, &issued
+}
+
+func TestClientCredentialsProvider(t *testing.T) {
+	tokenServer, issued := newTokenServer(t, func(r *http.Request) {
+		clientID, secret, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "mcp-server", clientID)
+		assert.Equal(t, "s3cret", secret)
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "graphql:read graphql:write", r.PostForm.Get("scope"))
+	})
+
+	provider, err := NewClientCredentialsProvider(ClientCredentialsConfig{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "mcp-server",
+		ClientSecret: "s3cret",
+		Scopes:       []string{"graphql:read", "graphql:write"},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	token, err := provider.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token-1", token.AuthorizationHeader())
+
+	token, err = provider.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+	assert.Equal(t, int32(1), atomic.LoadInt32(issued), "token should be cached")
+
+	// Tokens are refreshed shortly before they expire
+	provider.now = func() time.Time { return time.Now().Add(time.Hour - 30*time.Second) }
+	token, err = provider.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token.AccessToken)
+
+	provider.Invalidate(ctx)
+	token, err = provider.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-3", token.AccessToken)
+
+	// Concurrent callers share the request for a new token
+	provider.Invalidate(ctx)
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := provider.Token(ctx)
+			if assert.NoError(t, err) {
+				assert.Equal(t, "token-4", token.AccessToken)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(4), atomic.LoadInt32(issued))
+}
+
+func TestClientCredentialsProvider_CancelledCaller(t *testing.T) {
+	var started int32
+	release := make(chan struct{})
+	tokenServer, issued := newTokenServer(t, func(r *http.Request) {
+		atomic.AddInt32(&started, 1)
+		<-release
+	})
+
+	provider, err := NewClientCredentialsProvider(ClientCredentialsConfig{TokenURL: tokenServer.URL, ClientID: "mcp-server"})
+	require.NoError(t, err)
+
+	// The shared request keeps running for the other callers when the caller that started it
+	// is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := provider.Token(ctx)
+		cancelled <- err
+	}()
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&started) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	tokens := make(chan *Token, 1)
+	go func() {
+		token, err := provider.Token(context.Background())
+		assert.NoError(t, err)
+		tokens <- token
+	}()
+	cancel()
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+
+	close(release)
+	token := <-tokens
+	require.NotNil(t, token)
+	assert.Equal(t, "token-1", token.AccessToken)
+	assert.Equal(t, int32(1), atomic.LoadInt32(issued))
+}
+
+func TestFileTokenProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0o600))
+
+	provider, err := NewFileTokenProvider(path, "")
+	require.NoError(t, err)
+
+	token, err := provider.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer first", token.AuthorizationHeader())
+
+	// A rotated secret is picked up as soon as the file changes
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0o600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	token, err = provider.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "second", token.AccessToken)
+
+	_, err = NewFileTokenProvider(filepath.Join(t.TempDir(), "missing"), "")
+	assert.ErrorContains(t, err, "failed to read token file")
+}
+
+func TestTokenExchangeProvider(t *testing.T) {
+	tokenServer, issued := newTokenServer(t, func(r *http.Request) {
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:token-exchange", r.PostForm.Get("grant_type"))
+		assert.Equal(t, accessTokenType, r.PostForm.Get("subject_token_type"))
+		assert.Equal(t, "https://graphql.example.com", r.PostForm.Get("audience"))
+		assert.Contains(t, []string{"alice-token", "bob-token"}, r.PostForm.Get("subject_token"))
+	})
+
+	provider, err := NewTokenExchangeProvider(TokenExchangeConfig{
+		TokenURL: tokenServer.URL,
+		Audience: "https://graphql.example.com",
+	})
+	require.NoError(t, err)
+
+	alice := ContextWithSubjectToken(context.Background(), "alice-token")
+	bob := ContextWithSubjectToken(context.Background(), "bob-token")
+
+	aliceToken, err := provider.Token(alice)
+	require.NoError(t, err)
+	bobToken, err := provider.Token(bob)
+	require.NoError(t, err)
+	assert.NotEqual(t, aliceToken.AccessToken, bobToken.AccessToken)
+
+	// Exchanges are cached per caller token
+	again, err := provider.Token(alice)
+	require.NoError(t, err)
+	assert.Equal(t, aliceToken.AccessToken, again.AccessToken)
+	assert.Equal(t, int32(2), atomic.LoadInt32(issued))
+
+	_, err = provider.Token(context.Background())
+	assert.ErrorIs(t, err, ErrNoSubjectToken)
+}
+
+func TestTokenExchangeProvider_NoExpiry(t *testing.T) {
+	var issued int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&issued, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "bearer"}`, n)
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	provider, err := NewTokenExchangeProvider(TokenExchangeConfig{TokenURL: tokenServer.URL})
+	require.NoError(t, err)
+	start := time.Now()
+	provider.now = func() time.Time { return start }
+
+	alice := ContextWithSubjectToken(context.Background(), "alice-token")
+	token, err := provider.Token(alice)
+	require.NoError(t, err)
+	_, err = provider.Token(alice)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&issued))
+
+	// Exchanges without expires_in are only reused for a limited time and then evicted
+	provider.now = func() time.Time { return start.Add(exchangedTokenTTL) }
+	_, err = provider.Token(ContextWithSubjectToken(context.Background(), "bob-token"))
+	require.NoError(t, err)
+	assert.Len(t, provider.tokens, 1)
+
+	again, err := provider.Token(alice)
+	require.NoError(t, err)
+	assert.NotEqual(t, token.AccessToken, again.AccessToken)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&issued))
+}
+
+func TestTokenExchangeProvider_Fallback(t *testing.T) {
+	tokenServer, _ := newTokenServer(t, func(r *http.Request) {
+		assert.Equal(t, "caller-token", r.PostForm.Get("subject_token"))
+	})
+	fallbackServer, fallbackIssued := newTokenServer(t, func(r *http.Request) {
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+	})
+
+	fallback, err := NewClientCredentialsProvider(ClientCredentialsConfig{TokenURL: fallbackServer.URL, ClientID: "mcp-server"})
+	require.NoError(t, err)
+	provider, err := NewTokenExchangeProvider(TokenExchangeConfig{TokenURL: tokenServer.URL, Fallback: fallback})
+	require.NoError(t, err)
+
+	// Requests without a caller, such as the schema introspection, use the fallback credentials
+	token, err := provider.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+
+	provider.Invalidate(context.Background())
+	_, err = provider.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(fallbackIssued))
+
+	token, err = provider.Token(ContextWithSubjectToken(context.Background(), "caller-token"))
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+	assert.Equal(t, int32(2), atomic.LoadInt32(fallbackIssued))
+}
+
+func TestTokenExchangeProvider_Concurrent(t *testing.T) {
+	var started int32
+	release := make(chan struct{})
+	tokenServer, issued := newTokenServer(t, func(r *http.Request) {
+		atomic.AddInt32(&started, 1)
+		<-release
+	})
+
+	provider, err := NewTokenExchangeProvider(TokenExchangeConfig{TokenURL: tokenServer.URL})
+	require.NoError(t, err)
+
+	// Requests of the same caller share one exchange, while other callers do not wait for it
+	tokens := make(chan string, 4)
+	for _, subject := range []string{"alice-token", "alice-token", "alice-token", "bob-token"} {
+		go func() {
+			token, err := provider.Token(ContextWithSubjectToken(context.Background(), subject))
+			if !assert.NoError(t, err) {
+				tokens <- ""
+				return
+			}
+			tokens <- token.AccessToken
+		}()
+	}
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&started) == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	close(release)
+	received := make(map[string]int)
+	for range 4 {
+		received[<-tokens]++
+	}
+	counts := make([]int, 0, len(received))
+	for _, n := range received {
+		counts = append(counts, n)
+	}
+	assert.ElementsMatch(t, []int{3, 1}, counts)
+	assert.Equal(t, int32(2), atomic.LoadInt32(issued))
+}
+
+func TestGraphQLClient_CredentialRetry(t *testing.T) {
+	tokenServer, issued := newTokenServer(t, nil)
+	provider, err := NewClientCredentialsProvider(ClientCredentialsConfig{TokenURL: tokenServer.URL, ClientID: "mcp-server"})
+	require.NoError(t, err)
+
+	// The upstream only accepts the second token, as if the first one was revoked
+	var attempts int32
+	graphqlServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data": {"ok": true}}`))
+	}))
+	defer graphqlServer.Close()
+
+	client := NewGraphQLClient(graphqlServer.URL)
+	client.SetCredentialProvider(provider)
+
+	resp, err := client.ExecuteQuery(context.Background(), "{ ok }", nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ok": true}, resp.Data)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+	assert.Equal(t, int32(2), atomic.LoadInt32(issued))
+
+	// A second rejection is not retried again
+	provider.Invalidate(context.Background())
+	atomic.StoreInt32(&attempts, 0)
+	_, err = client.ExecuteQuery(context.Background(), "{ ok }", nil)
+	assert.ErrorContains(t, err, "status 401")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
@@ -6,7 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -19,6 +22,8 @@ type GraphQLClient struct {
 	httpClient *http.Client
 	headers    map[string]string
 	logger     logr.Logger
+	// credentials supplies the Authorization header of every request when set
+	credentials CredentialProvider
 }
 
 // NewGraphQLClient creates a new GraphQL client
@@ -38,6 +43,17 @@ func (c *GraphQLClient) SetHeader(key, value string) {
 	c.headers[key] = value
 }
 
+// SetTimeout sets the timeout of GraphQL requests, 30 seconds by default
+func (c *GraphQLClient) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+}
+
+// SetCredentialProvider sets the provider of the Authorization header for GraphQL requests
+// Requests rejected with 401 are retried once with refreshed credentials
+func (c *GraphQLClient) SetCredentialProvider(provider CredentialProvider) {
+	c.credentials = provider
+}
+
 // SetLogger sets a custom logger for the GraphQL client
 func (c *GraphQLClient) SetLogger(logger logr.Logger) {
 	c.logger = logger
@@ -51,10 +67,24 @@ type GraphQLRequest struct {
 
 // GraphQLResponse represents a GraphQL response
 type GraphQLResponse struct {
-	Data   interface{} `json:"data"`
-	Errors []struct {
-		Message string `json:"message"`
-	} `json:"errors,omitempty"`
+	Data       interface{}            `json:"data"`
+	Errors     []GraphQLError         `json:"errors,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+	// StatusCode is the HTTP status of the response, 0 for executors that do not use HTTP
+	StatusCode int `json:"-"`
+	// Header holds the HTTP headers of the response, nil for executors that do not use HTTP
+	Header http.Header `json:"-"`
+}
+
+// HTTPStatusError is returned when the GraphQL server responds with a status other than 200
+type HTTPStatusError struct {
+	StatusCode int
+	Body       string
+	Header     http.Header
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("GraphQL request failed with status %d: %s", e.StatusCode, e.Body)
 }
 
 // IntrospectionQuery is the standard GraphQL introspection query
@@ -260,7 +290,7 @@ query IntrospectionQuery {
         }
         defaultValue
       }
-      enumValues {
+      enumValues(includeDeprecated: true) {
         name
         description
         isDeprecated
@@ -307,6 +337,12 @@ query IntrospectionQuery {
 }
 `
 
+// IntrospectionQueryWithOneOf extends IntrospectionQuery with the isOneOf field used to
+// detect @oneOf input objects; servers that predate it reject the query
+var IntrospectionQueryWithOneOf = strings.Replace(IntrospectionQuery,
+	"    types {\n      name\n      kind\n",
+	"    types {\n      name\n      kind\n      isOneOf\n", 1)
+
 // IntrospectSchema performs GraphQL introspection to get the schema
 func (c *GraphQLClient) IntrospectSchema(ctx context.Context) (*schema.Schema, error) {
 	requestID := fmt.Sprintf("introspect_%d", time.Now().UnixNano())
@@ -317,10 +353,17 @@ func (c *GraphQLClient) IntrospectSchema(ctx context.Context) (*schema.Schema, e
 	)
 
 	req := &GraphQLRequest{
-		Query: IntrospectionQuery,
+		Query: IntrospectionQueryWithOneOf,
 	}
 
 	resp, err := c.executeRequest(ctx, req, requestID)
+	if ctx.Err() == nil && (err != nil || len(resp.Errors) > 0) {
+		c.logger.Info("Introspection with isOneOf failed, retrying with the standard query",
+			"request_id", requestID,
+			"endpoint", c.endpoint,
+		)
+		resp, err = c.executeRequest(ctx, &GraphQLRequest{Query: IntrospectionQuery}, requestID)
+	}
 	if err != nil {
 		c.logger.Error(err, "Introspection query execution failed",
 			"request_id", requestID,
@@ -388,30 +431,37 @@ func (c *GraphQLClient) ExecuteQuery(ctx context.Context, query string, variable
 	return c.executeRequest(ctx, req, requestID)
 }
 
-// executeRequest performs the actual HTTP request
-func (c *GraphQLClient) executeRequest(ctx context.Context, req *GraphQLRequest, requestID string) (*GraphQLResponse, error) {
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		c.logger.Error(err, "Failed to marshal GraphQL request",
-			"request_id", requestID,
-		)
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
+// doRequest sends the request body to the endpoint, retrying once with refreshed credentials
+// if the server rejects the credentials of the credential provider
+func (c *GraphQLClient) doRequest(ctx context.Context, jsonData []byte, requestID string) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		httpReq, err := c.newHTTPRequest(ctx, jsonData)
+		if err != nil {
+			return nil, err
+		}
 
-	// Log the request details at debug level
-	c.logger.V(1).Info("GraphQL request details",
-		"request_id", requestID,
-		"query", req.Query,
-		"variables", req.Variables,
-		"request_size_bytes", len(jsonData),
-	)
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute request: %w", err)
+		}
+		if resp.StatusCode != http.StatusUnauthorized || c.credentials == nil || attempt > 1 {
+			return resp, nil
+		}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.endpoint, bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.logger.Error(err, "Failed to create HTTP request",
+		c.logger.Info("GraphQL server rejected credentials, retrying with refreshed credentials",
 			"request_id", requestID,
 			"endpoint", c.endpoint,
 		)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		c.credentials.Invalidate(ctx)
+	}
+}
+
+// newHTTPRequest creates a GraphQL HTTP request with the client, passthru and credential headers
+func (c *GraphQLClient) newHTTPRequest(ctx context.Context, jsonData []byte) (*http.Request, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.endpoint, bytes.NewReader(jsonData))
+	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
@@ -423,14 +473,43 @@ func (c *GraphQLClient) executeRequest(ctx context.Context, req *GraphQLRequest,
 	}
 
 	// Set passthru headers from context
-	if passthruHeaders := GetPassthruHeaders(ctx); passthruHeaders != nil {
-		for key, value := range passthruHeaders {
-			httpReq.Header.Set(key, value)
+	for key, values := range GetPassthruHeaderValues(ctx) {
+		httpReq.Header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+
+	// Credentials from the provider take precedence over static and passthru headers
+	if c.credentials != nil {
+		token, err := c.credentials.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get credentials: %w", err)
 		}
+		httpReq.Header.Set("Authorization", token.AuthorizationHeader())
 	}
 
+	return httpReq, nil
+}
+
+// executeRequest performs the actual HTTP request
+func (c *GraphQLClient) executeRequest(ctx context.Context, req *GraphQLRequest, requestID string) (*GraphQLResponse, error) {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		c.logger.Error(err, "Failed to marshal GraphQL request",
+			"request_id", requestID,
+		)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Log the request details at debug level; variable values can be sensitive, so only their
+	// names are logged
+	c.logger.V(1).Info("GraphQL request details",
+		"request_id", requestID,
+		"query", req.Query,
+		"variables", slices.Sorted(maps.Keys(req.Variables)),
+		"request_size_bytes", len(jsonData),
+	)
+
 	startTime := time.Now()
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.doRequest(ctx, jsonData, requestID)
 	duration := time.Since(startTime)
 
 	if err != nil {
@@ -439,7 +518,7 @@ func (c *GraphQLClient) executeRequest(ctx context.Context, req *GraphQLRequest,
 			"endpoint", c.endpoint,
 			"duration_ms", duration.Milliseconds(),
 		)
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -462,10 +541,10 @@ func (c *GraphQLClient) executeRequest(ctx context.Context, req *GraphQLRequest,
 			"status_code", resp.StatusCode,
 			"response_body", string(body),
 		)
-		return nil, fmt.Errorf("GraphQL request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode, Body: string(body), Header: resp.Header}
 	}
 
-	var graphqlResp GraphQLResponse
+	graphqlResp := GraphQLResponse{StatusCode: resp.StatusCode, Header: resp.Header}
 	if err := json.Unmarshal(body, &graphqlResp); err != nil {
 		c.logger.Error(err, "Failed to unmarshal GraphQL response",
 			"request_id", requestID,
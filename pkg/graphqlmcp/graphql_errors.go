@@ -0,0 +1,144 @@
+package graphqlmcp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GraphQLError represents a single entry of the errors list in a GraphQL response
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Locations  []GraphQLErrorLocation `json:"locations,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLErrorLocation points at the position in the operation that caused an error
+type GraphQLErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error implements the error interface
+func (e GraphQLError) Error() string {
+	return e.Message
+}
+
+// Code returns the error code from extensions.code, or an empty string if there is none
+func (e GraphQLError) Code() string {
+	if code, ok := e.Extensions["code"].(string); ok {
+		return code
+	}
+	return ""
+}
+
+// PathString renders the response path of the error, e.g. "equipment.0.facility"
+func (e GraphQLError) PathString() string {
+	parts := make([]string, len(e.Path))
+	for i, segment := range e.Path {
+		switch v := segment.(type) {
+		case float64:
+			parts[i] = fmt.Sprintf("%d", int(v))
+		default:
+			parts[i] = fmt.Sprintf("%v", v)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// describe renders the error with its code, path and location on one line
+func (e GraphQLError) describe() string {
+	var sb strings.Builder
+	if code := e.Code(); code != "" {
+		sb.WriteString("[" + code + "] ")
+	}
+	sb.WriteString(e.Message)
+
+	var details []string
+	if path := e.PathString(); path != "" {
+		details = append(details, "path: "+path)
+	}
+	for _, location := range e.Locations {
+		details = append(details, fmt.Sprintf("line %d, column %d", location.Line, location.Column))
+	}
+	if len(details) > 0 {
+		sb.WriteString(" (" + strings.Join(details, "; ") + ")")
+	}
+	return sb.String()
+}
+
+// graphQLErrorGuidance maps well-known error codes to guidance for the model
+var graphQLErrorGuidance = map[string]string{
+	"UNAUTHENTICATED":           "The request was not authenticated. The caller's credentials are missing or expired; retrying will not help until the user re-authenticates.",
+	"FORBIDDEN":                 "The caller is not allowed to access this data. Do not retry; ask the user whether they have the required permissions.",
+	"BAD_USER_INPUT":            "One or more arguments were invalid. Check the argument values against the tool's input schema and the error message, correct them, and try again.",
+	"GRAPHQL_VALIDATION_FAILED": "The generated operation did not validate against the server's schema. The schema may have changed; retrying with the same tool will likely fail again.",
+	"GRAPHQL_PARSE_FAILED":      "The operation could not be parsed by the server. Retrying with the same tool will likely fail again.",
+	"NOT_FOUND":                 "The requested item does not exist. Check the identifier, or list the available items first.",
+	"RATE_LIMITED":              "The upstream API rate limit was exceeded. Wait before retrying, and request fewer or smaller results.",
+	"TOO_MANY_REQUESTS":         "The upstream API rate limit was exceeded. Wait before retrying, and request fewer or smaller results.",
+	"THROTTLED":                 "The upstream API rate limit was exceeded. Wait before retrying, and request fewer or smaller results.",
+	"INTERNAL_SERVER_ERROR":     "The upstream server failed while resolving this request. Retrying later may succeed.",
+}
+
+// formatGraphQLErrors renders GraphQL errors with guidance for well-known error codes
+func formatGraphQLErrors(header string, errs []GraphQLError) string {
+	var sb strings.Builder
+	sb.WriteString(header)
+
+	var guidance []string
+	seen := make(map[string]bool)
+	for _, err := range errs {
+		sb.WriteString("\n- ")
+		sb.WriteString(err.describe())
+
+		code := strings.ToUpper(err.Code())
+		if text, ok := graphQLErrorGuidance[code]; ok && !seen[code] {
+			seen[code] = true
+			guidance = append(guidance, text)
+		}
+	}
+
+	if len(guidance) > 0 {
+		sb.WriteString("\n\nGuidance:")
+		for _, text := range guidance {
+			sb.WriteString("\n- ")
+			sb.WriteString(text)
+		}
+	}
+	return sb.String()
+}
+
+// hasPartialData reports whether a GraphQL response carries any non-null data
+func hasPartialData(data interface{}) bool {
+	switch v := data.(type) {
+	case nil:
+		return false
+	case map[string]interface{}:
+		for _, value := range v {
+			if value != nil {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// graphQLResultMeta builds the tool result _meta entry that exposes errors and extensions
+// to downstream logic, or nil if the response carried neither
+func graphQLResultMeta(resp *GraphQLResponse) map[string]interface{} {
+	if len(resp.Errors) == 0 && len(resp.Extensions) == 0 {
+		return nil
+	}
+
+	meta := make(map[string]interface{})
+	if len(resp.Errors) > 0 {
+		meta["errors"] = resp.Errors
+	}
+	if len(resp.Extensions) > 0 {
+		meta["extensions"] = resp.Extensions
+	}
+	return map[string]interface{}{"graphql": meta}
+}
@@ -0,0 +1,93 @@
+package graphqlmcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphQLResponse_UnmarshalErrorDetails(t *testing.T) {
+	body := `{
+		"data": {"equipmentById": null},
+		"errors": [{
+			"message": "Not authorized",
+			"path": ["equipmentById", "facility", 0],
+			"locations": [{"line": 2, "column": 3}],
+			"extensions": {"code": "FORBIDDEN", "requiredRole": "operator"}
+		}],
+		"extensions": {"cost": {"requested": 12}}
+	}`
+
+	var resp GraphQLResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+	require.Len(t, resp.Errors, 1)
+
+	gqlErr := resp.Errors[0]
+	assert.Equal(t, "FORBIDDEN", gqlErr.Code())
+	assert.Equal(t, "equipmentById.facility.0", gqlErr.PathString())
+	assert.Equal(t, []GraphQLErrorLocation{{Line: 2, Column: 3}}, gqlErr.Locations)
+	assert.Equal(t, "operator", gqlErr.Extensions["requiredRole"])
+	assert.Equal(t, "[FORBIDDEN] Not authorized (path: equipmentById.facility.0; line 2, column 3)", gqlErr.describe())
+	assert.NotNil(t, resp.Extensions["cost"])
+}
+
+func TestFormatGraphQLErrors_Guidance(t *testing.T) {
+	text := formatGraphQLErrors("GraphQL query errors:", []GraphQLError{
+		{Message: "token expired", Extensions: map[string]interface{}{"code": "UNAUTHENTICATED"}},
+		{Message: "bad id", Extensions: map[string]interface{}{"code": "BAD_USER_INPUT"}},
+		{Message: "bad status", Extensions: map[string]interface{}{"code": "BAD_USER_INPUT"}},
+		{Message: "boom"},
+	})
+
+	assert.Contains(t, text, "- [UNAUTHENTICATED] token expired")
+	assert.Contains(t, text, "- boom")
+	assert.Contains(t, text, graphQLErrorGuidance["UNAUTHENTICATED"])
+	assert.Contains(t, text, graphQLErrorGuidance["BAD_USER_INPUT"])
+	assert.Equal(t, 1, strings.Count(text, graphQLErrorGuidance["BAD_USER_INPUT"]))
+}
+
+func TestMCPGraphQLServer_PartialData(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("map[string]interface {}")).
+		Return(&GraphQLResponse{
+			Data: map[string]interface{}{
+				"equipment": []interface{}{
+					map[string]interface{}{"id": "1", "facility": nil},
+				},
+			},
+			Errors: []GraphQLError{
+				{
+					Message:    "facility service unavailable",
+					Path:       []interface{}{"equipment", float64(0), "facility"},
+					Extensions: map[string]interface{}{"code": "INTERNAL_SERVER_ERROR"},
+				},
+			},
+			Extensions: map[string]interface{}{"traceId": "abc"},
+		}, nil)
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor, WithResponseFormatter(NewCompactJSONFormatter()))
+	require.NoError(t, err)
+
+	result, err := server.executeGraphQLOperation(context.Background(), findField(t, testSchema.GetQueries(), "equipment"), map[string]interface{}{}, "query")
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	require.Len(t, result.Content, 2)
+	assert.Equal(t, `{"equipment":[{"facility":null,"id":"1"}]}`, result.Content[0].(*mcp.TextContent).Text)
+
+	errorsText := result.Content[1].(*mcp.TextContent).Text
+	assert.Contains(t, errorsText, "partial data")
+	assert.Contains(t, errorsText, "[INTERNAL_SERVER_ERROR] facility service unavailable (path: equipment.0.facility)")
+
+	graphqlMeta := result.Meta["graphql"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"traceId": "abc"}, graphqlMeta["extensions"])
+	assert.Len(t, graphqlMeta["errors"], 1)
+}
@@ -2,6 +2,7 @@ package graphqlmcp
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/peterbeamish/go-mcp-graphql/pkg/graphqlmcp/schema"
 )
@@ -11,12 +12,37 @@ type passthruHeadersKey struct{}
 
 // AddPassthruHeadersToContext adds passthru headers to the context
 func AddPassthruHeadersToContext(ctx context.Context, headers map[string]string) context.Context {
+	var values http.Header
+	if headers != nil {
+		values = make(http.Header, len(headers))
+		for name, value := range headers {
+			values.Set(name, value)
+		}
+	}
+	return AddPassthruHeaderValuesToContext(ctx, values)
+}
+
+// AddPassthruHeaderValuesToContext adds passthru headers with all their values to the context
+func AddPassthruHeaderValuesToContext(ctx context.Context, headers http.Header) context.Context {
 	return context.WithValue(ctx, passthruHeadersKey{}, headers)
 }
 
-// GetPassthruHeaders retrieves passthru headers from the context
+// GetPassthruHeaders retrieves passthru headers from the context, with the first value of each
 func GetPassthruHeaders(ctx context.Context) map[string]string {
-	if headers, ok := ctx.Value(passthruHeadersKey{}).(map[string]string); ok {
+	values := GetPassthruHeaderValues(ctx)
+	if values == nil {
+		return nil
+	}
+	headers := make(map[string]string, len(values))
+	for name := range values {
+		headers[name] = values.Get(name)
+	}
+	return headers
+}
+
+// GetPassthruHeaderValues retrieves passthru headers with all their values from the context
+func GetPassthruHeaderValues(ctx context.Context) http.Header {
+	if headers, ok := ctx.Value(passthruHeadersKey{}).(http.Header); ok {
 		return headers
 	}
 	return nil
@@ -0,0 +1,47 @@
+package graphqlmcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/peterbeamish/go-mcp-graphql/pkg/graphqlmcp/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphQLClient_IntrospectSchemaOneOfFallback(t *testing.T) {
+	introspection, err := os.ReadFile("testdata/real_introspection_response.json")
+	require.NoError(t, err)
+
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GraphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		queries = append(queries, req.Query)
+
+		// Simulate a server that predates __Type.isOneOf
+		if strings.Contains(req.Query, "isOneOf") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":[{"message":"Cannot query field \"isOneOf\" on type \"__Type\"."}]}`))
+			return
+		}
+		_, _ = w.Write(introspection)
+	}))
+	defer server.Close()
+
+	client := NewGraphQLClient(server.URL)
+	result, err := client.IntrospectSchema(context.Background())
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.GetQueries())
+
+	require.Len(t, queries, 2)
+	assert.Equal(t, IntrospectionQueryWithOneOf, queries[0])
+	assert.Contains(t, queries[0], "isOneOf")
+	assert.Equal(t, IntrospectionQuery, queries[1])
+	assert.False(t, schema.IsOneOfInputObject(result.GetTypeDefinition("EquipmentInput")))
+}
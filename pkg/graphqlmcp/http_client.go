@@ -1,52 +1,96 @@
 package graphqlmcp
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
+	"maps"
 	"net/http"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-// MCPRequest represents an MCP request over HTTP
-type MCPRequest struct {
-	Method string      `json:"method"`
-	Params interface{} `json:"params,omitempty"`
-	ID     interface{} `json:"id,omitempty"`
+// HTTPMCPClient is an MCP client for servers on the streamable HTTP transport, such as the /mcp
+// endpoint of GetCompleteMux
+// The session is initialized on the first call and started again after the server ends it
+type HTTPMCPClient struct {
+	endpoint   string
+	httpClient *http.Client
+	header     http.Header
+	timeout    time.Duration
+	onNotify   func(context.Context, ClientNotification)
+	logger     logr.Logger
+
+	mu       sync.Mutex
+	session  *mcp.ClientSession
+	connects callGroup[*mcp.ClientSession]
 }
 
-// MCPResponse represents an MCP response over HTTP
-type MCPResponse struct {
-	Result interface{} `json:"result,omitempty"`
-	Error  *MCPError   `json:"error,omitempty"`
-	ID     interface{} `json:"id,omitempty"`
+// HTTPMCPClientOption configures an HTTPMCPClient
+type HTTPMCPClientOption func(*HTTPMCPClient)
+
+// ClientNotification is a notification the MCP server sent to an HTTPMCPClient, such as
+// notifications/tools/list_changed or notifications/progress
+type ClientNotification struct {
+	// Method is the method of the notification
+	Method string
+	// Params are the typed params of the notification, e.g. *mcp.ProgressNotificationParams
+	Params mcp.Params
 }
 
-// MCPError represents an MCP error
-type MCPError struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+// WithClientHeaders sets headers sent with every request of the client
+func WithClientHeaders(header http.Header) HTTPMCPClientOption {
+	return func(c *HTTPMCPClient) {
+		c.header = header.Clone()
+	}
 }
 
-// HTTPMCPClient is an HTTP client for MCP communication
-type HTTPMCPClient struct {
-	baseURL string
-	client  *http.Client
-	logger  logr.Logger
+// WithClientHTTPClient sets the HTTP client used to reach the MCP server
+// Its Timeout should be zero, since the server streams notifications over a long-lived response
+func WithClientHTTPClient(client *http.Client) HTTPMCPClientOption {
+	return func(c *HTTPMCPClient) {
+		c.httpClient = client
+	}
 }
 
-// CreateHTTPClient creates an HTTP client for communicating with the MCP server
-func CreateHTTPClient(baseURL string) *HTTPMCPClient {
-	return &HTTPMCPClient{
-		baseURL: baseURL,
-		client:  &http.Client{Timeout: 30 * time.Second},
-		logger:  logr.Discard(),
+// WithClientTimeout sets the timeout of each call; zero leaves it to the context of the call
+func WithClientTimeout(timeout time.Duration) HTTPMCPClientOption {
+	return func(c *HTTPMCPClient) {
+		c.timeout = timeout
+	}
+}
+
+// WithNotificationHandler sets a handler for the notifications the server streams to the client
+// The handler is called in the order the notifications arrive and should not block
+func WithNotificationHandler(handler func(context.Context, ClientNotification)) HTTPMCPClientOption {
+	return func(c *HTTPMCPClient) {
+		c.onNotify = handler
+	}
+}
+
+// NewHTTPMCPClient creates an MCP client for the streamable HTTP endpoint at endpoint, e.g.
+// http://localhost:8081/mcp or the prefix of a server profile
+func NewHTTPMCPClient(endpoint string, opts ...HTTPMCPClientOption) *HTTPMCPClient {
+	c := &HTTPMCPClient{
+		endpoint:   endpoint,
+		httpClient: &http.Client{},
+		timeout:    30 * time.Second,
+		logger:     logr.Discard(),
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
+}
+
+// CreateHTTPClient creates an HTTP client for communicating with the MCP server
+// baseURL is the address GetCompleteMux is served at; the client connects to its /mcp endpoint
+func CreateHTTPClient(baseURL string, opts ...HTTPMCPClientOption) *HTTPMCPClient {
+	return NewHTTPMCPClient(strings.TrimSuffix(baseURL, "/")+"/mcp", opts...)
 }
 
 // SetLogger sets a custom logger for the HTTP client
@@ -54,197 +98,282 @@ func (c *HTTPMCPClient) SetLogger(logger logr.Logger) {
 	c.logger = logger
 }
 
-// CallTool calls an MCP tool via HTTP
-func (c *HTTPMCPClient) CallTool(ctx context.Context, toolName string, arguments map[string]interface{}) (*MCPResponse, error) {
-	requestID := fmt.Sprintf("http_req_%d", time.Now().UnixNano())
+// clientHeadersKey is the context key of the headers of a single call
+type clientHeadersKey struct{}
 
-	// Log HTTP tool call initiation
-	c.logger.Info("HTTP tool call initiated",
-		"request_id", requestID,
-		"tool_name", toolName,
-		"arguments", arguments,
-		"base_url", c.baseURL,
-	)
+// ContextWithClientHeaders returns a context whose HTTPMCPClient calls send the given headers
+// in addition to the headers of the client, e.g. a tracing header for one tool call
+func ContextWithClientHeaders(ctx context.Context, header http.Header) context.Context {
+	return context.WithValue(ctx, clientHeadersKey{}, header)
+}
 
-	request := MCPRequest{
-		Method: "tools/call",
-		Params: map[string]interface{}{
-			"name":      toolName,
-			"arguments": arguments,
-		},
-		ID: requestID,
+// clientHeadersTransport adds the headers of the client and of the call to every request
+type clientHeadersTransport struct {
+	base   http.RoundTripper
+	header http.Header
+}
+
+func (t *clientHeadersTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	callHeader, _ := req.Context().Value(clientHeadersKey{}).(http.Header)
+	if len(t.header) == 0 && len(callHeader) == 0 {
+		return t.base.RoundTrip(req)
 	}
 
-	jsonData, err := json.Marshal(request)
-	if err != nil {
-		c.logger.Error(err, "Failed to marshal HTTP request",
-			"request_id", requestID,
-			"tool_name", toolName,
-		)
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	req = req.Clone(req.Context())
+	for _, header := range []http.Header{t.header, callHeader} {
+		for name, values := range header {
+			req.Header.Del(name)
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
 	}
+	return t.base.RoundTrip(req)
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/mcp", bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.logger.Error(err, "Failed to create HTTP request",
-			"request_id", requestID,
-			"tool_name", toolName,
-		)
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// Connect initializes an MCP session unless the client already has one
+// Calls connect on demand, so calling Connect is only needed to fail early
+func (c *HTTPMCPClient) Connect(ctx context.Context) error {
+	_, err := c.getSession(ctx)
+	return err
+}
+
+// SessionID returns the ID the server assigned to the current session, or "" without one
+func (c *HTTPMCPClient) SessionID() string {
+	session := c.currentSession()
+	if session == nil {
+		return ""
 	}
+	return session.ID()
+}
 
-	req.Header.Set("Content-Type", "application/json")
+// Close ends the current session; the next call starts a new one
+func (c *HTTPMCPClient) Close() error {
+	c.mu.Lock()
+	session := c.session
+	c.session = nil
+	c.mu.Unlock()
 
-	// Log the HTTP request details
-	c.logger.V(1).Info("Sending HTTP request",
-		"request_id", requestID,
-		"tool_name", toolName,
-		"url", req.URL.String(),
-		"method", req.Method,
-		"request_size_bytes", len(jsonData),
-	)
+	if session == nil {
+		return nil
+	}
+	return session.Close()
+}
 
-	startTime := time.Now()
-	resp, err := c.client.Do(req)
-	duration := time.Since(startTime)
+// defaultInitializeTimeout bounds the initialize handshake when the client has no call timeout
+const defaultInitializeTimeout = 30 * time.Second
 
-	if err != nil {
-		c.logger.Error(err, "HTTP request execution failed",
-			"request_id", requestID,
-			"tool_name", toolName,
-			"duration_ms", duration.Milliseconds(),
-		)
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+// getSession returns the current session, initializing a new one if there is none
+// Concurrent calls share one initialize handshake, which runs without holding the lock and is
+// not cancelled with the call that started it; the ctx of each call only bounds its wait
+func (c *HTTPMCPClient) getSession(ctx context.Context) (*mcp.ClientSession, error) {
+	if session := c.currentSession(); session != nil {
+		return session, nil
+	}
+	return c.connects.do(ctx, "", func() (*mcp.ClientSession, error) {
+		// A handshake that finished since the check above has installed its session
+		if session := c.currentSession(); session != nil {
+			return session, nil
+		}
+		timeout := c.timeout
+		if timeout <= 0 {
+			timeout = defaultInitializeTimeout
+		}
+		connectCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+		session, err := c.connect(connectCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.session = session
+
+		// Forget the session once it ends, so the next call initializes a new one
+		go func() {
+			err := session.Wait()
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.session == session {
+				c.logger.Info("MCP session ended", "session_id", session.ID(), "error", err)
+				c.session = nil
+			}
+		}()
+		return session, nil
+	})
+}
+
+// currentSession returns the current session, or nil without one
+func (c *HTTPMCPClient) currentSession() *mcp.ClientSession {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.session
+}
+
+// connect runs the initialize handshake of a new session
+func (c *HTTPMCPClient) connect(ctx context.Context) (*mcp.ClientSession, error) {
+	base := c.httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
 	}
-	defer resp.Body.Close()
+	httpClient := *c.httpClient
+	httpClient.Transport = &clientHeadersTransport{base: base, header: c.header}
+
+	transport := &sessionTransport{Transport: &mcp.StreamableClientTransport{
+		Endpoint:   c.endpoint,
+		HTTPClient: &httpClient,
+	}}
 
-	body, err := io.ReadAll(resp.Body)
+	c.logger.Info("Initializing MCP session", "endpoint", c.endpoint)
+	startTime := time.Now()
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "graphql-mcp-client", Version: "1.0.0"}, c.clientOptions()).
+		Connect(ctx, transport, nil)
 	if err != nil {
-		c.logger.Error(err, "Failed to read HTTP response body",
-			"request_id", requestID,
-			"tool_name", toolName,
-			"duration_ms", duration.Milliseconds(),
-			"status_code", resp.StatusCode,
+		c.logger.Error(err, "Failed to initialize MCP session",
+			"endpoint", c.endpoint,
+			"duration_ms", time.Since(startTime).Milliseconds(),
 		)
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to initialize MCP session: %w", err)
 	}
+	c.logger.Info("MCP session initialized",
+		"endpoint", c.endpoint,
+		"session_id", session.ID(),
+		"server", session.InitializeResult().ServerInfo.Name,
+		"protocol_version", session.InitializeResult().ProtocolVersion,
+		"duration_ms", time.Since(startTime).Milliseconds(),
+	)
+	return session, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		c.logger.Info("HTTP request failed with non-OK status",
-			"request_id", requestID,
-			"tool_name", toolName,
-			"duration_ms", duration.Milliseconds(),
-			"status_code", resp.StatusCode,
-			"response_body", string(body),
-		)
-		return nil, fmt.Errorf("HTTP request failed with status %d: %s", resp.StatusCode, string(body))
+// sessionTransport connects independently of the context of the call that starts the session,
+// which would otherwise end the session together with the call
+type sessionTransport struct {
+	mcp.Transport
+}
+
+func (t *sessionTransport) Connect(ctx context.Context) (mcp.Connection, error) {
+	return t.Transport.Connect(context.Background())
+}
+
+// clientOptions forwards the notifications of the server to the notification handler
+func (c *HTTPMCPClient) clientOptions() *mcp.ClientOptions {
+	if c.onNotify == nil {
+		return nil
 	}
+	return &mcp.ClientOptions{
+		ToolListChangedHandler: func(ctx context.Context, req *mcp.ToolListChangedRequest) {
+			c.onNotify(ctx, ClientNotification{Method: "notifications/tools/list_changed", Params: req.Params})
+		},
+		PromptListChangedHandler: func(ctx context.Context, req *mcp.PromptListChangedRequest) {
+			c.onNotify(ctx, ClientNotification{Method: "notifications/prompts/list_changed", Params: req.Params})
+		},
+		ResourceListChangedHandler: func(ctx context.Context, req *mcp.ResourceListChangedRequest) {
+			c.onNotify(ctx, ClientNotification{Method: "notifications/resources/list_changed", Params: req.Params})
+		},
+		ResourceUpdatedHandler: func(ctx context.Context, req *mcp.ResourceUpdatedNotificationRequest) {
+			c.onNotify(ctx, ClientNotification{Method: "notifications/resources/updated", Params: req.Params})
+		},
+		LoggingMessageHandler: func(ctx context.Context, req *mcp.LoggingMessageRequest) {
+			c.onNotify(ctx, ClientNotification{Method: "notifications/message", Params: req.Params})
+		},
+		ProgressNotificationHandler: func(ctx context.Context, req *mcp.ProgressNotificationClientRequest) {
+			c.onNotify(ctx, ClientNotification{Method: "notifications/progress", Params: req.Params})
+		},
+	}
+}
 
-	var mcpResp MCPResponse
-	if err := json.Unmarshal(body, &mcpResp); err != nil {
-		c.logger.Error(err, "Failed to unmarshal HTTP response",
-			"request_id", requestID,
-			"tool_name", toolName,
-			"duration_ms", duration.Milliseconds(),
-			"response_size_bytes", len(body),
-		)
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+// withTimeout applies the timeout of the client to the context of a call
+func (c *HTTPMCPClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return ctx, func() {}
 	}
+	return context.WithTimeout(ctx, c.timeout)
+}
 
-	// Log successful completion
-	c.logger.Info("HTTP tool call completed successfully",
-		"request_id", requestID,
-		"tool_name", toolName,
-		"duration_ms", duration.Milliseconds(),
-		"response_size_bytes", len(body),
-		"has_error", mcpResp.Error != nil,
-	)
+// argumentNames returns the sorted names of tool arguments given as a map, so their values,
+// which can be sensitive, stay out of the logs
+func argumentNames(arguments any) []string {
+	args, ok := arguments.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(args))
+}
 
-	return &mcpResp, nil
+// CallTool calls an MCP tool
+// Errors of the tool itself are reported in the result with IsError set, not as an error
+func (c *HTTPMCPClient) CallTool(ctx context.Context, toolName string, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	return c.CallToolWithParams(ctx, &mcp.CallToolParams{Name: toolName, Arguments: arguments})
 }
 
-// ListTools lists available MCP tools
-func (c *HTTPMCPClient) ListTools(ctx context.Context) ([]map[string]interface{}, error) {
-	requestID := fmt.Sprintf("list_tools_%d", time.Now().UnixNano())
+// CallToolWithParams calls an MCP tool with the full params of tools/call, e.g. to set a
+// progress token for notifications/progress
+func (c *HTTPMCPClient) CallToolWithParams(ctx context.Context, params *mcp.CallToolParams) (*mcp.CallToolResult, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 
-	c.logger.Info("Listing MCP tools",
-		"request_id", requestID,
-		"base_url", c.baseURL,
+	c.logger.Info("HTTP tool call initiated",
+		"tool_name", params.Name,
+		"arguments", argumentNames(params.Arguments),
+		"endpoint", c.endpoint,
 	)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/tools", nil)
+	session, err := c.getSession(ctx)
 	if err != nil {
-		c.logger.Error(err, "Failed to create tools list request",
-			"request_id", requestID,
-		)
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
 	startTime := time.Now()
-	resp, err := c.client.Do(req)
+	result, err := session.CallTool(ctx, params)
 	duration := time.Since(startTime)
-
 	if err != nil {
-		c.logger.Error(err, "Failed to execute tools list request",
-			"request_id", requestID,
+		c.logger.Error(err, "HTTP tool call failed",
+			"session_id", session.ID(),
+			"tool_name", params.Name,
 			"duration_ms", duration.Milliseconds(),
 		)
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, fmt.Errorf("failed to call tool %s: %w", params.Name, err)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		c.logger.Error(err, "Failed to read tools list response",
-			"request_id", requestID,
-			"duration_ms", duration.Milliseconds(),
-			"status_code", resp.StatusCode,
-		)
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
+	c.logger.Info("HTTP tool call completed",
+		"session_id", session.ID(),
+		"tool_name", params.Name,
+		"duration_ms", duration.Milliseconds(),
+		"is_error", result.IsError,
+	)
+	return result, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		c.logger.Info("Tools list request failed with non-OK status",
-			"request_id", requestID,
-			"duration_ms", duration.Milliseconds(),
-			"status_code", resp.StatusCode,
-			"response_body", string(body),
-		)
-		return nil, fmt.Errorf("HTTP request failed with status %d: %s", resp.StatusCode, string(body))
-	}
+// ListTools lists the available MCP tools, following the cursors of tools/list across pages
+func (c *HTTPMCPClient) ListTools(ctx context.Context) ([]*mcp.Tool, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.logger.Error(err, "Failed to unmarshal tools list response",
-			"request_id", requestID,
-			"duration_ms", duration.Milliseconds(),
-			"response_size_bytes", len(body),
-		)
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
+	c.logger.Info("Listing MCP tools", "endpoint", c.endpoint)
 
-	tools, ok := result["tools"].([]interface{})
-	if !ok {
-		c.logger.Info("Invalid tools response format",
-			"request_id", requestID,
-			"duration_ms", duration.Milliseconds(),
-			"response", result,
-		)
-		return nil, fmt.Errorf("invalid tools response format")
+	session, err := c.getSession(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	toolMaps := make([]map[string]interface{}, len(tools))
-	for i, tool := range tools {
-		if toolMap, ok := tool.(map[string]interface{}); ok {
-			toolMaps[i] = toolMap
+	startTime := time.Now()
+	var tools []*mcp.Tool
+	for tool, err := range session.Tools(ctx, nil) {
+		if err != nil {
+			c.logger.Error(err, "Failed to list MCP tools",
+				"session_id", session.ID(),
+				"duration_ms", time.Since(startTime).Milliseconds(),
+			)
+			return nil, fmt.Errorf("failed to list tools: %w", err)
 		}
+		tools = append(tools, tool)
 	}
 
 	c.logger.Info("Successfully listed MCP tools",
-		"request_id", requestID,
-		"duration_ms", duration.Milliseconds(),
-		"tool_count", len(toolMaps),
+		"session_id", session.ID(),
+		"duration_ms", time.Since(startTime).Milliseconds(),
+		"tool_count", len(tools),
 	)
-
-	return toolMaps, nil
+	return tools, nil
 }
@@ -0,0 +1,179 @@
+package graphqlmcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPMCPClient(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	// Headers of the client and of the call reach the executor as passthru headers
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.MatchedBy(func(ctx context.Context) bool {
+		header := GetPassthruHeaderValues(ctx)
+		return header.Get("X-Client") == "reporting" && header.Get("X-Request-Tag") == "call-1"
+	}), mock.AnythingOfType("string"), mock.Anything).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"facilities": []interface{}{}}}, nil).Once()
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor, WithPassthruHeaders([]string{"X-Client", "X-Request-Tag"}))
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(GetCompleteMux(server))
+	t.Cleanup(httpServer.Close)
+
+	client := CreateHTTPClient(httpServer.URL+"/", WithClientHeaders(http.Header{"X-Client": {"reporting"}}))
+	t.Cleanup(func() { client.Close() })
+	assert.Empty(t, client.SessionID())
+
+	tools, err := client.ListTools(context.Background())
+	require.NoError(t, err)
+	expected := listTools(t, server)
+	require.Len(t, tools, len(expected))
+	for _, tool := range tools {
+		assert.Contains(t, expected, tool.Name)
+	}
+
+	// The session of the initialize handshake is used for later calls
+	sessionID := client.SessionID()
+	require.NotEmpty(t, sessionID)
+
+	ctx := ContextWithClientHeaders(context.Background(), http.Header{"X-Request-Tag": {"call-1"}})
+	result, err := client.CallTool(ctx, "query_facilities", map[string]interface{}{})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Equal(t, sessionID, client.SessionID())
+	mockExecutor.AssertExpectations(t)
+
+	// Protocol errors are returned as errors
+	_, err = client.CallTool(context.Background(), "query_unknown", nil)
+	assert.ErrorContains(t, err, "failed to call tool query_unknown")
+
+	// Closing ends the session and the next call starts a new one
+	require.NoError(t, client.Close())
+	assert.Empty(t, client.SessionID())
+	require.NoError(t, client.Connect(context.Background()))
+	assert.NotEmpty(t, client.SessionID())
+	assert.NotEqual(t, sessionID, client.SessionID())
+}
+
+func TestHTTPMCPClient_PagesAndNotifications(t *testing.T) {
+	mcpServer := mcp.NewServer(&mcp.Implementation{Name: "paged", Version: "1.0.0"}, &mcp.ServerOptions{PageSize: 2})
+	addTool := func(name string) {
+		mcpServer.AddTool(&mcp.Tool{Name: name, InputSchema: map[string]interface{}{"type": "object"}},
+			func(context.Context, *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: name}}}, nil
+			})
+	}
+	for i := range 5 {
+		addTool(fmt.Sprintf("tool_%d", i))
+	}
+	httpServer := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return mcpServer }, nil))
+	t.Cleanup(httpServer.Close)
+
+	notifications := make(chan ClientNotification, 10)
+	client := NewHTTPMCPClient(httpServer.URL, WithNotificationHandler(func(_ context.Context, notification ClientNotification) {
+		notifications <- notification
+	}))
+	t.Cleanup(func() { client.Close() })
+
+	// tools/list is followed across pages
+	tools, err := client.ListTools(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, tools, 5)
+
+	result, err := client.CallTool(context.Background(), "tool_3", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "tool_3", result.Content[0].(*mcp.TextContent).Text)
+
+	// Notifications of the server are streamed to the handler
+	addTool("tool_5")
+	select {
+	case notification := <-notifications:
+		assert.Equal(t, "notifications/tools/list_changed", notification.Method)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification received")
+	}
+}
+
+func TestHTTPMCPClient_ConcurrentConnect(t *testing.T) {
+	mcpServer := mcp.NewServer(&mcp.Implementation{Name: "concurrent", Version: "1.0.0"}, nil)
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return mcpServer }, nil)
+
+	// Requests without a session ID start the initialize handshake; hold them until every call waits
+	var handshakes atomic.Int32
+	release := make(chan struct{})
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.Header.Get("Mcp-Session-Id") == "" {
+			handshakes.Add(1)
+			<-release
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(httpServer.Close)
+
+	client := NewHTTPMCPClient(httpServer.URL)
+	t.Cleanup(func() { client.Close() })
+
+	// The session lock is not held during the handshake
+	errs := make(chan error, 5)
+	for range 5 {
+		go func() { errs <- client.Connect(context.Background()) }()
+	}
+	require.Eventually(t, func() bool { return handshakes.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, client.SessionID())
+	require.NoError(t, client.Close())
+
+	// Concurrent calls share the one handshake
+	close(release)
+	for range 5 {
+		require.NoError(t, <-errs)
+	}
+	assert.Equal(t, int32(1), handshakes.Load())
+	assert.NotEmpty(t, client.SessionID())
+}
+
+func TestHTTPMCPClient_CancelledConnect(t *testing.T) {
+	mcpServer := mcp.NewServer(&mcp.Implementation{Name: "cancelled", Version: "1.0.0"}, nil)
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return mcpServer }, nil)
+
+	var handshakes atomic.Int32
+	release := make(chan struct{})
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.Header.Get("Mcp-Session-Id") == "" {
+			handshakes.Add(1)
+			<-release
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(httpServer.Close)
+
+	client := NewHTTPMCPClient(httpServer.URL)
+	t.Cleanup(func() { client.Close() })
+
+	// The handshake keeps running for the other calls when the call that started it is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() { cancelled <- client.Connect(ctx) }()
+	require.Eventually(t, func() bool { return handshakes.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	errs := make(chan error, 1)
+	go func() { errs <- client.Connect(context.Background()) }()
+	cancel()
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+
+	close(release)
+	require.NoError(t, <-errs)
+	assert.Equal(t, int32(1), handshakes.Load())
+	assert.NotEmpty(t, client.SessionID())
+}
@@ -1,9 +1,13 @@
 package graphqlmcp
 
 import (
+	"context"
+	"crypto/rand"
 	"encoding/json"
 	"net/http"
+	"sync"
 
+	"github.com/modelcontextprotocol/go-sdk/auth"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -13,6 +17,7 @@ import (
 // NewMCPHandler returns the MCP endpoint handler using the MCP SDK's StreamableHTTPHandler
 // which handles all the HTTP transport details including SSE support
 // If a PassThruHeaderHandler is configured, it will be used to process headers
+// If authenticators are configured, requests without valid credentials are rejected
 func NewMCPHandler(server *MCPGraphQLServer) http.Handler {
 
 	// Use the MCP SDK's handler
@@ -23,13 +28,129 @@ func NewMCPHandler(server *MCPGraphQLServer) http.Handler {
 		nil,
 	)
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		passthruHeaders := server.ExtractPassthruHeaders(r)
-		r = r.WithContext(AddPassthruHeadersToContext(r.Context(), passthruHeaders))
-		handler.ServeHTTP(w, r)
+	return server.withRequestContext(handler)
+}
+
+// NewSSEHandler returns a handler for the HTTP+SSE transport of the 2024-11-05 MCP spec, using
+// the MCP SDK's SSEServerTransport, for clients that do not support streamable HTTP yet
+// A GET request opens a session and POST requests to the endpoint it announces carry the
+// messages. Every request is authenticated, and a POST is rejected with 403 Forbidden unless its
+// principal is the one of the GET request. The passthru headers and verified JWT of the GET
+// request apply to the whole session
+func NewSSEHandler(server *MCPGraphQLServer) http.Handler {
+	return server.withRequestContext(&sseHandler{
+		server:   server,
+		sessions: make(map[string]*sseSession),
 	})
 }
 
+// sseHandler serves the sessions of the HTTP+SSE transport
+type sseHandler struct {
+	server *MCPGraphQLServer
+
+	mu       sync.Mutex
+	sessions map[string]*sseSession
+}
+
+// sseSession is an open HTTP+SSE session and the subject of the principal that opened it
+type sseSession struct {
+	transport *mcp.SSEServerTransport
+	subject   string
+}
+
+func (h *sseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.servePost(w, r)
+	case http.MethodGet:
+		h.serveGet(w, r)
+	default:
+		http.Error(w, "invalid method", http.StatusMethodNotAllowed)
+	}
+}
+
+// servePost passes a message to the session it was posted to
+func (h *sseHandler) servePost(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.URL.Query().Get("sessionid")
+	if sessionID == "" {
+		http.Error(w, "sessionid must be provided", http.StatusBadRequest)
+		return
+	}
+	h.mu.Lock()
+	session := h.sessions[sessionID]
+	h.mu.Unlock()
+	if session == nil {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	if principalSubject(r.Context()) != session.subject {
+		http.Error(w, "session belongs to another principal", http.StatusForbidden)
+		return
+	}
+	session.transport.ServeHTTP(w, r)
+}
+
+// serveGet opens a session and streams its messages until the request or the session ends
+func (h *sseHandler) serveGet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	sessionID := rand.Text()
+	endpoint, err := r.URL.Parse("?sessionid=" + sessionID)
+	if err != nil {
+		http.Error(w, "failed to create session endpoint", http.StatusInternalServerError)
+		return
+	}
+	transport := &mcp.SSEServerTransport{Endpoint: endpoint.RequestURI(), Response: w}
+
+	h.mu.Lock()
+	h.sessions[sessionID] = &sseSession{transport: transport, subject: principalSubject(r.Context())}
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.sessions, sessionID)
+		h.mu.Unlock()
+	}()
+
+	session, err := h.server.GetMCPServer().Connect(r.Context(), transport, nil)
+	if err != nil {
+		http.Error(w, "failed to connect session", http.StatusInternalServerError)
+		return
+	}
+	defer session.Close()
+
+	ended := make(chan struct{})
+	go func() {
+		session.Wait()
+		close(ended)
+	}()
+	select {
+	case <-r.Context().Done():
+	case <-ended:
+	}
+}
+
+// principalSubject returns the subject of the principal of the context, or "" without one
+func principalSubject(ctx context.Context) string {
+	if principal := PrincipalFromContext(ctx); principal != nil {
+		return principal.Subject
+	}
+	return ""
+}
+
+// withRequestContext authenticates requests and adds their passthru header values and, when it
+// was verified as a JWT, their bearer token to the context the MCP handler serves them with
+func (s *MCPGraphQLServer) withRequestContext(handler http.Handler) http.Handler {
+	return s.requireAuthentication(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := AddPassthruHeaderValuesToContext(r.Context(), s.passthruHeaderValuesFrom(r.Header))
+		if token := subjectTokenFromTokenInfo(auth.TokenInfoFromContext(r.Context())); token != "" {
+			ctx = ContextWithSubjectToken(ctx, token)
+		}
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	}))
+}
+
 // GetHealthHandler returns a health check endpoint handler
 func GetHealthHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -62,15 +183,25 @@ func GetToolsHandler(server *MCPGraphQLServer) http.HandlerFunc {
 		// Get available tools from the schema
 		queries := server.GetSchema().GetQueries()
 		mutations := server.GetSchema().GetMutations()
+		if !server.options.isOperationTypeAllowed("query") {
+			queries = nil
+		}
+		if !server.options.isOperationTypeAllowed("mutation") {
+			mutations = nil
+		}
 
 		tools := make([]map[string]interface{}, 0, len(queries)+len(mutations))
+		canList := server.toolListFilter(r)
 
 		// Add query tools
 		for _, query := range queries {
-			inputSchema := server.createInputSchema(query)
+			if !server.options.isOperationAllowed(query.Name) || !canList("query_"+query.Name) {
+				continue
+			}
+			inputSchema := server.toolInputSchema(query, "query")
 			tools = append(tools, map[string]interface{}{
 				"name":        "query_" + query.Name,
-				"description": query.Description,
+				"description": server.options.toolDescription("query_"+query.Name, query.Description),
 				"type":        "query",
 				"inputSchema": inputSchema,
 			})
@@ -78,10 +209,13 @@ func GetToolsHandler(server *MCPGraphQLServer) http.HandlerFunc {
 
 		// Add mutation tools
 		for _, mutation := range mutations {
-			inputSchema := server.createInputSchema(mutation)
+			if !server.options.isOperationAllowed(mutation.Name) || !canList("mutation_"+mutation.Name) {
+				continue
+			}
+			inputSchema := server.toolInputSchema(mutation, "mutation")
 			tools = append(tools, map[string]interface{}{
 				"name":        "mutation_" + mutation.Name,
-				"description": mutation.Description,
+				"description": server.options.toolDescription("mutation_"+mutation.Name, mutation.Description),
 				"type":        "mutation",
 				"inputSchema": inputSchema,
 			})
@@ -101,9 +235,13 @@ func GetCompleteMux(server *MCPGraphQLServer) *http.ServeMux {
 
 	// Register all handlers
 	mux.Handle("/mcp", NewMCPHandler(server))
+	mux.Handle("/sse", NewSSEHandler(server))
 	mux.HandleFunc("/health", GetHealthHandler())
-	mux.HandleFunc("/schema", GetSchemaHandler(server))
-	mux.HandleFunc("/tools", GetToolsHandler(server))
+	mux.Handle("/schema", server.requireAuthentication(GetSchemaHandler(server)))
+	mux.Handle("/tools", server.requireAuthentication(GetToolsHandler(server)))
+	if server.options.ProtectedResource != nil {
+		mux.HandleFunc(ProtectedResourcePath, GetProtectedResourceMetadataHandler(server))
+	}
 
 	return mux
 }
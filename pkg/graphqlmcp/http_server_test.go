@@ -0,0 +1,127 @@
+package graphqlmcp
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSSEHandler(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	apiKeys, err := NewAPIKeyAuthenticator([]APIKey{{Name: "legacy-client", SHA256: HashAPIKey("secret")}})
+	require.NoError(t, err)
+
+	// The principal and passthru headers of the session reach the executor through the context,
+	// while API keys are never passed on for token exchange
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.MatchedBy(func(ctx context.Context) bool {
+		principal := PrincipalFromContext(ctx)
+		return principal != nil && principal.Subject == "legacy-client" &&
+			GetPassthruHeaderValues(ctx).Get("X-Request-Tag") == "sse" &&
+			SubjectTokenFromContext(ctx) == ""
+	}), mock.AnythingOfType("string"), mock.Anything).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"facilities": []interface{}{}}}, nil).Once()
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor,
+		WithAuthenticators(apiKeys),
+		WithPassthruHeaders([]string{"X-Request-Tag"}),
+	)
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(GetCompleteMux(server))
+	t.Cleanup(httpServer.Close)
+
+	resp, err := http.Get(httpServer.URL + "/sse")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Posting to a session needs credentials as well
+	resp, err = http.Post(httpServer.URL+"/sse?sessionid=unknown", "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(context.Background(), &mcp.SSEClientTransport{
+		Endpoint: httpServer.URL + "/sse",
+		HTTPClient: &http.Client{Transport: &headerTransport{header: http.Header{
+			"Authorization": {"Bearer secret"},
+			"X-Request-Tag": {"sse"},
+		}}},
+	}, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { session.Close() })
+
+	tools, err := session.ListTools(context.Background(), nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tools.Tools)
+
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "query_facilities", Arguments: map[string]interface{}{}})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	mockExecutor.AssertExpectations(t)
+}
+
+func TestNewSSEHandler_SessionPrincipal(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	apiKeys, err := NewAPIKeyAuthenticator([]APIKey{
+		{Name: "legacy-client", SHA256: HashAPIKey("secret")},
+		{Name: "other-client", SHA256: HashAPIKey("other-secret")},
+	})
+	require.NoError(t, err)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor, WithAuthenticators(apiKeys))
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(GetCompleteMux(server))
+	t.Cleanup(httpServer.Close)
+
+	// Open a session as legacy-client and read the endpoint it announces
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, httpServer.URL+"/sse", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var endpoint string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			endpoint = data
+			break
+		}
+	}
+	require.Contains(t, endpoint, "sessionid=")
+
+	post := func(token string) int {
+		body := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+		req, err := http.NewRequest(http.MethodPost, httpServer.URL+endpoint, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Another principal cannot post to the session, while its owner can
+	assert.Equal(t, http.StatusForbidden, post("other-secret"))
+	assert.Equal(t, http.StatusAccepted, post("secret"))
+}
@@ -0,0 +1,520 @@
+package graphqlmcp
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // register SHA-256 for crypto.Hash
+	_ "crypto/sha512" // register SHA-384 and SHA-512 for crypto.Hash
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+)
+
+// JWTAuthenticatorOptions configures a JWTAuthenticator
+type JWTAuthenticatorOptions struct {
+	// Issuer must match the "iss" claim of every token
+	Issuer string
+	// Audience must be one of the "aud" claims of every token, typically the URL of the MCP endpoint
+	Audience string
+	// JWKSFile is a local JSON Web Key Set with the signing keys of the issuer
+	JWKSFile string
+	// JWKSURL is fetched for the signing keys when JWKSFile is not set
+	JWKSURL string
+	// HTTPClient fetches JWKSURL, http.DefaultClient if nil
+	HTTPClient *http.Client
+	// JWKSRefreshInterval is how long fetched keys are cached, 1 hour if zero
+	JWKSRefreshInterval time.Duration
+	// RolesClaim names the claim holding the caller's roles, "roles" if empty
+	RolesClaim string
+	// ClockSkew is the leeway for the "exp" and "nbf" claims, 1 minute if zero
+	ClockSkew time.Duration
+}
+
+// jwksMinRefetchInterval limits how often unknown key IDs and failed fetches trigger a JWKS fetch
+const jwksMinRefetchInterval = time.Minute
+
+// jwksFetchTimeout bounds a JWKS fetch, which does not end with the request that started it
+const jwksFetchTimeout = 10 * time.Second
+
+// JWTAuthenticator accepts JWT bearer tokens signed by a key of the issuer's JWKS
+type JWTAuthenticator struct {
+	opts JWTAuthenticatorOptions
+	now  func() time.Time
+
+	mu        sync.Mutex
+	keys      []jsonWebKey
+	fetchedAt time.Time
+	// attemptedAt and fetchErr record the last fetch, so failures back off instead of fetching
+	// again for every request
+	attemptedAt time.Time
+	fetchErr    error
+	fetching    chan struct{}
+}
+
+// jsonWebKey is a public signing key of a JWKS
+type jsonWebKey struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// NewJWTAuthenticator creates a JWT authenticator, loading the JWKS file if one is configured
+func NewJWTAuthenticator(opts JWTAuthenticatorOptions) (*JWTAuthenticator, error) {
+	if opts.Issuer == "" {
+		return nil, errors.New("JWT authenticator requires an issuer")
+	}
+	if opts.Audience == "" {
+		return nil, errors.New("JWT authenticator requires an audience")
+	}
+	if opts.JWKSFile == "" && opts.JWKSURL == "" {
+		return nil, errors.New("JWT authenticator requires a JWKS file or URL")
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.JWKSRefreshInterval == 0 {
+		opts.JWKSRefreshInterval = time.Hour
+	}
+	if opts.RolesClaim == "" {
+		opts.RolesClaim = "roles"
+	}
+	if opts.ClockSkew == 0 {
+		opts.ClockSkew = time.Minute
+	}
+
+	authenticator := &JWTAuthenticator{opts: opts, now: time.Now}
+	if opts.JWKSFile != "" {
+		data, err := os.ReadFile(opts.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+		}
+		keys, err := parseJWKS(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS file %s: %w", opts.JWKSFile, err)
+		}
+		authenticator.keys = keys
+	}
+	return authenticator, nil
+}
+
+// Authenticate verifies the signature and the registered claims of a JWT
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, token string, r *http.Request) (*Principal, *auth.TokenInfo, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, invalidToken("token is not a JWT")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, nil, invalidToken("malformed JWT header: %v", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, invalidToken("malformed JWT signature: %v", err)
+	}
+
+	keys, err := a.signingKeys(ctx, header.Kid)
+	if err != nil {
+		return nil, nil, err
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range keys {
+		if (header.Kid == "" || key.kid == header.Kid) && (key.alg == "" || key.alg == header.Alg) &&
+			verifyJWTSignature(header.Alg, key.key, signed, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, nil, invalidToken("JWT signature could not be verified (alg %q, kid %q)", header.Alg, header.Kid)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, nil, invalidToken("malformed JWT claims: %v", err)
+	}
+	principal, tokenInfo, err := a.checkClaims(claims)
+	if err != nil {
+		return nil, nil, err
+	}
+	tokenInfo.Extra = map[string]any{subjectTokenInfoKey: token}
+	return principal, tokenInfo, nil
+}
+
+// checkClaims validates the registered claims and builds the principal
+func (a *JWTAuthenticator) checkClaims(claims map[string]interface{}) (*Principal, *auth.TokenInfo, error) {
+	now := a.now()
+
+	expiration, ok := numericDateClaim(claims["exp"])
+	if !ok {
+		return nil, nil, invalidToken("JWT has no exp claim")
+	}
+	if now.After(expiration.Add(a.opts.ClockSkew)) {
+		return nil, nil, invalidToken("JWT expired at %s", expiration.Format(time.RFC3339))
+	}
+	if notBefore, ok := numericDateClaim(claims["nbf"]); ok && now.Add(a.opts.ClockSkew).Before(notBefore) {
+		return nil, nil, invalidToken("JWT is not valid before %s", notBefore.Format(time.RFC3339))
+	}
+	if issuer, _ := claims["iss"].(string); issuer != a.opts.Issuer {
+		return nil, nil, invalidToken("JWT issuer %q is not trusted", issuer)
+	}
+	if !containsString(audienceClaim(claims["aud"]), a.opts.Audience) {
+		return nil, nil, invalidToken("JWT audience does not include %q", a.opts.Audience)
+	}
+
+	subject, _ := claims["sub"].(string)
+	principal := &Principal{
+		Subject: subject,
+		Roles:   stringListClaim(claims[a.opts.RolesClaim]),
+		Claims:  claims,
+	}
+
+	scopes := stringListClaim(claims["scope"])
+	if len(scopes) == 0 {
+		scopes = stringListClaim(claims["scp"])
+	}
+	// Expiration may fall within the clock skew, which RequireBearerToken does not allow for
+	if expiration.Before(now) {
+		expiration = now
+	}
+	return principal, &auth.TokenInfo{Scopes: scopes, Expiration: expiration.Add(a.opts.ClockSkew)}, nil
+}
+
+// signingKeys returns the cached keys, fetching the JWKS URL when the cache is stale or the key
+// ID is unknown
+// The fetch runs outside the lock, so tokens signed with cached keys are verified while it runs,
+// and concurrent requests share a single fetch that does not end when one of them is cancelled
+func (a *JWTAuthenticator) signingKeys(ctx context.Context, kid string) ([]jsonWebKey, error) {
+	if a.opts.JWKSFile != "" {
+		return a.keys, nil
+	}
+
+	a.mu.Lock()
+	if !a.needsFetch(kid) {
+		defer a.mu.Unlock()
+		return a.loadedKeys()
+	}
+	if a.fetching == nil {
+		a.fetching = make(chan struct{})
+		go a.refreshKeys(context.WithoutCancel(ctx), a.fetching)
+	}
+	done := a.fetching
+	a.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.loadedKeys()
+}
+
+// needsFetch reports whether the JWKS URL has to be fetched for a token with the key ID
+// The caller must hold a.mu
+func (a *JWTAuthenticator) needsFetch(kid string) bool {
+	now := a.now()
+	if a.fetchErr != nil && now.Sub(a.attemptedAt) < jwksMinRefetchInterval {
+		return false
+	}
+	age := now.Sub(a.fetchedAt)
+	stale := a.fetchedAt.IsZero() || age > a.opts.JWKSRefreshInterval
+	unknown := kid != "" && !hasKeyID(a.keys, kid) && age > jwksMinRefetchInterval
+	return stale || unknown
+}
+
+// loadedKeys returns the cached keys, or the error of the last fetch if there are none
+// The caller must hold a.mu
+func (a *JWTAuthenticator) loadedKeys() ([]jsonWebKey, error) {
+	if len(a.keys) == 0 {
+		return nil, a.fetchErr
+	}
+	// Keep serving with the previous keys while the issuer is unavailable
+	return a.keys, nil
+}
+
+// refreshKeys fetches the JWKS URL with its own timeout and closes done when the keys are updated
+func (a *JWTAuthenticator) refreshKeys(ctx context.Context, done chan struct{}) {
+	ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+	defer cancel()
+	keys, err := a.fetchJWKS(ctx)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.attemptedAt = a.now()
+	a.fetchErr = err
+	if err == nil {
+		a.keys = keys
+		a.fetchedAt = a.attemptedAt
+	}
+	a.fetching = nil
+	close(done)
+}
+
+// fetchJWKS downloads the JWKS from the configured URL
+func (a *JWTAuthenticator) fetchJWKS(ctx context.Context) ([]jsonWebKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.opts.JWKSURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := a.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWKS from %s: %w", a.opts.JWKSURL, err)
+	}
+	return keys, nil
+}
+
+// parseJWKS parses the signing keys of a JSON Web Key Set, skipping encryption keys
+func parseJWKS(data []byte) ([]jsonWebKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+			Use string `json:"use"`
+			Crv string `json:"crv"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	var keys []jsonWebKey
+	for i, jwk := range set.Keys {
+		if jwk.Use == "enc" {
+			continue
+		}
+
+		var key crypto.PublicKey
+		var err error
+		switch jwk.Kty {
+		case "RSA":
+			key, err = rsaPublicKey(jwk.N, jwk.E)
+		case "EC":
+			key, err = ecdsaPublicKey(jwk.Crv, jwk.X, jwk.Y)
+		case "OKP":
+			key, err = ed25519PublicKey(jwk.Crv, jwk.X)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %d (kid %q): %w", i, jwk.Kid, err)
+		}
+		keys = append(keys, jsonWebKey{kid: jwk.Kid, alg: jwk.Alg, key: key})
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys found")
+	}
+	return keys, nil
+}
+
+// rsaPublicKey decodes the modulus and exponent of an RSA JWK
+func rsaPublicKey(n, e string) (*rsa.PublicKey, error) {
+	modulus, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+	}
+	exponent, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil || len(exponent) == 0 || len(exponent) > 4 {
+		return nil, errors.New("invalid RSA exponent")
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: int(new(big.Int).SetBytes(exponent).Int64()),
+	}, nil
+}
+
+// ecdsaPublicKey decodes the coordinates of an EC JWK
+func ecdsaPublicKey(crv, x, y string) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", crv)
+	}
+
+	xBytes, errX := base64.RawURLEncoding.DecodeString(x)
+	yBytes, errY := base64.RawURLEncoding.DecodeString(y)
+	if errX != nil || errY != nil {
+		return nil, errors.New("invalid EC coordinates")
+	}
+	key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(xBytes), Y: new(big.Int).SetBytes(yBytes)}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, errors.New("EC point is not on the curve")
+	}
+	return key, nil
+}
+
+// ed25519PublicKey decodes an Ed25519 OKP JWK
+func ed25519PublicKey(crv, x string) (ed25519.PublicKey, error) {
+	if crv != "Ed25519" {
+		return nil, fmt.Errorf("unsupported curve %q", crv)
+	}
+	key, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid Ed25519 key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// jwtAlgorithms lists the accepted asymmetric signing algorithms; symmetric algorithms and
+// "none" are never accepted
+var jwtAlgorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"PS256": crypto.SHA256, "PS384": crypto.SHA384, "PS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+	"EdDSA": 0,
+}
+
+// jwtCurves names the curve each ECDSA algorithm signs with
+var jwtCurves = map[string]string{"ES256": "P-256", "ES384": "P-384", "ES512": "P-521"}
+
+// verifyJWTSignature checks a JWS signature with one of the accepted algorithms
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed, signature []byte) bool {
+	hash, ok := jwtAlgorithms[alg]
+	if !ok {
+		return false
+	}
+	if alg == "EdDSA" {
+		pub, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(pub, signed, signature)
+	}
+
+	hasher := hash.New()
+	hasher.Write(signed)
+	digest := hasher.Sum(nil)
+
+	switch alg[:2] {
+	case "RS":
+		pub, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(pub, hash, digest, signature) == nil
+	case "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPSS(pub, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+	default:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve.Params().Name != jwtCurves[alg] {
+			return false
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(pub, digest, r, s)
+	}
+}
+
+// decodeJWTSegment decodes a base64url-encoded JSON segment of a JWT
+func decodeJWTSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// numericDateClaim converts a NumericDate claim to a time
+func numericDateClaim(value interface{}) (time.Time, bool) {
+	seconds, ok := value.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// audienceClaim reads the "aud" claim, which is either a single audience or a list of them
+func audienceClaim(value interface{}) []string {
+	if v, ok := value.(string); ok {
+		return []string{v}
+	}
+	return stringListClaim(value)
+}
+
+// stringListClaim reads a claim that is either a list of strings or a space-separated string
+func stringListClaim(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// containsString reports whether a list contains a value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// hasKeyID reports whether a key set contains a key ID
+func hasKeyID(keys []jsonWebKey, kid string) bool {
+	for _, key := range keys {
+		if key.kid == kid {
+			return true
+		}
+	}
+	return false
+}
+
+// invalidToken creates a token verification error that results in a 401 response
+func invalidToken(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", auth.ErrInvalidToken, fmt.Sprintf(format, args...))
+}
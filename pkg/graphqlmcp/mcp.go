@@ -3,6 +3,7 @@ package graphqlmcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -21,6 +22,20 @@ type MCPGraphQLServer struct {
 	logger    logr.Logger
 	options   *MCPGraphQLServerOptions
 	testMode  bool
+
+	// confirmations holds the confirmation tokens issued for mutation previews
+	confirmations *confirmationStore
+	// rateLimiters and inFlight enforce the configured rate and concurrency limits
+	rateLimiters []*rateLimiter
+	inFlight     *concurrencyLimiter
+	// argumentOverrides holds the validated argument overrides by tool name
+	argumentOverrides map[string][]ArgumentOverride
+	// redaction holds the validated redaction rules
+	redaction *redactionRules
+	// passthru holds the validated passthru rules
+	passthru *passthruRules
+	// mountPath is the path of the MCP endpoint when the server is mounted as a profile
+	mountPath string
 }
 
 // NewMCPGraphQLServer creates a new MCP GraphQL server
@@ -32,60 +47,125 @@ func NewMCPGraphQLServer(endpoint string, opts ...MCPGraphQLServerOption) (*MCPG
 
 // NewMCPGraphQLServerWithExecutor creates a new MCP GraphQL server with a custom executor
 func NewMCPGraphQLServerWithExecutor(executor GraphQLExecutor, opts ...MCPGraphQLServerOption) (*MCPGraphQLServer, error) {
+	server, err := newServerWithoutSchema(executor, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Introspect the schema
+	ctx := context.Background()
+	introspected, err := executor.IntrospectSchema(ctx)
+	if err != nil {
+		server.logger.Info("Failed to introspect GraphQL schema, continuing with empty schema", "error", err)
+	}
+
+	if err := server.setSchema(introspected); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// newServerWithoutSchema applies and validates the options of a server, which has no schema or
+// tools until setSchema is called
+func newServerWithoutSchema(executor GraphQLExecutor, opts []MCPGraphQLServerOption) (*MCPGraphQLServer, error) {
 	// Apply options
 	options := NewMCPGraphQLServerOptions()
 	for _, opt := range opts {
 		opt(options)
 	}
 
+	if len(options.AuditHashKey) == 0 {
+		key, err := newAuditHashKey()
+		if err != nil {
+			return nil, err
+		}
+		options.AuditHashKey = key
+	}
+
 	// Set logger - use provided logger or default
 	logger := options.Logger
 	if logger.GetSink() == nil {
 		logger = logr.Discard()
 	}
 
-	// Introspect the schema
-	ctx := context.Background()
-	schema, err := executor.IntrospectSchema(ctx)
+	rateLimiters, err := newRateLimiters(options.RateLimits)
+	if err != nil {
+		return nil, err
+	}
+	passthru, err := compilePassthruRules(options)
+	if err != nil {
+		return nil, err
+	}
+	if err := options.JSONSchema.ValidateScalars(); err != nil {
+		return nil, err
+	}
+	if options.Confirmation != nil {
+		if err := options.Confirmation.compile(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &MCPGraphQLServer{
+		executor:      executor,
+		logger:        logger,
+		options:       options,
+		confirmations: newConfirmationStore(),
+		rateLimiters:  rateLimiters,
+		inFlight:      newConcurrencyLimiter(options.ConcurrencyLimit),
+		passthru:      passthru,
+	}, nil
+}
+
+// setSchema prepares an introspected schema for the server and recreates the MCP server with
+// its tools. A nil schema leaves the server without tools
+func (s *MCPGraphQLServer) setSchema(introspected *schema.Schema) error {
+	prepared, redaction, err := prepareSchema(introspected, s.options)
 	if err != nil {
-		logger.Info("Failed to introspect GraphQL schema, continuing with empty schema", "error", err)
+		return err
 	}
 
-	// Apply max depth configuration to schema if it was introspected successfully
-	if schema != nil {
-		schema.MaxDepth = options.MaxDepth
+	s.Schema = prepared
+	s.redaction = redaction
+	s.mcpServer = s.newMCPServer()
+
+	// Add tools for queries and mutations
+	if s.Schema == nil {
+		s.logger.Info("No schema introspected, skipping tool creation")
+		return nil
 	}
+	if err := s.addGraphQLTools(); err != nil {
+		return fmt.Errorf("failed to add GraphQL tools: %w", err)
+	}
+	return nil
+}
 
-	// Create MCP server
+// newMCPServer creates the underlying MCP server with the configured middleware
+func (s *MCPGraphQLServer) newMCPServer() *mcp.Server {
 	mcpServer := mcp.NewServer(&mcp.Implementation{
 		Name:    "graphql-mcp-server",
 		Version: "1.0.0",
 	}, nil)
 
-	server := &MCPGraphQLServer{
-		executor:  executor,
-		Schema:    schema,
-		mcpServer: mcpServer,
-		logger:    logger,
-		options:   options,
-	}
-
-	// Add tools for queries and mutations
-	if server.Schema != nil {
-		if err := server.addGraphQLTools(); err != nil {
-			return nil, fmt.Errorf("failed to add GraphQL tools: %w", err)
-		}
-	} else {
-		logger.Info("No schema introspected, skipping tool creation")
+	if s.options.AuthorizationPolicy != nil {
+		mcpServer.AddReceivingMiddleware(s.authorizationMiddleware)
 	}
-
-	return server, nil
+	return mcpServer
 }
 
 // addGraphQLTools adds MCP tools for all GraphQL queries and mutations
 func (s *MCPGraphQLServer) addGraphQLTools() error {
+	argumentOverrides, err := s.resolveArgumentOverrides()
+	if err != nil {
+		return err
+	}
+	s.argumentOverrides = argumentOverrides
+
 	// Add query tools
 	queries := s.Schema.GetQueries()
+	if !s.options.isOperationTypeAllowed("query") {
+		s.logger.V(1).Info("Skipping all queries due to the operation type policy", "query_count", len(queries))
+		queries = nil
+	}
 	for _, query := range queries {
 		// Check if this query is allowed based on masking options
 		if !s.options.isOperationAllowed(query.Name) {
@@ -100,6 +180,10 @@ func (s *MCPGraphQLServer) addGraphQLTools() error {
 
 	// Add mutation tools
 	mutations := s.Schema.GetMutations()
+	if !s.options.isOperationTypeAllowed("mutation") {
+		s.logger.V(1).Info("Skipping all mutations due to the operation type policy", "mutation_count", len(mutations))
+		mutations = nil
+	}
 	for _, mutation := range mutations {
 		// Check if this mutation is allowed based on masking options
 		if !s.options.isOperationAllowed(mutation.Name) {
@@ -117,14 +201,14 @@ func (s *MCPGraphQLServer) addGraphQLTools() error {
 
 // addQueryTool adds an MCP tool for a GraphQL query
 func (s *MCPGraphQLServer) addQueryTool(query *schema.Field) error {
-	toolName := "query_" + query.Name
-	toolDescription := query.Description
+	toolName := toolNameFor("query", query.Name)
+	toolDescription := s.options.toolDescription(toolName, query.Description)
 	if toolDescription == "" {
 		toolDescription = fmt.Sprintf("Execute GraphQL query: %s", query.Name)
 	}
 
 	// Create input schema for the tool
-	inputSchema := s.createInputSchema(query)
+	inputSchema := s.toolInputSchema(query, "query")
 
 	tool := &mcp.Tool{
 		Name:        toolName,
@@ -132,38 +216,35 @@ func (s *MCPGraphQLServer) addQueryTool(query *schema.Field) error {
 		InputSchema: inputSchema,
 	}
 
-	// Create the handler function
-	handler := func(ctx context.Context, req *mcp.CallToolRequest, input map[string]interface{}) (*mcp.CallToolResult, any, error) {
-		// Add passthru headers to context if available
-		if passthruHeaders := GetPassthruHeaders(ctx); passthruHeaders != nil {
-			ctx = AddPassthruHeadersToContext(ctx, passthruHeaders)
-		}
-		result, err := s.executeGraphQLOperation(ctx, query, input, "query")
-		return result, nil, err
-	}
-
-	mcp.AddTool(s.mcpServer, tool, handler)
+	s.mcpServer.AddTool(tool, s.withAudit(s.newToolHandler(query, "query")))
 	return nil
 }
 
 // addMutationTool adds an MCP tool for a GraphQL mutation
 func (s *MCPGraphQLServer) addMutationTool(mutation *schema.Field) error {
-	toolName := "mutation_" + mutation.Name
-	toolDescription := mutation.Description
+	toolName := toolNameFor("mutation", mutation.Name)
+	toolDescription := s.options.toolDescription(toolName, mutation.Description)
 	if toolDescription == "" {
 		toolDescription = fmt.Sprintf("Execute GraphQL mutation: %s", mutation.Name)
 	}
 
 	// Create input schema for the tool
-	inputSchema := s.createInputSchema(mutation)
+	inputSchema := s.toolInputSchema(mutation, "mutation")
 
 	// Enhance description with input information
-	if len(mutation.Args) > 0 {
-		argNames := make([]string, len(mutation.Args))
-		for i, arg := range mutation.Args {
-			argNames[i] = arg.Name
+	if properties, _ := inputSchema["properties"].(map[string]interface{}); len(mutation.Args) > 0 {
+		argNames := make([]string, 0, len(mutation.Args))
+		for _, arg := range mutation.Args {
+			if _, ok := properties[arg.Name]; ok {
+				argNames = append(argNames, arg.Name)
+			}
+		}
+		if len(argNames) > 0 {
+			toolDescription += fmt.Sprintf(" (Inputs: %s)", strings.Join(argNames, ", "))
 		}
-		toolDescription += fmt.Sprintf(" (Inputs: %s)", strings.Join(argNames, ", "))
+	}
+	if s.options.requiresConfirmation("mutation", mutation.Name) {
+		toolDescription += " Requires confirmation: the first call returns a preview and a confirmation token."
 	}
 
 	tool := &mcp.Tool{
@@ -172,23 +253,168 @@ func (s *MCPGraphQLServer) addMutationTool(mutation *schema.Field) error {
 		InputSchema: inputSchema,
 	}
 
-	// Create the handler function
-	handler := func(ctx context.Context, req *mcp.CallToolRequest, input map[string]interface{}) (*mcp.CallToolResult, any, error) {
-		// Add passthru headers to context if available
-		if passthruHeaders := GetPassthruHeaders(ctx); passthruHeaders != nil {
-			ctx = AddPassthruHeadersToContext(ctx, passthruHeaders)
+	s.mcpServer.AddTool(tool, s.withAudit(s.newToolHandler(mutation, "mutation")))
+	return nil
+}
+
+// newToolHandler creates the MCP tool handler for a GraphQL root field
+// Arguments are validated and coerced against the GraphQL input types before the
+// operation is executed, so the model gets precise, path-addressed errors
+func (s *MCPGraphQLServer) newToolHandler(field *schema.Field, operationType string) mcp.ToolHandler {
+	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		input := make(map[string]interface{})
+		if len(req.Params.Arguments) > 0 && string(req.Params.Arguments) != "null" {
+			if err := json.Unmarshal(req.Params.Arguments, &input); err != nil {
+				auditFailure(ctx, AuditOutcomeError, AuditErrorInvalidArguments)
+				return toolErrorResult(fmt.Sprintf("Invalid arguments for %s: arguments must be a JSON object: %v", req.Params.Name, err)), nil
+			}
+		}
+
+		// Strict schemas make every property required, so the model sends null for values it omits
+		if s.options.JSONSchema.Dialect == schema.JSONSchemaStrict {
+			dropNullFields(input)
+		}
+
+		requiresConfirmation := s.options.requiresConfirmation(operationType, field.Name)
+		var confirmationToken string
+		if requiresConfirmation {
+			confirmationToken, _ = input[ConfirmationTokenArgument].(string)
+			delete(input, ConfirmationTokenArgument)
+		}
+
+		ctx = s.requestContext(ctx, req)
+		if err := s.applyArgumentOverrides(ctx, req.Params.Name, input); err != nil {
+			s.auditArguments(ctx, input)
+			auditFailure(ctx, AuditOutcomeError, AuditErrorArgumentOverride)
+			s.logger.Info("Tool call rejected due to an unresolved argument override",
+				"tool_name", req.Params.Name,
+				"error", err.Error(),
+			)
+			return toolErrorResult(fmt.Sprintf("Cannot call %s: %v", req.Params.Name, err)), nil
+		}
+
+		coerced, err := s.Schema.CoerceArguments(field, input)
+		if err != nil {
+			s.logger.Info("Tool call rejected due to invalid arguments",
+				"tool_name", req.Params.Name,
+				"errors", err.Error(),
+			)
+			s.auditArguments(ctx, input)
+			auditFailure(ctx, AuditOutcomeError, AuditErrorInvalidArguments)
+			return invalidArgumentsResult(req.Params.Name, err), nil
+		}
+		// The audit record holds the arguments as they are sent upstream
+		s.auditArguments(ctx, coerced)
+
+		if err := s.checkRateLimits(ctx, req); err != nil {
+			auditFailure(ctx, AuditOutcomeDenied, AuditErrorRateLimited)
+			return rateLimitedResult(err), nil
+		}
+		if requiresConfirmation {
+			if preview := s.confirmMutation(ctx, req, field, coerced, confirmationToken); preview != nil {
+				if preview.IsError {
+					auditFailure(ctx, AuditOutcomeDenied, AuditErrorConfirmationRejected)
+				} else {
+					auditFailure(ctx, AuditOutcomeDenied, AuditErrorConfirmationRequired)
+				}
+				return preview, nil
+			}
+		}
+		result, err := s.executeGraphQLOperation(ctx, field, coerced, operationType)
+		if err != nil {
+			return toolErrorResult(err.Error()), nil
+		}
+		return result, nil
+	}
+}
+
+// requestContext adds the passthru headers, verified JWT and principal of the current request to
+// the context. Over streamable HTTP the context belongs to the session, so the values sent with
+// each request take precedence over those the session was created with
+func (s *MCPGraphQLServer) requestContext(ctx context.Context, req *mcp.CallToolRequest) context.Context {
+	if req.Extra != nil && req.Extra.Header != nil {
+		if passthruHeaders := s.passthruHeaderValuesFrom(req.Extra.Header); passthruHeaders != nil {
+			ctx = AddPassthruHeaderValuesToContext(ctx, passthruHeaders)
+		}
+	}
+	if req.Extra != nil {
+		if token := subjectTokenFromTokenInfo(req.Extra.TokenInfo); token != "" {
+			ctx = ContextWithSubjectToken(ctx, token)
 		}
-		result, err := s.executeGraphQLOperation(ctx, mutation, input, "mutation")
-		return result, nil, err
 	}
+	if principal := requestPrincipal(ctx, req.Extra); principal != nil {
+		ctx = ContextWithPrincipal(ctx, principal)
+	}
+	return ctx
+}
 
-	mcp.AddTool(s.mcpServer, tool, handler)
-	return nil
+// dropNullFields removes null object fields recursively, keeping null list elements
+func dropNullFields(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, fieldValue := range v {
+			if fieldValue == nil {
+				delete(v, key)
+				continue
+			}
+			dropNullFields(fieldValue)
+		}
+	case []interface{}:
+		for _, item := range v {
+			dropNullFields(item)
+		}
+	}
+}
+
+// toolErrorResult creates a tool result that reports an error to the model
+func toolErrorResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
+
+// invalidArgumentsResult reports argument validation errors, one per line with its path
+func invalidArgumentsResult(toolName string, err error) *mcp.CallToolResult {
+	var inputErrs schema.InputErrors
+	if !errors.As(err, &inputErrs) {
+		return toolErrorResult(fmt.Sprintf("Invalid arguments for %s: %v", toolName, err))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Invalid arguments for %s:", toolName))
+	for _, inputErr := range inputErrs {
+		sb.WriteString("\n- ")
+		sb.WriteString(inputErr.Error())
+	}
+	sb.WriteString("\nCorrect these values according to the tool's input schema and call the tool again.")
+
+	result := toolErrorResult(sb.String())
+	result.Meta = map[string]interface{}{"validationErrors": inputErrs}
+	return result
+}
+
+// toolNameFor returns the MCP tool name for a GraphQL root field
+func toolNameFor(operationType, fieldName string) string {
+	return operationType + "_" + fieldName
 }
 
 // createInputSchema creates a JSON schema for the tool input
 func (s *MCPGraphQLServer) createInputSchema(field *schema.Field) map[string]interface{} {
-	return s.Schema.CreateInputSchema(field)
+	return s.Schema.CreateInputSchemaWithOptions(field, s.options.JSONSchema)
+}
+
+// toolInputSchema creates the input schema of the tool for a root field, including the
+// confirmation token of mutations that require confirmation
+func (s *MCPGraphQLServer) toolInputSchema(field *schema.Field, operationType string) map[string]interface{} {
+	inputSchema := s.createInputSchema(field)
+	s.hideOverriddenArguments(toolNameFor(operationType, field.Name), inputSchema)
+	if s.options.requiresConfirmation(operationType, field.Name) {
+		addConfirmationArgument(inputSchema, s.options.JSONSchema.Dialect == schema.JSONSchemaStrict)
+	}
+	return inputSchema
 }
 
 // executeGraphQLOperation executes a GraphQL query or mutation
@@ -202,7 +428,6 @@ func (s *MCPGraphQLServer) executeGraphQLOperation(ctx context.Context, field *s
 		"operation_type", operationType,
 		"field_name", field.Name,
 		"input_args", len(field.Args),
-		"input_values", input,
 	)
 
 	// Generate the GraphQL query/mutation string
@@ -215,6 +440,7 @@ func (s *MCPGraphQLServer) executeGraphQLOperation(ctx context.Context, field *s
 				"request_id", requestID,
 				"field_name", field.Name,
 			)
+			auditFailure(ctx, AuditOutcomeError, AuditErrorInternal)
 			return nil, fmt.Errorf("failed to generate query string: %w", err)
 		}
 	} else {
@@ -224,9 +450,28 @@ func (s *MCPGraphQLServer) executeGraphQLOperation(ctx context.Context, field *s
 				"request_id", requestID,
 				"field_name", field.Name,
 			)
+			auditFailure(ctx, AuditOutcomeError, AuditErrorInternal)
 			return nil, fmt.Errorf("failed to generate mutation string: %w", err)
 		}
 	}
+	auditOperation(ctx, queryString)
+
+	// Enforce the operation type policy on the exact document that is sent upstream
+	if err := s.options.checkOperationDocument(queryString); err != nil {
+		s.logger.Info("GraphQL operation rejected by the operation type policy",
+			"request_id", requestID,
+			"operation_type", operationType,
+			"field_name", field.Name,
+			"reason", err.Error(),
+		)
+		auditFailure(ctx, AuditOutcomeDenied, AuditErrorOperationTypeDenied)
+		return nil, fmt.Errorf("cannot execute %s: %w", field.Name, err)
+	}
+
+	if err := s.checkOperationCost(requestID, field, operationType, queryString, input); err != nil {
+		auditFailure(ctx, AuditOutcomeDenied, AuditErrorCostLimit)
+		return nil, fmt.Errorf("cannot execute %s: %w", field.Name, err)
+	}
 
 	// Log the generated query/mutation
 	s.logger.V(1).Info("Generated GraphQL operation",
@@ -234,14 +479,38 @@ func (s *MCPGraphQLServer) executeGraphQLOperation(ctx context.Context, field *s
 		"operation_type", operationType,
 		"field_name", field.Name,
 		"query", queryString,
+		"arguments", s.recordedArguments(input),
 	)
 
+	// Wait for a free slot when the number of requests in flight is limited
+	if s.inFlight != nil {
+		release, err := s.inFlight.acquire(ctx)
+		if err != nil {
+			var limitErr *RateLimitError
+			if errors.As(err, &limitErr) {
+				s.logger.Info("GraphQL operation rejected by concurrency limit",
+					"request_id", requestID,
+					"operation_type", operationType,
+					"field_name", field.Name,
+				)
+				auditFailure(ctx, AuditOutcomeDenied, AuditErrorRateLimited)
+				return rateLimitedResult(limitErr), nil
+			}
+			auditFailure(ctx, AuditOutcomeError, AuditErrorInternal)
+			return nil, fmt.Errorf("cancelled while waiting to execute %s: %w", field.Name, err)
+		}
+		defer release()
+	}
+
 	// Execute the GraphQL operation
 	startTime := time.Now()
 	resp, err := s.executor.ExecuteQuery(ctx, queryString, input)
 	duration := time.Since(startTime)
+	auditUpstreamStatus(ctx, resp, err)
+	responseHeaders := s.responseHeaderMeta(resp, err)
 
 	if err != nil {
+		auditFailure(ctx, AuditOutcomeError, AuditErrorUpstream)
 		s.logger.Error(err, "GraphQL execution failed",
 			"request_id", requestID,
 			"operation_type", operationType,
@@ -249,6 +518,7 @@ func (s *MCPGraphQLServer) executeGraphQLOperation(ctx context.Context, field *s
 			"duration_ms", duration.Milliseconds(),
 		)
 		return &mcp.CallToolResult{
+			Meta:    withResponseHeaders(nil, responseHeaders),
 			IsError: true,
 			Content: []mcp.Content{
 				&mcp.TextContent{
@@ -258,11 +528,16 @@ func (s *MCPGraphQLServer) executeGraphQLOperation(ctx context.Context, field *s
 		}, nil
 	}
 
-	// Check for GraphQL errors
+	// Errors can repeat sensitive values, so they are redacted before they are logged or shown
+	redacted := s.redactErrors(resp)
+
+	// GraphQL errors without any data fail the tool call; with partial data they are
+	// reported alongside the data that did resolve
+	partial := len(resp.Errors) > 0 && hasPartialData(resp.Data)
 	if len(resp.Errors) > 0 {
 		errorMessages := make([]string, len(resp.Errors))
 		for i, err := range resp.Errors {
-			errorMessages[i] = err.Message
+			errorMessages[i] = err.describe()
 		}
 		s.logger.Info("GraphQL operation returned errors",
 			"request_id", requestID,
@@ -271,21 +546,37 @@ func (s *MCPGraphQLServer) executeGraphQLOperation(ctx context.Context, field *s
 			"duration_ms", duration.Milliseconds(),
 			"error_count", len(resp.Errors),
 			"errors", errorMessages,
+			"partial_data", partial,
 		)
-		return &mcp.CallToolResult{
-			IsError: true,
-			Content: []mcp.Content{
-				&mcp.TextContent{
-					Text: fmt.Sprintf("GraphQL %s errors: %s", operationType, strings.Join(errorMessages, "; ")),
+		if !partial {
+			auditFailure(ctx, AuditOutcomeError, AuditErrorGraphQL)
+			return &mcp.CallToolResult{
+				Meta:    withResponseHeaders(graphQLResultMeta(resp), responseHeaders),
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{
+						Text: formatGraphQLErrors(fmt.Sprintf("GraphQL %s errors:", operationType), resp.Errors),
+					},
 				},
-			},
-		}, nil
+			}, nil
+		}
+	}
+
+	// Redact sensitive values before they are formatted for the model
+	redacted += s.redactResponse(s.rootTypeName(operationType), resp.Data)
+	if redacted > 0 {
+		s.logger.Info("Redacted values in GraphQL response",
+			"request_id", requestID,
+			"field_name", field.Name,
+			"redacted_values", redacted,
+		)
 	}
 
-	// Convert response to JSON string
-	jsonData, err := json.MarshalIndent(resp.Data, "", "  ")
+	// Format the response data within the tool's response budget
+	toolName := toolNameFor(operationType, field.Name)
+	formatted, err := s.formatResponse(toolName, resp.Data)
 	if err != nil {
-		s.logger.Error(err, "Failed to marshal GraphQL response",
+		s.logger.Error(err, "Failed to format GraphQL response",
 			"request_id", requestID,
 			"operation_type", operationType,
 			"field_name", field.Name,
@@ -295,7 +586,7 @@ func (s *MCPGraphQLServer) executeGraphQLOperation(ctx context.Context, field *s
 			IsError: true,
 			Content: []mcp.Content{
 				&mcp.TextContent{
-					Text: fmt.Sprintf("Failed to marshal response: %v", err),
+					Text: fmt.Sprintf("Failed to format response: %v", err),
 				},
 			},
 		}, nil
@@ -307,19 +598,100 @@ func (s *MCPGraphQLServer) executeGraphQLOperation(ctx context.Context, field *s
 		"operation_type", operationType,
 		"field_name", field.Name,
 		"duration_ms", duration.Milliseconds(),
-		"response_size_bytes", len(jsonData),
+		"response_size_bytes", len(formatted.Text),
+		"truncated", formatted.Note != "",
+		"partial_data", partial,
 	)
 
+	content := []mcp.Content{
+		&mcp.TextContent{
+			Text: formatted.Text,
+		},
+	}
+	if formatted.Note != "" {
+		content = append(content, &mcp.TextContent{Text: formatted.Note})
+	}
+	if partial {
+		content = append(content, &mcp.TextContent{
+			Text: formatGraphQLErrors(fmt.Sprintf("GraphQL %s returned partial data; these fields failed:", operationType), resp.Errors),
+		})
+	}
+
+	meta := withResponseHeaders(graphQLResultMeta(resp), responseHeaders)
+	if redacted > 0 {
+		content = append(content, &mcp.TextContent{
+			Text: fmt.Sprintf("%d values were redacted by the server's redaction rules.", redacted),
+		})
+		if meta == nil {
+			meta = make(map[string]interface{})
+		}
+		meta["redactedValues"] = redacted
+	}
+
 	return &mcp.CallToolResult{
+		Meta:    meta,
 		IsError: false,
-		Content: []mcp.Content{
-			&mcp.TextContent{
-				Text: string(jsonData),
-			},
-		},
+		Content: content,
 	}, nil
 }
 
+// rootTypeName returns the name of the root type of an operation type
+func (s *MCPGraphQLServer) rootTypeName(operationType string) string {
+	rootType := s.Schema.QueryType
+	if operationType == "mutation" {
+		rootType = s.Schema.MutationType
+	}
+	if rootType == nil {
+		return ""
+	}
+	return rootType.Name
+}
+
+// checkOperationCost estimates the cost of an operation, logs it and enforces the cost limits
+func (s *MCPGraphQLServer) checkOperationCost(requestID string, field *schema.Field, operationType, queryString string, input map[string]interface{}) error {
+	limits := s.options.CostLimits
+	analysis, err := s.Schema.AnalyzeCost(queryString, input, limits)
+	if err != nil {
+		s.logger.Error(err, "Failed to analyze operation cost",
+			"request_id", requestID,
+			"field_name", field.Name,
+		)
+		if limits.MaxCost > 0 || limits.MaxDepth > 0 || limits.MaxNodes > 0 {
+			return fmt.Errorf("failed to analyze operation cost: %w", err)
+		}
+		return nil
+	}
+
+	s.logger.Info("Operation cost estimated",
+		"request_id", requestID,
+		"operation_type", operationType,
+		"field_name", field.Name,
+		"cost", analysis.Cost,
+		"depth", analysis.Depth,
+		"nodes", analysis.Nodes,
+	)
+
+	if err := limits.Check(analysis); err != nil {
+		s.logger.Info("Operation rejected by cost limits",
+			"request_id", requestID,
+			"operation_type", operationType,
+			"field_name", field.Name,
+			"reason", err.Error(),
+		)
+		return err
+	}
+	return nil
+}
+
+// formatResponse renders response data with the configured formatter and the tool's budget
+func (s *MCPGraphQLServer) formatResponse(toolName string, data interface{}) (*formattedResponse, error) {
+	formatter := s.options.ResponseFormatter
+	if formatter == nil {
+		formatter = NewIndentedJSONFormatter()
+	}
+	return formatWithinBudget(data, formatter, s.options.responseBudgetFor(toolName))
+}
+
 // GetMCPServer returns the underlying MCP server
 func (s *MCPGraphQLServer) GetMCPServer() *mcp.Server {
 	return s.mcpServer
@@ -333,32 +705,41 @@ func (s *MCPGraphQLServer) SetLogger(logger logr.Logger) {
 // RefreshSchema re-introspects the GraphQL schema and updates tools
 func (s *MCPGraphQLServer) RefreshSchema() error {
 	ctx := context.Background()
-	schema, err := s.executor.IntrospectSchema(ctx)
+	introspected, err := s.executor.IntrospectSchema(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to refresh GraphQL schema: %w", err)
 	}
 
-	// Apply max depth configuration to the refreshed schema
-	if schema != nil {
-		schema.MaxDepth = s.options.MaxDepth
+	// Recreate the MCP server with new tools (respecting masking options)
+	if err := s.setSchema(introspected); err != nil {
+		return fmt.Errorf("failed to refresh GraphQL schema: %w", err)
 	}
+	return nil
+}
 
-	s.Schema = schema
-
-	// Recreate the MCP server with new tools
-	mcpServer := mcp.NewServer(&mcp.Implementation{
-		Name:    "graphql-mcp-server",
-		Version: "1.0.0",
-	}, nil)
-
-	s.mcpServer = mcpServer
-
-	// Add tools for queries and mutations (respecting masking options)
-	if err := s.addGraphQLTools(); err != nil {
-		return fmt.Errorf("failed to add GraphQL tools after refresh: %w", err)
+// prepareSchema applies the max depth, redaction rules and visibility filter to an introspected
+// schema. Redaction rules are checked against the full schema, so they may name hidden fields
+func prepareSchema(introspected *schema.Schema, options *MCPGraphQLServerOptions) (*schema.Schema, *redactionRules, error) {
+	redaction, err := compileRedactionRules(options.Redaction, introspected)
+	if err != nil {
+		return nil, nil, err
+	}
+	if introspected == nil {
+		return nil, redaction, nil
 	}
 
-	return nil
+	pruned, err := introspected.Prune(options.Visibility)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid visibility filter: %w", err)
+	}
+	if pruned == introspected {
+		// Servers sharing an introspected schema keep their own depth and exclusions
+		shallow := *introspected
+		pruned = &shallow
+	}
+	pruned.MaxDepth = options.MaxDepth
+	pruned.ExcludedFields = redaction.excludedFields()
+	return pruned, redaction, nil
 }
 
 // GetSchema returns the current GraphQL schema
@@ -371,18 +752,31 @@ func (s *MCPGraphQLServer) GetExecutor() GraphQLExecutor {
 	return s.executor
 }
 
-// ExtractPassthruHeaders extracts the configured passthru headers from the request
+// ExtractPassthruHeaders extracts the configured passthru headers from the request, with the
+// first value of each header under its upstream name
 func (s *MCPGraphQLServer) ExtractPassthruHeaders(r *http.Request) map[string]string {
-	if len(s.options.PassthruHeaders) == 0 {
+	return s.passthruHeadersFrom(r.Header)
+}
+
+// passthruHeadersFrom extracts the configured passthru headers from HTTP headers
+func (s *MCPGraphQLServer) passthruHeadersFrom(header http.Header) map[string]string {
+	values := s.passthruHeaderValuesFrom(header)
+	if values == nil {
 		return nil
 	}
 
-	headers := make(map[string]string)
-	for _, headerName := range s.options.PassthruHeaders {
-		if value := r.Header.Get(headerName); value != "" {
-			headers[headerName] = value
-		}
+	headers := make(map[string]string, len(values))
+	for name := range values {
+		headers[name] = values.Get(name)
 	}
-
 	return headers
 }
+
+// passthruHeaderValuesFrom applies the passthru rules to HTTP headers, returning nil when no
+// rules are configured
+func (s *MCPGraphQLServer) passthruHeaderValuesFrom(header http.Header) http.Header {
+	if s.passthru == nil {
+		return nil
+	}
+	return s.passthru.extract(header)
+}
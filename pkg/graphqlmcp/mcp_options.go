@@ -1,9 +1,12 @@
 package graphqlmcp
 
 import (
+	"fmt"
 	"regexp"
+	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/peterbeamish/go-mcp-graphql/pkg/graphqlmcp/schema"
 )
 
 // MCPGraphQLServerOptions holds configuration options for the MCP GraphQL server
@@ -12,6 +15,56 @@ type MCPGraphQLServerOptions struct {
 	Mask            *MaskConfig
 	PassthruHeaders []string
 	MaxDepth        int // Maximum depth for query generation
+
+	// PassthruRules forward renamed, templated and cookie values to GraphQL requests
+	PassthruRules []PassthruRule
+	// PassthruDeny lists headers that are never passed through; a trailing "*" matches a prefix
+	PassthruDeny []string
+	// ResponseHeaders lists GraphQL response headers copied into the _meta of tool results
+	ResponseHeaders []string
+
+	// ResponseFormatter renders GraphQL response data as tool result text
+	ResponseFormatter ResponseFormatter
+	// ResponseBudget limits the size of every tool result unless overridden per tool
+	ResponseBudget ResponseBudget
+	// ToolResponseBudgets overrides ResponseBudget for individual tools, keyed by tool name
+	ToolResponseBudgets map[string]ResponseBudget
+
+	// JSONSchema controls how tool input schemas are generated
+	JSONSchema schema.JSONSchemaOptions
+	// ToolDescriptions replaces the GraphQL descriptions of tools, keyed by tool name
+	ToolDescriptions map[string]string
+
+	// AuthorizationPolicy decides per session which tools may be listed and called
+	AuthorizationPolicy AuthorizationPolicy
+	// Authenticators verify the bearer tokens of HTTP callers; empty accepts any caller
+	Authenticators []Authenticator
+	// ProtectedResource is served as OAuth protected resource metadata when set
+	ProtectedResource *ProtectedResourceMetadata
+	// TrustForwardedProto takes the scheme of derived resource URLs from X-Forwarded-Proto
+	TrustForwardedProto bool
+	// Confirmation lists the mutations that must be confirmed before they are executed
+	Confirmation *ConfirmationConfig
+	// OperationTypes decides which GraphQL operation types may be listed and executed
+	OperationTypes OperationTypePolicy
+	// CostLimits configures the cost analysis of operations and the limits they must stay within
+	CostLimits schema.CostOptions
+	// RateLimits are token-bucket limits on tool calls; a call must pass all of them
+	RateLimits []RateLimit
+	// ConcurrencyLimit bounds the number of GraphQL requests in flight
+	ConcurrencyLimit ConcurrencyLimit
+	// ArgumentOverrides hide tool arguments from the model and fill them in on the server
+	ArgumentOverrides []ArgumentOverride
+	// Redaction rules keep sensitive fields and values out of tool results
+	Redaction []RedactionRule
+	// Visibility hides types and fields from tools, input schemas and the SDL
+	Visibility schema.VisibilityFilter
+	// AuditSink receives a record of every tool call, including denied calls
+	AuditSink AuditSink
+	// AuditArguments controls whether audit records hold argument hashes or redacted values
+	AuditArguments AuditArgumentMode
+	// AuditHashKey keys the argument hashes of audit records and logs; random per server if empty
+	AuditHashKey []byte
 }
 
 // MaskConfig defines how to filter queries and mutations
@@ -26,6 +79,19 @@ type MaskConfig struct {
 	CompiledBlockList []*regexp.Regexp
 }
 
+// defaultConfirmationTTL is how long a confirmation token is valid unless configured otherwise
+const defaultConfirmationTTL = 5 * time.Minute
+
+// ConfirmationConfig defines which mutations must be confirmed before they are executed
+type ConfirmationConfig struct {
+	// Patterns match the names of mutations that require confirmation
+	Patterns []string
+	// CompiledPatterns contains compiled regex patterns for Patterns, set when the server is created
+	CompiledPatterns []*regexp.Regexp
+	// TTL is how long a confirmation token stays valid
+	TTL time.Duration
+}
+
 // MCPGraphQLServerOption is a function that configures MCPGraphQLServerOptions
 type MCPGraphQLServerOption func(*MCPGraphQLServerOptions)
 
@@ -91,6 +157,90 @@ func (opts *MCPGraphQLServerOptions) isOperationAllowed(operationName string) bo
 	return false
 }
 
+// WithReadOnly blocks every mutation, whatever the mask allows
+func WithReadOnly() MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.OperationTypes.DenyMutations = true
+	}
+}
+
+// WithOperationTypePolicy allows or denies queries, mutations and subscriptions separately
+func WithOperationTypePolicy(policy OperationTypePolicy) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.OperationTypes = policy
+	}
+}
+
+// WithCostLimits configures per-field costs, list sizes and the cost, depth and node limits
+// every operation is checked against before it is executed
+func WithCostLimits(limits schema.CostOptions) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.CostLimits = limits
+	}
+}
+
+// WithRateLimit adds a token-bucket limit on tool calls, counted per key
+// For example RateLimit{Name: "session", Key: RateLimitBySession, Requests: 10, Per: time.Second}
+func WithRateLimit(limit RateLimit) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.RateLimits = append(opts.RateLimits, limit)
+	}
+}
+
+// WithConcurrencyLimit bounds the number of GraphQL requests in flight, queueing the calls
+// over the limit for up to the queue timeout
+func WithConcurrencyLimit(limit ConcurrencyLimit) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.ConcurrencyLimit = limit
+	}
+}
+
+// WithArgumentOverrides hides tool arguments from the input schema and fills them from a
+// passthru header, a token claim, a constant or a callback when the tool is called
+func WithArgumentOverrides(overrides ...ArgumentOverride) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.ArgumentOverrides = append(opts.ArgumentOverrides, overrides...)
+	}
+}
+
+// WithRedaction adds rules that drop or mask sensitive fields and values in tool results
+func WithRedaction(rules ...RedactionRule) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.Redaction = append(opts.Redaction, rules...)
+	}
+}
+
+// WithHiddenTypes hides types from everything the model can see, along with the fields and
+// operations that depend on them
+func WithHiddenTypes(typeNames ...string) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.Visibility.HiddenTypes = append(opts.Visibility.HiddenTypes, typeNames...)
+	}
+}
+
+// WithHiddenFields hides fields, given as "Type.field", from everything the model can see
+func WithHiddenFields(typeFields ...string) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.Visibility.HiddenFields = append(opts.Visibility.HiddenFields, typeFields...)
+	}
+}
+
+// WithAuditSink writes a record of every tool call to the sink
+func WithAuditSink(sink AuditSink, arguments AuditArgumentMode) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.AuditSink = sink
+		opts.AuditArguments = arguments
+	}
+}
+
+// WithAuditHashKey sets the key of the HMAC-SHA256 argument hashes in audit records and logs
+// Without it every server uses a random key, so hashes can only be correlated until it restarts
+func WithAuditHashKey(key []byte) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.AuditHashKey = key
+	}
+}
+
 // WithPassthruHeaders configures which headers to pass through from MCP requests to GraphQL requests
 func WithPassthruHeaders(headers []string) MCPGraphQLServerOption {
 	return func(opts *MCPGraphQLServerOptions) {
@@ -98,6 +248,29 @@ func WithPassthruHeaders(headers []string) MCPGraphQLServerOption {
 	}
 }
 
+// WithPassthruRules adds rules that forward headers and cookies of MCP requests to GraphQL
+// requests, optionally under another name and with a template
+func WithPassthruRules(rules ...PassthruRule) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.PassthruRules = append(opts.PassthruRules, rules...)
+	}
+}
+
+// WithPassthruDeny adds headers that are never passed through, even when a rule matches them
+func WithPassthruDeny(headers ...string) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.PassthruDeny = append(opts.PassthruDeny, headers...)
+	}
+}
+
+// WithResponseHeaders copies GraphQL response headers, such as rate limit and request ID
+// headers, into the _meta of tool results. A trailing "*" matches a prefix
+func WithResponseHeaders(headers ...string) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.ResponseHeaders = append(opts.ResponseHeaders, headers...)
+	}
+}
+
 // WithMaxDepth configures the maximum depth for query generation
 func WithMaxDepth(maxDepth int) MCPGraphQLServerOption {
 	return func(opts *MCPGraphQLServerOptions) {
@@ -105,6 +278,171 @@ func WithMaxDepth(maxDepth int) MCPGraphQLServerOption {
 	}
 }
 
+// WithResponseFormatter configures how GraphQL response data is rendered in tool results
+func WithResponseFormatter(formatter ResponseFormatter) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.ResponseFormatter = formatter
+	}
+}
+
+// WithResponseBudget limits the size of every tool result
+// Lists in oversized results are truncated and a note explains what was left out
+func WithResponseBudget(budget ResponseBudget) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.ResponseBudget = budget
+	}
+}
+
+// WithToolResponseBudget limits the size of the results of a single tool, such as "query_equipment"
+func WithToolResponseBudget(toolName string, budget ResponseBudget) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		if opts.ToolResponseBudgets == nil {
+			opts.ToolResponseBudgets = make(map[string]ResponseBudget)
+		}
+		opts.ToolResponseBudgets[toolName] = budget
+	}
+}
+
+// WithInlineInputSchemas inlines input objects in tool input schemas instead of emitting
+// shared $defs and $ref, for clients that cannot resolve references
+func WithInlineInputSchemas() MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.JSONSchema.InlineInputObjects = true
+	}
+}
+
+// WithJSONSchemaDialect selects the JSON Schema dialect of tool input schemas
+// With schema.JSONSchemaStrict, null arguments and input fields are treated as not provided
+func WithJSONSchemaDialect(dialect schema.JSONSchemaDialect) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.JSONSchema.Dialect = dialect
+	}
+}
+
+// WithEnumStyle selects how enum values and their descriptions are rendered in tool input schemas
+func WithEnumStyle(style schema.EnumStyle) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.JSONSchema.EnumStyle = style
+	}
+}
+
+// WithDeprecatedEnumValues keeps deprecated enum values in tool input schemas, flagged as
+// deprecated, instead of leaving them out
+func WithDeprecatedEnumValues() MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.JSONSchema.IncludeDeprecatedEnumValues = true
+	}
+}
+
+// WithScalarSchema describes the values of a custom scalar, such as DateTime, in tool input
+// schemas; unmapped custom scalars are plain strings
+func WithScalarSchema(scalarName string, scalar schema.ScalarSchema) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		if opts.JSONSchema.Scalars == nil {
+			opts.JSONSchema.Scalars = make(map[string]schema.ScalarSchema)
+		}
+		opts.JSONSchema.Scalars[scalarName] = scalar
+	}
+}
+
+// WithToolDescription replaces the description of a tool, such as "query_equipment", which
+// otherwise comes from the GraphQL schema
+func WithToolDescription(toolName, description string) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		if opts.ToolDescriptions == nil {
+			opts.ToolDescriptions = make(map[string]string)
+		}
+		opts.ToolDescriptions[toolName] = description
+	}
+}
+
+// toolDescription returns the configured description of a tool, or the GraphQL description
+func (opts *MCPGraphQLServerOptions) toolDescription(toolName, graphQLDescription string) string {
+	if description, ok := opts.ToolDescriptions[toolName]; ok {
+		return description
+	}
+	return graphQLDescription
+}
+
+// WithAuthorizationPolicy filters tools/list and rejects tool calls per session using the policy
+func WithAuthorizationPolicy(policy AuthorizationPolicy) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.AuthorizationPolicy = policy
+	}
+}
+
+// WithAuthenticators requires callers of the MCP endpoint to present a bearer token accepted by
+// one of the authenticators, which are tried in order
+func WithAuthenticators(authenticators ...Authenticator) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.Authenticators = append(opts.Authenticators, authenticators...)
+	}
+}
+
+// WithProtectedResourceMetadata serves the metadata at /.well-known/oauth-protected-resource and
+// points unauthenticated callers to it. An empty Resource defaults to the URL of the MCP endpoint
+func WithProtectedResourceMetadata(metadata ProtectedResourceMetadata) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.ProtectedResource = &metadata
+	}
+}
+
+// WithTrustedForwardedProto takes the scheme of the resource and metadata URLs derived from the
+// request from the X-Forwarded-Proto header. Only use it behind a proxy that sets the header
+func WithTrustedForwardedProto() MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		opts.TrustForwardedProto = true
+	}
+}
+
+// WithMutationConfirmation requires confirmation before executing mutations whose names match
+// one of the patterns, such as "^delete". The first call returns a preview of the operation and
+// a confirmation token valid for ttl (5 minutes if zero); the mutation only runs when the tool is
+// called again with the token, or when the user accepts an elicitation request on clients that
+// support elicitation
+func WithMutationConfirmation(patterns []string, ttl time.Duration) MCPGraphQLServerOption {
+	return func(opts *MCPGraphQLServerOptions) {
+		if ttl <= 0 {
+			ttl = defaultConfirmationTTL
+		}
+		opts.Confirmation = &ConfirmationConfig{Patterns: patterns, TTL: ttl}
+	}
+}
+
+// compile compiles the patterns of the mutations that require confirmation
+func (c *ConfirmationConfig) compile() error {
+	c.CompiledPatterns = make([]*regexp.Regexp, 0, len(c.Patterns))
+	for _, pattern := range c.Patterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid mutation confirmation pattern %q: %w", pattern, err)
+		}
+		c.CompiledPatterns = append(c.CompiledPatterns, compiled)
+	}
+	return nil
+}
+
+// requiresConfirmation checks if an operation must be confirmed before it is executed
+func (opts *MCPGraphQLServerOptions) requiresConfirmation(operationType, fieldName string) bool {
+	if opts.Confirmation == nil || operationType != "mutation" {
+		return false
+	}
+	for _, pattern := range opts.Confirmation.CompiledPatterns {
+		if pattern.MatchString(fieldName) {
+			return true
+		}
+	}
+	return false
+}
+
+// responseBudgetFor returns the response budget that applies to a tool
+func (opts *MCPGraphQLServerOptions) responseBudgetFor(toolName string) ResponseBudget {
+	if budget, ok := opts.ToolResponseBudgets[toolName]; ok {
+		return budget
+	}
+	return opts.ResponseBudget
+}
+
 // NewMCPGraphQLServerOptions creates a new options struct with default values
 func NewMCPGraphQLServerOptions() *MCPGraphQLServerOptions {
 	return &MCPGraphQLServerOptions{
@@ -112,5 +450,7 @@ func NewMCPGraphQLServerOptions() *MCPGraphQLServerOptions {
 		Mask:            nil,            // No masking by default
 		PassthruHeaders: nil,            // No passthru headers by default
 		MaxDepth:        5,              // Default max depth
+
+		ResponseFormatter: NewIndentedJSONFormatter(), // Indented JSON by default
 	}
 }
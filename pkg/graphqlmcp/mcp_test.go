@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http/httptest"
 	"os"
 	"testing"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/peterbeamish/go-mcp-graphql/pkg/graphqlmcp/schema"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockGraphQLExecutor is a mock implementation of GraphQLExecutor for testing
@@ -344,9 +346,7 @@ func TestMCPGraphQLServer_ErrorHandling(t *testing.T) {
 		// Test with GraphQL errors
 		mockResponseWithErrors := &GraphQLResponse{
 			Data: nil,
-			Errors: []struct {
-				Message string `json:"message"`
-			}{
+			Errors: []GraphQLError{
 				{Message: "Test GraphQL error"},
 			},
 		}
@@ -385,6 +385,95 @@ func TestMCPGraphQLServer_ErrorHandling(t *testing.T) {
 	mockExecutor.AssertExpectations(t)
 }
 
+func TestMCPGraphQLServer_ToolInputValidation(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), map[string]interface{}{"id": "42"}).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"equipmentById": nil}}, nil).Once()
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor)
+	assert.NoError(t, err)
+
+	handler := server.newToolHandler(findField(t, testSchema.GetQueries(), "equipmentById"), "query")
+	callTool := func(arguments string) *mcp.CallToolResult {
+		result, err := handler(context.Background(), &mcp.CallToolRequest{
+			Params: &mcp.CallToolParamsRaw{Name: "query_equipmentById", Arguments: json.RawMessage(arguments)},
+		})
+		assert.NoError(t, err)
+		return result
+	}
+
+	// Integer IDs are coerced to strings before execution
+	result := callTool(`{"id": 42}`)
+	assert.False(t, result.IsError)
+
+	// Invalid input is rejected without calling the upstream API
+	result = callTool(`{"identifier": "42"}`)
+	assert.True(t, result.IsError)
+	text := result.Content[0].(*mcp.TextContent).Text
+	assert.Contains(t, text, "Invalid arguments for query_equipmentById:")
+	assert.Contains(t, text, "- id: missing required argument of type ID!")
+	assert.Contains(t, text, "- identifier: unknown argument; expected one of: id")
+	assert.Len(t, result.Meta["validationErrors"], 2)
+
+	mockExecutor.AssertExpectations(t)
+}
+
+func TestMCPGraphQLServer_InputSchemaRefs(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+
+	createEquipment := findField(t, testSchema.GetMutations(), "createEquipment")
+
+	// Input objects are shared through $defs by default
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor)
+	assert.NoError(t, err)
+	inputSchema := server.createInputSchema(createEquipment)
+	input := inputSchema["properties"].(map[string]interface{})["input"].(map[string]interface{})
+	assert.Equal(t, "#/$defs/CreateEquipmentInput", input["$ref"])
+	assert.Contains(t, inputSchema["$defs"], "CreateEquipmentInput")
+
+	// WithInlineInputSchemas keeps self-contained schemas
+	server, err = NewMCPGraphQLServerWithExecutor(mockExecutor, WithInlineInputSchemas())
+	assert.NoError(t, err)
+	inputSchema = server.createInputSchema(createEquipment)
+	input = inputSchema["properties"].(map[string]interface{})["input"].(map[string]interface{})
+	assert.Equal(t, "object", input["type"])
+	assert.NotContains(t, inputSchema, "$defs")
+}
+
+func TestMCPGraphQLServer_StrictDialect(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), map[string]interface{}{"id": "1", "status": "OPERATIONAL"}).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"updateEquipmentStatus": nil}}, nil).Once()
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor, WithJSONSchemaDialect(schema.JSONSchemaStrict))
+	assert.NoError(t, err)
+
+	updateStatus := findField(t, testSchema.GetMutations(), "updateEquipmentStatus")
+	inputSchema := server.createInputSchema(updateStatus)
+	assert.Equal(t, false, inputSchema["additionalProperties"])
+	assert.Equal(t, []string{"id", "status", "notes"}, inputSchema["required"])
+
+	// Null stands for an omitted optional value and is not sent upstream
+	result, err := server.newToolHandler(updateStatus, "mutation")(context.Background(), &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{
+			Name:      "mutation_updateEquipmentStatus",
+			Arguments: json.RawMessage(`{"id": "1", "status": "OPERATIONAL", "notes": null}`),
+		},
+	})
+	assert.NoError(t, err)
+	assert.False(t, result.IsError)
+	mockExecutor.AssertExpectations(t)
+}
+
 func TestMCPGraphQLServer_ToolDescriptions(t *testing.T) {
 	// Load test schema
 	testSchema := loadTestSchema(t)
@@ -466,3 +555,60 @@ func TestMCPGraphQLServer_InputSchemas(t *testing.T) {
 	// Verify mock expectations
 	mockExecutor.AssertExpectations(t)
 }
+
+func TestMCPGraphQLServer_HiddenTypesAndFields(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor,
+		WithHiddenTypes("ContactInfo", "ContactInfoInput"),
+		WithHiddenFields("Equipment.totalOperatingHours"),
+		// Redaction rules may still name hidden fields
+		WithRedaction(RedactField("ContactInfo.email", RedactDrop)),
+	)
+	require.NoError(t, err)
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	_, err = server.GetMCPServer().Connect(context.Background(), serverTransport, nil)
+	require.NoError(t, err)
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(context.Background(), clientTransport, nil)
+	require.NoError(t, err)
+	defer session.Close()
+
+	listed, err := session.ListTools(context.Background(), nil)
+	require.NoError(t, err)
+	tools := make(map[string]*mcp.Tool)
+	for _, tool := range listed.Tools {
+		tools[tool.Name] = tool
+	}
+
+	// CreateFacilityInput requires contact info, so createFacility is dropped
+	assert.NotContains(t, tools, "mutation_createFacility")
+	require.Contains(t, tools, "mutation_updateFacility")
+	inputSchema, err := json.Marshal(tools["mutation_updateFacility"].InputSchema)
+	require.NoError(t, err)
+	assert.NotContains(t, string(inputSchema), "contactInfo")
+	assert.Contains(t, string(inputSchema), "manager")
+
+	facilities := findField(t, server.GetSchema().GetQueries(), "facilities")
+	query, err := facilities.GenerateQueryStringWithSchema(server.GetSchema())
+	require.NoError(t, err)
+	assert.NotContains(t, query, "contactInfo")
+	assert.NotContains(t, query, "totalOperatingHours")
+	assert.Contains(t, query, "manager")
+
+	recorder := httptest.NewRecorder()
+	GetSchemaHandler(server)(recorder, httptest.NewRequest("GET", "/schema", nil))
+	for _, hidden := range []string{"ContactInfo", "totalOperatingHours", "createFacility"} {
+		assert.NotContains(t, recorder.Body.String(), hidden)
+	}
+
+	// The executor's schema is left untouched
+	assert.NotNil(t, testSchema.GetTypeDefinition("ContactInfo"))
+
+	_, err = NewMCPGraphQLServerWithExecutor(mockExecutor, WithHiddenFields("Equipment.owner"))
+	assert.EqualError(t, err, "invalid visibility filter: cannot hide field Equipment.owner: Equipment has no field owner")
+}
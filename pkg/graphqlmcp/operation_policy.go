@@ -0,0 +1,69 @@
+package graphqlmcp
+
+import (
+	"fmt"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// OperationTypePolicy decides which GraphQL operation types the server may execute
+// The zero value allows queries, mutations and subscriptions
+type OperationTypePolicy struct {
+	// DenyQueries blocks query operations
+	DenyQueries bool
+	// DenyMutations blocks mutation operations
+	DenyMutations bool
+	// DenySubscriptions blocks subscription operations
+	DenySubscriptions bool
+}
+
+// Allows checks if an operation type ("query", "mutation" or "subscription") may be executed
+func (p OperationTypePolicy) Allows(operationType string) bool {
+	switch ast.Operation(operationType) {
+	case ast.Query:
+		return !p.DenyQueries
+	case ast.Mutation:
+		return !p.DenyMutations
+	case ast.Subscription:
+		return !p.DenySubscriptions
+	}
+	return false
+}
+
+// isOperationTypeAllowed checks if an operation type is allowed by the operation type policy
+func (opts *MCPGraphQLServerOptions) isOperationTypeAllowed(operationType string) bool {
+	return opts.OperationTypes.Allows(operationType)
+}
+
+// checkOperationDocument rejects a GraphQL document if any of its operations has a type the
+// policy denies. This runs right before execution, so tools added later cannot bypass it
+func (opts *MCPGraphQLServerOptions) checkOperationDocument(document string) error {
+	operationTypes, err := documentOperationTypes(document)
+	if err != nil {
+		return err
+	}
+	for _, operationType := range operationTypes {
+		if !opts.isOperationTypeAllowed(operationType) {
+			return fmt.Errorf("%s operations are disabled on this server", operationType)
+		}
+	}
+	return nil
+}
+
+// documentOperationTypes returns the type of every operation in a GraphQL document
+func documentOperationTypes(document string) ([]string, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: document})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GraphQL operation: %w", err)
+	}
+	if len(doc.Operations) == 0 {
+		return nil, fmt.Errorf("the GraphQL document contains no operations")
+	}
+
+	operationTypes := make([]string, 0, len(doc.Operations))
+	for _, operation := range doc.Operations {
+		operationTypes = append(operationTypes, string(operation.Operation))
+	}
+	return operationTypes, nil
+}
@@ -0,0 +1,134 @@
+package graphqlmcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentOperationTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		expected []string
+		errMsg   string
+	}{
+		{name: "anonymous query", document: "{ equipment { id } }", expected: []string{"query"}},
+		{name: "named mutation", document: "mutation Delete($id: ID!) { deleteEquipment(id: $id) }", expected: []string{"mutation"}},
+		{
+			name:     "several operations and a fragment",
+			document: "fragment F on Equipment { id }\nquery A { equipment { ...F } }\nsubscription B { updates { id } }",
+			expected: []string{"query", "subscription"},
+		},
+		{
+			name:     "object default value",
+			document: `query($filter: Filter = {status: "ACTIVE"}) { equipment(filter: $filter) { id } }`,
+			expected: []string{"query"},
+		},
+		{name: "scalar result", document: "mutation($id: ID!) {\n  deleteEquipment(id: $id)\n}", expected: []string{"mutation"}},
+		{name: "empty selection set", document: "mutation($id: ID!) {\n  deleteEquipment(id: $id) {\n    \n  }\n}", errMsg: "failed to parse GraphQL operation"},
+		{name: "empty document", document: "  ", errMsg: "contains no operations"},
+		{name: "unknown definition", document: "type Query { id: ID }", errMsg: `Unexpected Name "type"`},
+		{name: "invalid token", document: `query { a(b: "unterminated) }`, errMsg: "failed to parse GraphQL operation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operationTypes, err := documentOperationTypes(tt.document)
+			if tt.errMsg != "" {
+				assert.ErrorContains(t, err, tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, operationTypes)
+		})
+	}
+}
+
+func TestMCPGraphQLServer_ReadOnly(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"equipment": []interface{}{}}}, nil)
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor, WithReadOnly())
+	require.NoError(t, err)
+
+	// Only query tools are registered
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	_, err = server.GetMCPServer().Connect(context.Background(), serverTransport, nil)
+	require.NoError(t, err)
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(context.Background(), clientTransport, nil)
+	require.NoError(t, err)
+	defer session.Close()
+
+	listed, err := session.ListTools(context.Background(), nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, listed.Tools)
+	for _, tool := range listed.Tools {
+		assert.True(t, strings.HasPrefix(tool.Name, "query_"), "unexpected tool %s", tool.Name)
+	}
+
+	// The /tools listing applies the same policy
+	recorder := httptest.NewRecorder()
+	GetToolsHandler(server)(recorder, httptest.NewRequest("GET", "/tools", nil))
+	var body struct {
+		Tools []struct {
+			Type string `json:"type"`
+		} `json:"tools"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Len(t, body.Tools, len(testSchema.GetQueries()))
+	for _, tool := range body.Tools {
+		assert.Equal(t, "query", tool.Type)
+	}
+
+	// Handlers that bypass registration are still stopped before execution
+	handler := server.newToolHandler(findField(t, testSchema.GetMutations(), "deleteEquipment"), "mutation")
+	result, err := handler(context.Background(), &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{Name: "mutation_deleteEquipment", Arguments: json.RawMessage(`{"id": "7"}`)},
+	})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "mutation operations are disabled on this server")
+	mockExecutor.AssertNotCalled(t, "ExecuteQuery", mock.Anything, mock.Anything, mock.Anything)
+
+	result, err = session.CallTool(context.Background(), &mcp.CallToolParams{Name: "query_equipment"})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+}
+
+func TestMCPGraphQLServer_OperationTypePolicy(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor, WithOperationTypePolicy(OperationTypePolicy{DenyQueries: true}))
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	GetToolsHandler(server)(recorder, httptest.NewRequest("GET", "/tools", nil))
+	var body struct {
+		Count int `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, len(testSchema.GetMutations()), body.Count)
+
+	handler := server.newToolHandler(findField(t, testSchema.GetQueries(), "equipment"), "query")
+	result, err := handler(context.Background(), &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "query_equipment"}})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "query operations are disabled on this server")
+	mockExecutor.AssertNotCalled(t, "ExecuteQuery", mock.Anything, mock.Anything, mock.Anything)
+}
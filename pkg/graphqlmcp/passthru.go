@@ -0,0 +1,266 @@
+package graphqlmcp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// PassthruValue is the placeholder for the incoming value in a passthru rule template
+const PassthruValue = "{value}"
+
+// protectedHeaders describe the upstream HTTP request itself and are never passed through
+var protectedHeaders = []string{
+	"Connection", "Content-Length", "Content-Type", "Host", "Keep-Alive", "Proxy-Connection",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// PassthruRule forwards a header or cookie of incoming MCP requests to GraphQL requests
+// Exactly one of Header and Cookie selects the incoming value
+type PassthruRule struct {
+	// Header names the incoming header; a trailing "*" matches every header with that prefix,
+	// e.g. "X-Tenant-*"
+	Header string `yaml:"header"`
+	// Cookie names a cookie of the incoming request
+	Cookie string `yaml:"cookie"`
+
+	// As is the header name sent upstream. It defaults to Header, and cookies are forwarded in
+	// the upstream Cookie header unless As is set
+	As string `yaml:"as"`
+	// Template formats each upstream value, with PassthruValue replaced by the incoming value,
+	// e.g. "Bearer {value}"
+	Template string `yaml:"template"`
+}
+
+// PassthruHeader forwards every value of a header unchanged
+func PassthruHeader(name string) PassthruRule {
+	return PassthruRule{Header: name}
+}
+
+// PassthruRename forwards a header under another name, formatting its values with a template
+// such as "Bearer {value}"; an empty template forwards the values unchanged
+func PassthruRename(from, to, template string) PassthruRule {
+	return PassthruRule{Header: from, As: to, Template: template}
+}
+
+// PassthruCookie forwards a cookie in the upstream Cookie header
+func PassthruCookie(name string) PassthruRule {
+	return PassthruRule{Cookie: name}
+}
+
+// String describes the rule for errors and logs
+func (r PassthruRule) String() string {
+	source := "header " + r.Header
+	if r.Cookie != "" {
+		source = "cookie " + r.Cookie
+	}
+	if r.As != "" {
+		source += " as " + r.As
+	}
+	return source
+}
+
+// upstreamName returns the name of the header sent upstream, or "" for wildcard rules
+func (r PassthruRule) upstreamName() string {
+	switch {
+	case r.As != "":
+		return http.CanonicalHeaderKey(r.As)
+	case r.Cookie != "":
+		return "Cookie"
+	case strings.HasSuffix(r.Header, "*"):
+		return ""
+	}
+	return http.CanonicalHeaderKey(r.Header)
+}
+
+// validate checks that the rule selects one value and names valid headers
+func (r PassthruRule) validate() error {
+	if (r.Header == "") == (r.Cookie == "") {
+		return errors.New("exactly one of Header and Cookie must be set")
+	}
+	for _, name := range []string{strings.TrimSuffix(r.Header, "*"), r.Cookie, r.As} {
+		if strings.ContainsAny(name, " \t\r\n:;,=*") {
+			return fmt.Errorf("invalid name %q", name)
+		}
+	}
+	if strings.HasSuffix(r.Header, "*") && (r.As != "" || r.Template != "") {
+		return errors.New("prefix rules cannot rename headers or use a template")
+	}
+	if r.Template != "" && !strings.Contains(r.Template, PassthruValue) {
+		return fmt.Errorf("template %q does not contain %s", r.Template, PassthruValue)
+	}
+	if r.Cookie != "" && r.As == "" && r.Template != "" {
+		return errors.New("cookies forwarded in the Cookie header cannot use a template")
+	}
+	return nil
+}
+
+// headerPattern matches header names exactly, or by prefix with a trailing "*"
+type headerPattern string
+
+// newHeaderPattern canonicalizes a header name or prefix pattern
+func newHeaderPattern(name string) headerPattern {
+	return headerPattern(strings.ToLower(name))
+}
+
+// matches reports whether a header name matches the pattern
+func (p headerPattern) matches(name string) bool {
+	name = strings.ToLower(name)
+	if prefix, ok := strings.CutSuffix(string(p), "*"); ok {
+		return strings.HasPrefix(name, prefix)
+	}
+	return name == string(p)
+}
+
+// matchesAny reports whether a header name matches one of the patterns
+func matchesAny(patterns []headerPattern, name string) bool {
+	for _, pattern := range patterns {
+		if pattern.matches(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// passthruRules holds the validated passthru rules and deny-list
+type passthruRules struct {
+	rules []PassthruRule
+	deny  []headerPattern
+}
+
+// compilePassthruRules validates the passthru headers and rules of the options
+func compilePassthruRules(options *MCPGraphQLServerOptions) (*passthruRules, error) {
+	rules := make([]PassthruRule, 0, len(options.PassthruHeaders)+len(options.PassthruRules))
+	for _, name := range options.PassthruHeaders {
+		rules = append(rules, PassthruHeader(name))
+	}
+	rules = append(rules, options.PassthruRules...)
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	compiled := &passthruRules{rules: rules}
+	for _, name := range append(protectedHeaders, options.PassthruDeny...) {
+		compiled.deny = append(compiled.deny, newHeaderPattern(name))
+	}
+	for _, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("invalid passthru rule %s: %w", rule, err)
+		}
+		if name := rule.upstreamName(); name != "" && matchesAny(compiled.deny, name) {
+			return nil, fmt.Errorf("invalid passthru rule %s: %s is on the deny-list", rule, name)
+		}
+	}
+	return compiled, nil
+}
+
+// forwards reports whether a rule sends a header with the given name upstream
+func (p *passthruRules) forwards(name string) bool {
+	if p == nil {
+		return false
+	}
+	for _, rule := range p.rules {
+		if upstream := rule.upstreamName(); upstream != "" {
+			if upstream == http.CanonicalHeaderKey(name) {
+				return true
+			}
+		} else if newHeaderPattern(rule.Header).matches(name) && !matchesAny(p.deny, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// extract returns the upstream headers for the headers of an incoming request
+func (p *passthruRules) extract(header http.Header) http.Header {
+	upstream := make(http.Header)
+	var cookies []string
+	cookieReq := &http.Request{Header: header}
+
+	for _, rule := range p.rules {
+		switch {
+		case rule.Cookie != "":
+			cookie, err := cookieReq.Cookie(rule.Cookie)
+			if err != nil || cookie.Value == "" {
+				continue
+			}
+			if rule.As == "" {
+				cookies = append(cookies, cookie.String())
+				continue
+			}
+			upstream.Add(rule.As, rule.format(cookie.Value))
+
+		case strings.HasSuffix(rule.Header, "*"):
+			pattern := newHeaderPattern(rule.Header)
+			for name, values := range header {
+				if pattern.matches(name) && !matchesAny(p.deny, name) {
+					upstream[name] = append(upstream[name], values...)
+				}
+			}
+
+		default:
+			for _, value := range header.Values(rule.Header) {
+				if value != "" {
+					upstream.Add(rule.upstreamName(), rule.format(value))
+				}
+			}
+		}
+	}
+	if len(cookies) > 0 {
+		// HTTP/1.1 allows a single Cookie header
+		upstream.Set("Cookie", strings.Join(cookies, "; "))
+	}
+	return upstream
+}
+
+// format applies the rule template to an incoming value
+func (r PassthruRule) format(value string) string {
+	if r.Template == "" {
+		return value
+	}
+	return strings.ReplaceAll(r.Template, PassthruValue, value)
+}
+
+// responseHeaderMeta returns the configured upstream response headers, with multiple values
+// joined by commas, or nil when there are none
+func (s *MCPGraphQLServer) responseHeaderMeta(resp *GraphQLResponse, err error) map[string]string {
+	if len(s.options.ResponseHeaders) == 0 {
+		return nil
+	}
+
+	header := http.Header(nil)
+	var statusErr *HTTPStatusError
+	switch {
+	case errors.As(err, &statusErr):
+		header = statusErr.Header
+	case resp != nil:
+		header = resp.Header
+	}
+
+	selected := make(map[string]string)
+	for name, values := range header {
+		for _, pattern := range s.options.ResponseHeaders {
+			if newHeaderPattern(pattern).matches(name) {
+				selected[name] = strings.Join(values, ", ")
+				break
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+	return selected
+}
+
+// withResponseHeaders adds the upstream response headers to the meta of a tool result
+func withResponseHeaders(meta map[string]interface{}, headers map[string]string) map[string]interface{} {
+	if headers == nil {
+		return meta
+	}
+	if meta == nil {
+		meta = make(map[string]interface{})
+	}
+	meta["responseHeaders"] = headers
+	return meta
+}
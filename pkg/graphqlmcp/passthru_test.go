@@ -0,0 +1,152 @@
+package graphqlmcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassthruRules_Extract(t *testing.T) {
+	options := NewMCPGraphQLServerOptions()
+	WithPassthruHeaders([]string{"X-Request-ID"})(options)
+	WithPassthruRules(
+		PassthruRename("X-User-Token", "Authorization", "Bearer {value}"),
+		PassthruCookie("session"),
+		PassthruCookie("locale"),
+		PassthruRule{Cookie: "csrf", As: "X-CSRF-Token"},
+		PassthruHeader("X-Tenant-*"),
+	)(options)
+	WithPassthruDeny("X-Tenant-Secret")(options)
+
+	rules, err := compilePassthruRules(options)
+	require.NoError(t, err)
+
+	upstream := rules.extract(http.Header{
+		"X-Request-Id":    {"a", "b"},
+		"X-User-Token":    {"abc"},
+		"Cookie":          {"session=s1; csrf=c1", "locale=en; other=x"},
+		"X-Tenant-Id":     {"t1"},
+		"X-Tenant-Region": {"eu"},
+		"X-Tenant-Secret": {"hunter2"},
+		"X-Unrelated":     {"x"},
+	})
+
+	assert.Equal(t, http.Header{
+		"X-Request-Id":    {"a", "b"},
+		"Authorization":   {"Bearer abc"},
+		"Cookie":          {"session=s1; locale=en"},
+		"X-Csrf-Token":    {"c1"},
+		"X-Tenant-Id":     {"t1"},
+		"X-Tenant-Region": {"eu"},
+	}, upstream)
+
+	assert.True(t, rules.forwards("authorization"))
+	assert.True(t, rules.forwards("X-Tenant-Id"))
+	assert.False(t, rules.forwards("X-Tenant-Secret"))
+	assert.False(t, rules.forwards("X-User-Token"))
+}
+
+func TestPassthruRules_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		option   MCPGraphQLServerOption
+		expected string
+	}{
+		{name: "no source", option: WithPassthruRules(PassthruRule{As: "Authorization"}), expected: "exactly one of Header and Cookie must be set"},
+		{name: "template without value", option: WithPassthruRules(PassthruRename("X-Token", "Authorization", "Bearer")), expected: `template "Bearer" does not contain {value}`},
+		{name: "renamed prefix", option: WithPassthruRules(PassthruRule{Header: "X-Tenant-*", As: "X-Org"}), expected: "prefix rules cannot rename headers"},
+		{name: "invalid name", option: WithPassthruRules(PassthruHeader("X Token")), expected: `invalid name "X Token"`},
+		{name: "protected header", option: WithPassthruRules(PassthruRename("X-Upstream", "Host", "")), expected: "Host is on the deny-list"},
+		{name: "denied header", option: WithPassthruHeaders([]string{"X-Secret", "X-Debug"}), expected: "X-Debug is on the deny-list"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := NewMCPGraphQLServerOptions()
+			tt.option(options)
+			WithPassthruDeny("x-debug")(options)
+			_, err := compilePassthruRules(options)
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestMCPGraphQLServer_PassthruAndResponseHeaders(t *testing.T) {
+	introspection, err := os.ReadFile("testdata/real_introspection_response.json")
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var received http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GraphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if strings.Contains(req.Query, "__schema") {
+			_, _ = w.Write(introspection)
+			return
+		}
+
+		mu.Lock()
+		received = r.Header.Clone()
+		mu.Unlock()
+
+		w.Header().Set("X-Request-Id", "req-42")
+		w.Header().Set("X-RateLimit-Remaining", "9")
+		w.Header().Set("X-Internal-Trace", "secret")
+		if strings.Contains(req.Query, "facilities") {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte("slow down"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"equipment": []}}`))
+	}))
+	defer upstream.Close()
+
+	server, err := NewMCPGraphQLServer(upstream.URL,
+		WithPassthruHeaders([]string{"X-Request-Tag"}),
+		WithPassthruRules(PassthruRename("X-User-Token", "Authorization", "Bearer {value}")),
+		WithResponseHeaders("X-Request-Id", "X-RateLimit-*", "Retry-After"),
+	)
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(GetCompleteMux(server))
+	defer httpServer.Close()
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(context.Background(), &mcp.StreamableClientTransport{
+		Endpoint: httpServer.URL + "/mcp",
+		HTTPClient: &http.Client{Transport: &headerTransport{header: http.Header{
+			"X-User-Token":  {"user-token"},
+			"X-Request-Tag": {"a", "b"},
+		}}},
+	}, nil)
+	require.NoError(t, err)
+	defer session.Close()
+
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "query_equipment"})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	mu.Lock()
+	assert.Equal(t, "Bearer user-token", received.Get("Authorization"))
+	assert.Equal(t, []string{"a", "b"}, received.Values("X-Request-Tag"))
+	assert.Empty(t, received.Get("X-User-Token"))
+	mu.Unlock()
+
+	assert.Equal(t, map[string]interface{}{"X-Request-Id": "req-42", "X-Ratelimit-Remaining": "9"}, result.Meta["responseHeaders"])
+
+	// Headers of failed requests are reported too
+	result, err = session.CallTool(context.Background(), &mcp.CallToolParams{Name: "query_facilities"})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "status 429: slow down")
+	assert.Equal(t, map[string]interface{}{"X-Request-Id": "req-42", "X-Ratelimit-Remaining": "9", "Retry-After": "30"}, result.Meta["responseHeaders"])
+}
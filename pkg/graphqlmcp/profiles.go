@@ -0,0 +1,181 @@
+package graphqlmcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/peterbeamish/go-mcp-graphql/pkg/graphqlmcp/schema"
+)
+
+// ServerProfile is a view of a shared schema and executor with its own masks, policies and
+// descriptions, such as a read-only profile for analysts next to one for operators
+type ServerProfile struct {
+	// Name identifies the profile, e.g. "readonly"
+	Name string
+	// Prefix is the path GetProfilesMux mounts the MCP endpoint of the profile at, with its
+	// tools and schema endpoints below it; defaults to "/mcp/" + Name
+	Prefix string
+	// Options are applied after the options shared by every profile
+	Options []MCPGraphQLServerOption
+}
+
+// profilePrefixPattern matches the paths profiles can be mounted at
+var profilePrefixPattern = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+// ServerProfiles holds the servers of several profiles that share one schema and executor
+type ServerProfiles struct {
+	executor GraphQLExecutor
+	profiles []ServerProfile
+	servers  map[string]*MCPGraphQLServer
+}
+
+// NewMCPGraphQLServerProfiles introspects the schema once and creates a server for each
+// profile, configured with the shared options followed by the options of the profile
+// Rate limits are counted per profile, while the concurrency limit of the shared options bounds
+// the upstream requests of all profiles together
+func NewMCPGraphQLServerProfiles(executor GraphQLExecutor, shared []MCPGraphQLServerOption, profiles ...ServerProfile) (*ServerProfiles, error) {
+	if len(profiles) == 0 {
+		return nil, errors.New("at least one server profile is required")
+	}
+
+	set := &ServerProfiles{
+		executor: executor,
+		servers:  make(map[string]*MCPGraphQLServer, len(profiles)),
+	}
+	prefixes := make(map[string]string, len(profiles))
+
+	// The profiles share one executor, so they share one limit on its requests in flight
+	sharedOptions := NewMCPGraphQLServerOptions()
+	for _, opt := range shared {
+		opt(sharedOptions)
+	}
+	inFlight := newConcurrencyLimiter(sharedOptions.ConcurrencyLimit)
+
+	// Argument hashes of all profiles use one key, so audit records can be correlated across them
+	if len(sharedOptions.AuditHashKey) == 0 {
+		key, err := newAuditHashKey()
+		if err != nil {
+			return nil, err
+		}
+		shared = append([]MCPGraphQLServerOption{WithAuditHashKey(key)}, shared...)
+	}
+
+	for _, profile := range profiles {
+		if profile.Name == "" {
+			return nil, errors.New("invalid server profile: missing name")
+		}
+		if _, ok := set.servers[profile.Name]; ok {
+			return nil, fmt.Errorf("invalid server profile %s: the name is used twice", profile.Name)
+		}
+		if profile.Prefix == "" {
+			profile.Prefix = "/mcp/" + profile.Name
+		}
+		if !profilePrefixPattern.MatchString(profile.Prefix) {
+			return nil, fmt.Errorf("invalid server profile %s: prefix %q must be a path such as /mcp/%s", profile.Name, profile.Prefix, profile.Name)
+		}
+		if other, ok := prefixes[profile.Prefix]; ok {
+			return nil, fmt.Errorf("invalid server profile %s: prefix %s is also used by %s", profile.Name, profile.Prefix, other)
+		}
+		prefixes[profile.Prefix] = profile.Name
+
+		opts := append(append([]MCPGraphQLServerOption{}, shared...), profile.Options...)
+		server, err := newServerWithoutSchema(executor, opts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server profile %s: %w", profile.Name, err)
+		}
+		if server.options.ConcurrencyLimit != sharedOptions.ConcurrencyLimit {
+			return nil, fmt.Errorf("invalid server profile %s: the concurrency limit is shared by all profiles and can only be set in the shared options", profile.Name)
+		}
+		server.mountPath = profile.Prefix
+		server.inFlight = inFlight
+
+		set.profiles = append(set.profiles, profile)
+		set.servers[profile.Name] = server
+	}
+
+	// GetProfilesMux also serves endpoints below each prefix and /health, which no prefix may take
+	for _, profile := range set.profiles {
+		if profile.Prefix == "/health" {
+			return nil, fmt.Errorf("invalid server profile %s: prefix /health is used by the health endpoint", profile.Name)
+		}
+		for _, suffix := range []string{"/sse", "/tools", "/schema"} {
+			if !strings.HasSuffix(profile.Prefix, suffix) {
+				continue
+			}
+			if other, ok := prefixes[strings.TrimSuffix(profile.Prefix, suffix)]; ok {
+				return nil, fmt.Errorf("invalid server profile %s: prefix %s is used by the %s endpoint of %s", profile.Name, profile.Prefix, suffix, other)
+			}
+		}
+	}
+
+	// Introspect the schema once for every profile
+	ctx := context.Background()
+	introspected, err := executor.IntrospectSchema(ctx)
+	if err != nil {
+		set.servers[profiles[0].Name].logger.Info("Failed to introspect GraphQL schema, continuing with empty schema", "error", err)
+	}
+	if err := set.setSchema(introspected); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// setSchema prepares the schema and recreates the tools of every profile
+func (p *ServerProfiles) setSchema(introspected *schema.Schema) error {
+	for _, profile := range p.profiles {
+		if err := p.servers[profile.Name].setSchema(introspected); err != nil {
+			return fmt.Errorf("invalid server profile %s: %w", profile.Name, err)
+		}
+	}
+	return nil
+}
+
+// Server returns the server of a profile, or nil if there is no such profile
+func (p *ServerProfiles) Server(name string) *MCPGraphQLServer {
+	return p.servers[name]
+}
+
+// Profiles returns the profiles in the order they were given, with their prefixes filled in
+func (p *ServerProfiles) Profiles() []ServerProfile {
+	return append([]ServerProfile(nil), p.profiles...)
+}
+
+// RefreshSchema re-introspects the GraphQL schema once and updates the tools of every profile
+func (p *ServerProfiles) RefreshSchema() error {
+	ctx := context.Background()
+	introspected, err := p.executor.IntrospectSchema(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to refresh GraphQL schema: %w", err)
+	}
+	if err := p.setSchema(introspected); err != nil {
+		return fmt.Errorf("failed to refresh GraphQL schema: %w", err)
+	}
+	return nil
+}
+
+// GetProfilesMux returns an http.ServeMux that mounts every profile under its prefix, like
+// GetCompleteMux does for a single server: the MCP endpoint at the prefix, and the SSE, tools
+// and schema endpoints at prefix + "/sse", "/tools" and "/schema". /health is shared, and the
+// protected resource metadata of a profile is served at ProtectedResourcePath + prefix
+func GetProfilesMux(profiles *ServerProfiles) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", GetHealthHandler())
+
+	for _, profile := range profiles.profiles {
+		server := profiles.servers[profile.Name]
+		prefix := profile.Prefix
+
+		mux.Handle(prefix, NewMCPHandler(server))
+		mux.Handle(prefix+"/sse", NewSSEHandler(server))
+		mux.Handle(prefix+"/tools", server.requireAuthentication(GetToolsHandler(server)))
+		mux.Handle(prefix+"/schema", server.requireAuthentication(GetSchemaHandler(server)))
+		if server.options.ProtectedResource != nil {
+			mux.HandleFunc(server.protectedResourcePath(), GetProtectedResourceMetadataHandler(server))
+		}
+	}
+	return mux
+}
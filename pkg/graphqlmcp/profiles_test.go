@@ -0,0 +1,246 @@
+package graphqlmcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMCPGraphQLServerProfiles(t *testing.T) {
+	testSchema := loadTestSchema(t)
+	maxDepth := testSchema.MaxDepth
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+
+	profiles, err := NewMCPGraphQLServerProfiles(mockExecutor,
+		[]MCPGraphQLServerOption{WithMaxDepth(3)},
+		ServerProfile{Name: "readonly", Options: []MCPGraphQLServerOption{
+			WithReadOnly(),
+			WithMaxDepth(1),
+			WithToolDescription("query_equipment", "Equipment for analysts"),
+		}},
+		ServerProfile{Name: "operator", Prefix: "/ops/mcp"},
+	)
+	require.NoError(t, err)
+
+	// The schema is introspected once for every profile
+	mockExecutor.AssertNumberOfCalls(t, "IntrospectSchema", 1)
+	assert.Equal(t, []ServerProfile{
+		{Name: "readonly", Prefix: "/mcp/readonly"},
+		{Name: "operator", Prefix: "/ops/mcp"},
+	}, stripProfileOptions(profiles.Profiles()))
+
+	readonly, operator := profiles.Server("readonly"), profiles.Server("operator")
+	require.NotNil(t, readonly)
+	require.NotNil(t, operator)
+	assert.Nil(t, profiles.Server("admin"))
+	assert.Equal(t, 1, readonly.GetSchema().MaxDepth)
+	assert.Equal(t, 3, operator.GetSchema().MaxDepth)
+	assert.NotEmpty(t, readonly.options.AuditHashKey)
+	assert.Equal(t, readonly.options.AuditHashKey, operator.options.AuditHashKey)
+	assert.Equal(t, maxDepth, testSchema.MaxDepth)
+
+	readonlyTools := listTools(t, readonly)
+	operatorTools := listTools(t, operator)
+	assert.Equal(t, "Equipment for analysts", readonlyTools["query_equipment"].Description)
+	assert.NotEqual(t, "Equipment for analysts", operatorTools["query_equipment"].Description)
+	for name := range readonlyTools {
+		assert.True(t, strings.HasPrefix(name, "query_"), name)
+	}
+	assert.Contains(t, operatorTools, "mutation_createEquipment")
+
+	// Refreshing introspects once more and keeps the profiles apart
+	require.NoError(t, profiles.RefreshSchema())
+	mockExecutor.AssertNumberOfCalls(t, "IntrospectSchema", 2)
+	assert.Equal(t, 1, profiles.Server("readonly").GetSchema().MaxDepth)
+	assert.Equal(t, 3, profiles.Server("operator").GetSchema().MaxDepth)
+}
+
+func TestNewMCPGraphQLServerProfiles_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles []ServerProfile
+		expected string
+	}{
+		{name: "none", expected: "at least one server profile is required"},
+		{name: "missing name", profiles: []ServerProfile{{}}, expected: "missing name"},
+		{name: "duplicate name", profiles: []ServerProfile{{Name: "ops"}, {Name: "ops"}}, expected: "invalid server profile ops: the name is used twice"},
+		{name: "prefix", profiles: []ServerProfile{{Name: "ops", Prefix: "ops/"}}, expected: `invalid server profile ops: prefix "ops/" must be a path`},
+		{name: "duplicate prefix", profiles: []ServerProfile{{Name: "a", Prefix: "/mcp"}, {Name: "b", Prefix: "/mcp"}}, expected: "invalid server profile b: prefix /mcp is also used by a"},
+		{name: "health prefix", profiles: []ServerProfile{{Name: "ops", Prefix: "/health"}}, expected: "invalid server profile ops: prefix /health is used by the health endpoint"},
+		{name: "tools prefix", profiles: []ServerProfile{{Name: "a", Prefix: "/mcp/ops/tools"}, {Name: "b", Prefix: "/mcp/ops"}}, expected: "invalid server profile a: prefix /mcp/ops/tools is used by the /tools endpoint of b"},
+		{name: "sse prefix", profiles: []ServerProfile{{Name: "a"}, {Name: "b", Prefix: "/mcp/a/sse"}}, expected: "invalid server profile b: prefix /mcp/a/sse is used by the /sse endpoint of a"},
+		{name: "concurrency limit", profiles: []ServerProfile{{Name: "ops", Options: []MCPGraphQLServerOption{WithConcurrencyLimit(ConcurrencyLimit{MaxInFlight: 2})}}}, expected: "invalid server profile ops: the concurrency limit is shared by all profiles"},
+		{name: "options", profiles: []ServerProfile{{Name: "ops", Options: []MCPGraphQLServerOption{WithPassthruHeaders([]string{"Host"})}}}, expected: "invalid server profile ops:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMCPGraphQLServerProfiles(new(MockGraphQLExecutor), nil, tt.profiles...)
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestNewMCPGraphQLServerProfiles_ConcurrencyLimit(t *testing.T) {
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(loadTestSchema(t), nil)
+
+	profiles, err := NewMCPGraphQLServerProfiles(mockExecutor,
+		[]MCPGraphQLServerOption{WithConcurrencyLimit(ConcurrencyLimit{MaxInFlight: 1})},
+		ServerProfile{Name: "readonly", Options: []MCPGraphQLServerOption{WithReadOnly()}},
+		ServerProfile{Name: "operator"},
+	)
+	require.NoError(t, err)
+
+	// The upstream requests of all profiles count against one limit
+	release, err := profiles.Server("readonly").inFlight.acquire(context.Background())
+	require.NoError(t, err)
+	_, err = profiles.Server("operator").inFlight.acquire(context.Background())
+	var limitErr *RateLimitError
+	assert.ErrorAs(t, err, &limitErr)
+
+	release()
+	release, err = profiles.Server("operator").inFlight.acquire(context.Background())
+	require.NoError(t, err)
+	release()
+}
+
+func TestGetProfilesMux(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+
+	profiles, err := NewMCPGraphQLServerProfiles(mockExecutor,
+		[]MCPGraphQLServerOption{WithProtectedResourceMetadata(ProtectedResourceMetadata{AuthorizationServers: []string{testIssuer}})},
+		ServerProfile{Name: "readonly", Options: []MCPGraphQLServerOption{WithReadOnly()}},
+		ServerProfile{Name: "operator"},
+	)
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(GetProfilesMux(profiles))
+	t.Cleanup(httpServer.Close)
+
+	resp, err := http.Get(httpServer.URL + "/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	for _, profile := range []string{"readonly", "operator"} {
+		prefix := "/mcp/" + profile
+
+		resp, err := http.Get(httpServer.URL + ProtectedResourcePath + prefix)
+		require.NoError(t, err)
+		var metadata ProtectedResourceMetadata
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&metadata))
+		resp.Body.Close()
+		assert.Equal(t, httpServer.URL+prefix, metadata.Resource)
+
+		resp, err = http.Get(httpServer.URL + prefix + "/schema")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	var tools struct {
+		Tools []map[string]interface{} `json:"tools"`
+	}
+	resp, err = http.Get(httpServer.URL + "/mcp/readonly/tools")
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&tools))
+	resp.Body.Close()
+	require.NotEmpty(t, tools.Tools)
+	for _, tool := range tools.Tools {
+		assert.Equal(t, "query", tool["type"], tool["name"])
+	}
+
+	// Each prefix serves the MCP endpoint of its own profile
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(context.Background(), &mcp.StreamableClientTransport{Endpoint: httpServer.URL + "/mcp/operator"}, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { session.Close() })
+
+	result, err := session.ListTools(context.Background(), nil)
+	require.NoError(t, err)
+	var names []string
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+	}
+	assert.Contains(t, names, "mutation_createEquipment")
+}
+
+func TestGetProfilesMux_Masks(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+
+	profiles, err := NewMCPGraphQLServerProfiles(mockExecutor, nil,
+		ServerProfile{Name: "equipment", Options: []MCPGraphQLServerOption{WithMask([]string{"^equipment"}, nil)}},
+		ServerProfile{Name: "other", Options: []MCPGraphQLServerOption{WithMask(nil, []string{"^equipment"})}},
+	)
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(GetProfilesMux(profiles))
+	t.Cleanup(httpServer.Close)
+
+	// The tools endpoint of each profile lists the tools its MCP server has
+	for _, profile := range []string{"equipment", "other"} {
+		var tools struct {
+			Tools []map[string]interface{} `json:"tools"`
+		}
+		resp, err := http.Get(httpServer.URL + "/mcp/" + profile + "/tools")
+		require.NoError(t, err)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&tools))
+		resp.Body.Close()
+
+		listed := make(map[string]bool, len(tools.Tools))
+		for _, tool := range tools.Tools {
+			listed[tool["name"].(string)] = true
+		}
+		expected := make(map[string]bool)
+		for name := range listTools(t, profiles.Server(profile)) {
+			expected[name] = true
+		}
+		require.NotEmpty(t, listed)
+		assert.Equal(t, expected, listed, profile)
+		assert.Equal(t, profile == "equipment", listed["query_equipment"], profile)
+	}
+}
+
+// listTools lists the tools of a server over an in-memory session, keyed by name
+func listTools(t *testing.T, server *MCPGraphQLServer) map[string]*mcp.Tool {
+	t.Helper()
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	_, err := server.GetMCPServer().Connect(context.Background(), serverTransport, nil)
+	require.NoError(t, err)
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(context.Background(), clientTransport, nil)
+	require.NoError(t, err)
+	defer session.Close()
+
+	result, err := session.ListTools(context.Background(), nil)
+	require.NoError(t, err)
+	tools := make(map[string]*mcp.Tool, len(result.Tools))
+	for _, tool := range result.Tools {
+		tools[tool.Name] = tool
+	}
+	return tools
+}
+
+// stripProfileOptions drops the options of the profiles so they can be compared
+func stripProfileOptions(profiles []ServerProfile) []ServerProfile {
+	for i := range profiles {
+		profiles[i].Options = nil
+	}
+	return profiles
+}
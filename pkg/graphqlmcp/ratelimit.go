@@ -0,0 +1,305 @@
+package graphqlmcp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// RateLimitCall describes a tool call for choosing the bucket a rate limit counts it against
+type RateLimitCall struct {
+	SessionID string
+	Principal *Principal
+	ToolName  string
+	Header    http.Header
+}
+
+// RateLimitKeyFunc returns the bucket key of a call; calls with the same key share a bucket
+type RateLimitKeyFunc func(call *RateLimitCall) string
+
+// RateLimitBySession gives every MCP session its own bucket
+func RateLimitBySession(call *RateLimitCall) string {
+	return call.SessionID
+}
+
+// RateLimitByPrincipal gives every authenticated caller its own bucket
+// Anonymous callers share a single bucket
+func RateLimitByPrincipal(call *RateLimitCall) string {
+	if call.Principal == nil {
+		return ""
+	}
+	return call.Principal.Subject
+}
+
+// RateLimitByTool gives every tool its own bucket, shared by all callers
+func RateLimitByTool(call *RateLimitCall) string {
+	return call.ToolName
+}
+
+// RateLimitBySessionAndTool gives every tool its own bucket in each MCP session
+func RateLimitBySessionAndTool(call *RateLimitCall) string {
+	return call.SessionID + "\x00" + call.ToolName
+}
+
+// RateLimit is a token-bucket limit on tool calls
+type RateLimit struct {
+	// Name identifies the limit in errors and logs, e.g. "session"
+	Name string
+	// Key selects the bucket of a call; nil counts every call against one global bucket
+	Key RateLimitKeyFunc
+	// Requests calls are allowed per Per duration on average
+	Requests int
+	Per      time.Duration
+	// Burst is how many calls may be made at once; defaults to Requests
+	Burst int
+}
+
+// ConcurrencyLimit bounds the number of GraphQL requests in flight across all sessions
+type ConcurrencyLimit struct {
+	// MaxInFlight is the number of upstream requests that may run at once; 0 means unlimited
+	MaxInFlight int
+	// MaxQueued is the number of calls that may wait for a free slot; 0 means unlimited
+	MaxQueued int
+	// QueueTimeout is how long a call waits for a free slot; 0 fails immediately when all slots are busy
+	QueueTimeout time.Duration
+}
+
+// RateLimitError reports a call that was rejected by a rate or concurrency limit
+type RateLimitError struct {
+	// Limit is the name of the exceeded limit
+	Limit string
+	// RetryAfter is how long the caller should wait before trying again
+	RetryAfter time.Duration
+	reason     string
+}
+
+// Error describes the exceeded limit and when to retry
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s; retry after %s", e.reason, e.RetryAfter.Round(time.Millisecond))
+}
+
+// retryAfterSeconds rounds the retry delay up to whole seconds, as in the Retry-After header
+func (e *RateLimitError) retryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// rateLimitedResult turns a rate limit error into a tool error with a retry hint in _meta
+func rateLimitedResult(err *RateLimitError) *mcp.CallToolResult {
+	result := toolErrorResult(fmt.Sprintf("Rate limit exceeded: %v. Wait before calling this tool again.", err))
+	result.Meta = map[string]interface{}{
+		"rateLimit":         err.Limit,
+		"retryAfterSeconds": err.retryAfterSeconds(),
+	}
+	return result
+}
+
+// tokenBucket holds the tokens left for one rate limit key
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter enforces a RateLimit with one token bucket per key
+type rateLimiter struct {
+	limit    RateLimit
+	rate     float64 // tokens per second
+	capacity float64
+	now      func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// newRateLimiter creates a rate limiter, or returns an error for an invalid limit
+func newRateLimiter(limit RateLimit) (*rateLimiter, error) {
+	if limit.Requests <= 0 || limit.Per <= 0 {
+		return nil, fmt.Errorf("rate limit %q must allow a positive number of requests per positive duration", limit.Name)
+	}
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = limit.Requests
+	}
+	return &rateLimiter{
+		limit:    limit,
+		rate:     float64(limit.Requests) / limit.Per.Seconds(),
+		capacity: float64(burst),
+		now:      time.Now,
+		buckets:  make(map[string]*tokenBucket),
+	}, nil
+}
+
+// take removes a token from the bucket of a call, or reports how long until one is available
+func (l *rateLimiter) take(call *RateLimitCall) (string, bool, time.Duration) {
+	key := ""
+	if l.limit.Key != nil {
+		key = l.limit.Key(call)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.capacity, last: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(l.capacity, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+		return key, false, wait
+	}
+	bucket.tokens--
+	return key, true, 0
+}
+
+// refund returns a token taken for a call that another limit rejected
+func (l *rateLimiter) refund(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if bucket, ok := l.buckets[key]; ok {
+		bucket.tokens = math.Min(l.capacity, bucket.tokens+1)
+	}
+}
+
+// sweep drops buckets that have refilled completely, as they are equivalent to new ones
+func (l *rateLimiter) sweep(now time.Time) {
+	refill := time.Duration(l.capacity / l.rate * float64(time.Second))
+	if now.Sub(l.lastSweep) < refill {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// concurrencyLimiter bounds the number of upstream requests in flight
+type concurrencyLimiter struct {
+	limit ConcurrencyLimit
+	slots chan struct{}
+
+	mu     sync.Mutex
+	queued int
+}
+
+// newConcurrencyLimiter creates a concurrency limiter, or nil when the limit is disabled
+func newConcurrencyLimiter(limit ConcurrencyLimit) *concurrencyLimiter {
+	if limit.MaxInFlight <= 0 {
+		return nil
+	}
+	return &concurrencyLimiter{limit: limit, slots: make(chan struct{}, limit.MaxInFlight)}
+}
+
+// acquire waits for a free slot and returns the function that releases it
+func (c *concurrencyLimiter) acquire(ctx context.Context) (func(), error) {
+	release := func() { <-c.slots }
+
+	select {
+	case c.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	c.mu.Lock()
+	if c.limit.QueueTimeout <= 0 || (c.limit.MaxQueued > 0 && c.queued >= c.limit.MaxQueued) {
+		c.mu.Unlock()
+		return nil, c.busyError()
+	}
+	c.queued++
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.queued--
+		c.mu.Unlock()
+	}()
+
+	timer := time.NewTimer(c.limit.QueueTimeout)
+	defer timer.Stop()
+	select {
+	case c.slots <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, c.busyError()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// busyError reports that no slot became free in time
+func (c *concurrencyLimiter) busyError() *RateLimitError {
+	retryAfter := c.limit.QueueTimeout
+	if retryAfter <= 0 {
+		retryAfter = time.Second
+	}
+	return &RateLimitError{
+		Limit:      "concurrency",
+		RetryAfter: retryAfter,
+		reason:     fmt.Sprintf("the server already has %d GraphQL requests in flight", c.limit.MaxInFlight),
+	}
+}
+
+// newRateLimiters creates the rate limiters configured in the options
+func newRateLimiters(limits []RateLimit) ([]*rateLimiter, error) {
+	limiters := make([]*rateLimiter, 0, len(limits))
+	for _, limit := range limits {
+		limiter, err := newRateLimiter(limit)
+		if err != nil {
+			return nil, err
+		}
+		limiters = append(limiters, limiter)
+	}
+	return limiters, nil
+}
+
+// checkRateLimits takes a token from every configured rate limit for a tool call
+// Either all limits allow the call, or none of them is charged for it
+func (s *MCPGraphQLServer) checkRateLimits(ctx context.Context, req *mcp.CallToolRequest) *RateLimitError {
+	if len(s.rateLimiters) == 0 {
+		return nil
+	}
+
+	call := &RateLimitCall{ToolName: req.Params.Name, Principal: PrincipalFromContext(ctx)}
+	if req.Session != nil {
+		call.SessionID = req.Session.ID()
+	}
+	if req.Extra != nil {
+		call.Header = req.Extra.Header
+	}
+
+	taken := make([]string, 0, len(s.rateLimiters))
+	for _, limiter := range s.rateLimiters {
+		key, ok, retryAfter := limiter.take(call)
+		if ok {
+			taken = append(taken, key)
+			continue
+		}
+		for j, key := range taken {
+			s.rateLimiters[j].refund(key)
+		}
+		s.logger.Info("Tool call rejected by rate limit",
+			"tool_name", call.ToolName,
+			"session_id", call.SessionID,
+			"rate_limit", limiter.limit.Name,
+			"retry_after_ms", retryAfter.Milliseconds(),
+		)
+		return &RateLimitError{
+			Limit:      limiter.limit.Name,
+			RetryAfter: retryAfter,
+			reason:     fmt.Sprintf("rate limit %q allows %d calls per %s", limiter.limit.Name, limiter.limit.Requests, limiter.limit.Per),
+		}
+	}
+	return nil
+}
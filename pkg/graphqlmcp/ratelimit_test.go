@@ -0,0 +1,140 @@
+package graphqlmcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter, err := newRateLimiter(RateLimit{Name: "tool", Key: RateLimitByTool, Requests: 2, Per: time.Second})
+	require.NoError(t, err)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	equipment := &RateLimitCall{ToolName: "query_equipment"}
+	facilities := &RateLimitCall{ToolName: "query_facilities"}
+
+	for i := 0; i < 2; i++ {
+		_, ok, _ := limiter.take(equipment)
+		assert.True(t, ok, "call %d should fit in the burst", i)
+	}
+	_, ok, retryAfter := limiter.take(equipment)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// Other keys have their own bucket
+	_, ok, _ = limiter.take(facilities)
+	assert.True(t, ok)
+
+	// Tokens refill at the configured rate
+	now = now.Add(500 * time.Millisecond)
+	_, ok, _ = limiter.take(equipment)
+	assert.True(t, ok)
+	_, ok, _ = limiter.take(equipment)
+	assert.False(t, ok)
+
+	// Idle buckets are dropped once they are full again
+	now = now.Add(time.Minute)
+	_, ok, _ = limiter.take(equipment)
+	assert.True(t, ok)
+	assert.Len(t, limiter.buckets, 1)
+
+	_, err = newRateLimiter(RateLimit{Name: "broken", Requests: 0, Per: time.Second})
+	assert.ErrorContains(t, err, `rate limit "broken" must allow a positive number of requests`)
+}
+
+func TestMCPGraphQLServer_RateLimits(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"equipment": []interface{}{}}}, nil)
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor,
+		WithRateLimit(RateLimit{Name: "global", Requests: 10, Per: time.Hour}),
+		WithRateLimit(RateLimit{Name: "tool", Key: RateLimitByTool, Requests: 1, Per: time.Minute}),
+	)
+	require.NoError(t, err)
+
+	callTool := func(fieldName string) *mcp.CallToolResult {
+		field := findField(t, testSchema.GetQueries(), fieldName)
+		result, err := server.newToolHandler(field, "query")(context.Background(), &mcp.CallToolRequest{
+			Params: &mcp.CallToolParamsRaw{Name: "query_" + fieldName, Arguments: json.RawMessage(`{}`)},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	assert.False(t, callTool("equipment").IsError)
+
+	result := callTool("equipment")
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, `Rate limit exceeded: rate limit "tool" allows 1 calls per 1m0s; retry after`)
+	assert.Equal(t, "tool", result.Meta["rateLimit"])
+	assert.Equal(t, 60, result.Meta["retryAfterSeconds"])
+	mockExecutor.AssertNumberOfCalls(t, "ExecuteQuery", 1)
+
+	// The rejected call was not charged to the global limit
+	global := server.rateLimiters[0].buckets[""]
+	assert.InDelta(t, 9, global.tokens, 0.01)
+
+	assert.False(t, callTool("facilities").IsError)
+
+	_, err = NewMCPGraphQLServerWithExecutor(mockExecutor, WithRateLimit(RateLimit{Name: "session", Per: time.Second}))
+	assert.ErrorContains(t, err, `rate limit "session" must allow a positive number of requests`)
+}
+
+func TestMCPGraphQLServer_ConcurrencyLimit(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+		Run(func(mock.Arguments) {
+			close(started)
+			<-unblock
+		}).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"equipment": []interface{}{}}}, nil).Once()
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"equipment": []interface{}{}}}, nil)
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor,
+		WithConcurrencyLimit(ConcurrencyLimit{MaxInFlight: 1, QueueTimeout: 20 * time.Millisecond}))
+	require.NoError(t, err)
+
+	handler := server.newToolHandler(findField(t, testSchema.GetQueries(), "equipment"), "query")
+	callTool := func() *mcp.CallToolResult {
+		result, err := handler(context.Background(), &mcp.CallToolRequest{
+			Params: &mcp.CallToolParamsRaw{Name: "query_equipment", Arguments: json.RawMessage(`{}`)},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	done := make(chan *mcp.CallToolResult)
+	go func() { done <- callTool() }()
+	<-started
+
+	// The only slot is taken, so the queued call times out with a retry hint
+	result := callTool()
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "the server already has 1 GraphQL requests in flight; retry after 20ms")
+	assert.Equal(t, "concurrency", result.Meta["rateLimit"])
+	assert.Equal(t, 1, result.Meta["retryAfterSeconds"])
+
+	close(unblock)
+	assert.False(t, (<-done).IsError)
+
+	// The slot is released after the call completes
+	assert.False(t, callTool().IsError)
+}
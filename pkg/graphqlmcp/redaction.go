@@ -0,0 +1,333 @@
+package graphqlmcp
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/peterbeamish/go-mcp-graphql/pkg/graphqlmcp/schema"
+)
+
+// DefaultRedactionReplacement replaces redacted values unless a rule sets its own replacement
+const DefaultRedactionReplacement = "[REDACTED]"
+
+// RedactionAction is what a redaction rule does with a matching value
+type RedactionAction int
+
+const (
+	// RedactMask replaces the value, or the matching part of a string for value pattern rules
+	RedactMask RedactionAction = iota
+	// RedactDrop removes the field; Type.field rules also leave it out of generated selection sets
+	RedactDrop
+)
+
+// RedactionRule keeps sensitive values out of tool results
+// Exactly one of Field, Path and ValuePattern selects the values
+type RedactionRule struct {
+	// Field selects a field of a type, e.g. "Personnel.email"
+	Field string
+	// Path selects values by their path in the response data, e.g. "personnel[*].contact.phone"
+	// List indices are ignored, so "[*]" is optional; "*" matches any single field name
+	Path string
+	// ValuePattern selects the parts of string values that match a regular expression
+	ValuePattern string
+
+	// Action is RedactMask or RedactDrop; value pattern rules can only mask
+	Action RedactionAction
+	// Replacement is the masked value; defaults to DefaultRedactionReplacement
+	Replacement string
+}
+
+// RedactField masks or drops a field of a type, given as "Type.field"
+func RedactField(typeField string, action RedactionAction) RedactionRule {
+	return RedactionRule{Field: typeField, Action: action}
+}
+
+// RedactPath masks or drops the values at a path in the response data
+func RedactPath(path string, action RedactionAction) RedactionRule {
+	return RedactionRule{Path: path, Action: action}
+}
+
+// RedactValues masks the parts of string values that match a regular expression
+func RedactValues(pattern string) RedactionRule {
+	return RedactionRule{ValuePattern: pattern}
+}
+
+// String describes the rule for errors and logs
+func (r RedactionRule) String() string {
+	switch {
+	case r.Field != "":
+		return "field " + r.Field
+	case r.Path != "":
+		return "path " + r.Path
+	}
+	return "pattern " + r.ValuePattern
+}
+
+// compiledRedactionRule is a validated redaction rule
+type compiledRedactionRule struct {
+	RedactionRule
+	path    []string
+	pattern *regexp.Regexp
+}
+
+// replacement returns the masked value of the rule
+func (r *compiledRedactionRule) replacement() string {
+	if r.Replacement != "" {
+		return r.Replacement
+	}
+	return DefaultRedactionReplacement
+}
+
+// matchesPath checks a response path, without list indices, against the rule path
+func (r *compiledRedactionRule) matchesPath(path []string) bool {
+	if len(path) != len(r.path) {
+		return false
+	}
+	for i, segment := range r.path {
+		if segment != "*" && segment != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// redactionRules holds the validated redaction rules by kind
+type redactionRules struct {
+	fields map[string]*compiledRedactionRule
+	paths  []*compiledRedactionRule
+	values []*compiledRedactionRule
+}
+
+// compileRedactionRules validates the redaction rules; Type.field rules are checked against the schema
+func compileRedactionRules(rules []RedactionRule, s *schema.Schema) (*redactionRules, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	compiled := &redactionRules{fields: make(map[string]*compiledRedactionRule)}
+	for _, rule := range rules {
+		c := &compiledRedactionRule{RedactionRule: rule}
+		selectors := 0
+		for _, set := range []bool{rule.Field != "", rule.Path != "", rule.ValuePattern != ""} {
+			if set {
+				selectors++
+			}
+		}
+		if selectors != 1 {
+			return nil, errors.New("invalid redaction rule: exactly one of Field, Path and ValuePattern must be set")
+		}
+
+		switch {
+		case rule.Field != "":
+			typeName, fieldName, ok := strings.Cut(rule.Field, ".")
+			if !ok || typeName == "" || fieldName == "" {
+				return nil, fmt.Errorf("invalid redaction rule %s: expected Type.field", rule)
+			}
+			if s != nil {
+				typeDef := s.GetTypeDefinition(typeName)
+				if typeDef == nil {
+					return nil, fmt.Errorf("invalid redaction rule %s: the schema has no type %s", rule, typeName)
+				}
+				if typeDef.Fields.ForName(fieldName) == nil {
+					return nil, fmt.Errorf("invalid redaction rule %s: %s has no field %s", rule, typeName, fieldName)
+				}
+			}
+			compiled.fields[rule.Field] = c
+		case rule.Path != "":
+			path := strings.TrimPrefix(strings.TrimPrefix(rule.Path, "$"), ".")
+			for _, segment := range strings.Split(path, ".") {
+				segment = strings.TrimSuffix(segment, "[*]")
+				if segment == "" || strings.ContainsAny(segment, "[]") {
+					return nil, fmt.Errorf("invalid redaction rule %s: expected field names separated by dots", rule)
+				}
+				c.path = append(c.path, segment)
+			}
+			compiled.paths = append(compiled.paths, c)
+		default:
+			if rule.Action != RedactMask {
+				return nil, fmt.Errorf("invalid redaction rule %s: value pattern rules can only mask", rule)
+			}
+			pattern, err := regexp.Compile(rule.ValuePattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid redaction rule %s: %w", rule, err)
+			}
+			c.pattern = pattern
+			compiled.values = append(compiled.values, c)
+		}
+	}
+	return compiled, nil
+}
+
+// excludedFields returns the fields that drop rules leave out of generated selection sets
+func (r *redactionRules) excludedFields() map[string]bool {
+	if r == nil {
+		return nil
+	}
+	excluded := make(map[string]bool)
+	for key, rule := range r.fields {
+		if rule.Action == RedactDrop {
+			excluded[key] = true
+		}
+	}
+	return excluded
+}
+
+// redactor applies redaction rules to one response and counts the redacted values
+type redactor struct {
+	rules  *redactionRules
+	schema *schema.Schema
+	count  int
+}
+
+// redactResponse applies the redaction rules to response data in place and returns the number
+// of redacted values. rootType is the name of the Query or Mutation type
+func (s *MCPGraphQLServer) redactResponse(rootType string, data interface{}) int {
+	if s.redaction == nil {
+		return 0
+	}
+	r := &redactor{rules: s.redaction, schema: s.Schema}
+	r.walk(data, rootType, nil)
+	return r.count
+}
+
+// walk redacts a value of a GraphQL type at a response path and returns the redacted value
+func (r *redactor) walk(value interface{}, typeName string, path []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		// Abstract types are resolved through __typename, which generated operations select
+		declared := typeName
+		if concrete, ok := v["__typename"].(string); ok {
+			typeName = concrete
+		}
+		ruleTypes := r.fieldRuleTypes(declared, typeName)
+		for key, child := range v {
+			fieldName := strings.TrimPrefix(key, typeName+"_") // aliases of conflicting union fields
+			childPath := append(path[:len(path):len(path)], key)
+
+			if rule := r.ruleFor(ruleTypes, fieldName, childPath); rule != nil {
+				if child == nil {
+					continue
+				}
+				r.count++
+				if rule.Action == RedactDrop {
+					delete(v, key)
+				} else {
+					v[key] = rule.replacement()
+				}
+				continue
+			}
+			v[key] = r.walk(child, r.fieldType(typeName, fieldName), childPath)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.walk(item, typeName, path)
+		}
+		return v
+	case string:
+		return r.redactString(v)
+	}
+	return value
+}
+
+// redactErrors applies the value pattern rules to the messages, paths and extensions of the
+// GraphQL errors and to the extensions of a response in place, and returns the number of
+// redacted values. Errors can repeat argument or data values, such as "no user ops@example.com"
+func (s *MCPGraphQLServer) redactErrors(resp *GraphQLResponse) int {
+	if s.redaction == nil || len(s.redaction.values) == 0 {
+		return 0
+	}
+	r := &redactor{rules: s.redaction, schema: s.Schema}
+	for i := range resp.Errors {
+		graphQLError := &resp.Errors[i]
+		graphQLError.Message = r.redactString(graphQLError.Message)
+		r.redactStrings(graphQLError.Path)
+		r.redactStrings(graphQLError.Extensions)
+	}
+	r.redactStrings(resp.Extensions)
+	return r.count
+}
+
+// redactStrings applies the value pattern rules to every string in a value, in place
+func (r *redactor) redactStrings(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = r.redactStrings(child)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.redactStrings(item)
+		}
+	case string:
+		return r.redactString(v)
+	}
+	return value
+}
+
+// redactString applies the value pattern rules to a string
+func (r *redactor) redactString(value string) string {
+	redacted := value
+	for _, rule := range r.rules.values {
+		redacted = rule.pattern.ReplaceAllString(redacted, rule.replacement())
+	}
+	if redacted != value {
+		r.count++
+	}
+	return redacted
+}
+
+// fieldRuleTypes returns the types whose Type.field rules apply to the fields of an object: its
+// concrete type, the type it was selected as and the interfaces the concrete type implements
+func (r *redactor) fieldRuleTypes(declared, concrete string) []string {
+	types := []string{concrete}
+	if declared != "" && declared != concrete {
+		types = append(types, declared)
+	}
+	if r.schema == nil {
+		return types
+	}
+	if typeDef := r.schema.GetTypeDefinition(concrete); typeDef != nil {
+		for _, iface := range typeDef.Interfaces {
+			if !slices.Contains(types, iface) {
+				types = append(types, iface)
+			}
+		}
+	}
+	return types
+}
+
+// ruleFor returns the Type.field or path rule that applies to a field of an object with the
+// given rule types, or nil
+func (r *redactor) ruleFor(typeNames []string, fieldName string, path []string) *compiledRedactionRule {
+	for _, typeName := range typeNames {
+		if rule, ok := r.rules.fields[typeName+"."+fieldName]; ok {
+			return rule
+		}
+	}
+	for _, rule := range r.rules.paths {
+		if rule.matchesPath(path) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// fieldType returns the named type of a field, or "" when it is unknown
+func (r *redactor) fieldType(typeName, fieldName string) string {
+	if r.schema == nil || typeName == "" {
+		return ""
+	}
+	typeDef := r.schema.GetTypeDefinition(typeName)
+	if typeDef == nil {
+		return ""
+	}
+	field := typeDef.Fields.ForName(fieldName)
+	if field == nil {
+		return ""
+	}
+	return schema.GetASTTypeName(field.Type)
+}
@@ -0,0 +1,188 @@
+package graphqlmcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/peterbeamish/go-mcp-graphql/pkg/graphqlmcp/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileRedactionRules_Invalid(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	tests := []struct {
+		name     string
+		rule     RedactionRule
+		expected string
+	}{
+		{name: "no selector", rule: RedactionRule{}, expected: "exactly one of Field, Path and ValuePattern must be set"},
+		{name: "several selectors", rule: RedactionRule{Field: "ContactInfo.email", Path: "a.b"}, expected: "exactly one of Field, Path and ValuePattern must be set"},
+		{name: "malformed field", rule: RedactField("email", RedactMask), expected: "invalid redaction rule field email: expected Type.field"},
+		{name: "unknown type", rule: RedactField("Personnel.email", RedactMask), expected: "the schema has no type Personnel"},
+		{name: "unknown field", rule: RedactField("ContactInfo.fax", RedactMask), expected: "ContactInfo has no field fax"},
+		{name: "malformed path", rule: RedactPath("facilities[0].contactInfo", RedactMask), expected: "expected field names separated by dots"},
+		{name: "dropping values", rule: RedactionRule{ValuePattern: "x", Action: RedactDrop}, expected: "value pattern rules can only mask"},
+		{name: "invalid pattern", rule: RedactValues("("), expected: "invalid redaction rule pattern ("},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileRedactionRules([]RedactionRule{tt.rule}, testSchema)
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestMCPGraphQLServer_Redaction(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	response := map[string]interface{}{
+		"facilities": []interface{}{
+			map[string]interface{}{
+				"id":   "fac-1",
+				"name": "Plant 1, call 555-0100 for access",
+				"contactInfo": map[string]interface{}{
+					"phone":          "555-0101",
+					"email":          "ops@example.com",
+					"emergencyPhone": "555-0199",
+					"managerContact": nil,
+				},
+				"location": map[string]interface{}{"latitude": 1.5, "longitude": 2.5},
+			},
+			map[string]interface{}{
+				"id":          "fac-2",
+				"name":        "Plant 2",
+				"contactInfo": map[string]interface{}{"phone": "555-0201", "emergencyPhone": "555-0299"},
+				"location":    map[string]interface{}{"latitude": 3.5, "longitude": 4.5},
+			},
+		},
+	}
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+		Return(&GraphQLResponse{Data: response}, nil)
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor, WithRedaction(
+		RedactField("ContactInfo.email", RedactDrop),
+		RedactField("ContactInfo.phone", RedactMask),
+		RedactPath("$.facilities[*].contactInfo.emergencyPhone", RedactDrop),
+		RedactPath("facilities.location.*", RedactMask),
+		RedactionRule{ValuePattern: `\d{3}-\d{4}`, Replacement: "###-####"},
+	))
+	require.NoError(t, err)
+
+	// Dropped Type.field rules are left out of the generated selection set
+	facilities := findField(t, testSchema.GetQueries(), "facilities")
+	query, err := facilities.GenerateQueryStringWithSchema(server.GetSchema())
+	require.NoError(t, err)
+	assert.NotContains(t, query, "email")
+	assert.Contains(t, query, "phone")
+
+	result, err := server.newToolHandler(facilities, "query")(context.Background(), &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{Name: "query_facilities", Arguments: json.RawMessage(`{}`)},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &data))
+	first := data["facilities"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "Plant 1, call ###-#### for access", first["name"])
+	assert.Equal(t, map[string]interface{}{"phone": "[REDACTED]", "managerContact": nil}, first["contactInfo"])
+	assert.Equal(t, map[string]interface{}{"latitude": "[REDACTED]", "longitude": "[REDACTED]"}, first["location"])
+
+	// 1 name, 2 phones, 1 email, 2 emergency phones and 4 coordinates
+	assert.Equal(t, 10, result.Meta["redactedValues"])
+	assert.Equal(t, "10 values were redacted by the server's redaction rules.", result.Content[len(result.Content)-1].(*mcp.TextContent).Text)
+}
+
+func TestMCPGraphQLServer_RedactionErrors(t *testing.T) {
+	testSchema := loadTestSchema(t)
+	facilities := findField(t, testSchema.GetQueries(), "facilities")
+
+	tests := []struct {
+		name    string
+		data    interface{}
+		isError bool
+	}{
+		{name: "errors only", isError: true},
+		{name: "partial data", data: map[string]interface{}{"facilities": []interface{}{map[string]interface{}{"id": "fac-1"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockExecutor := new(MockGraphQLExecutor)
+			mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+			mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+				Return(&GraphQLResponse{
+					Data: tt.data,
+					Errors: []GraphQLError{{
+						Message:    "No manager with email ops@example.com",
+						Path:       []interface{}{"facilities", 0, "ops@example.com"},
+						Extensions: map[string]interface{}{"code": "NOT_FOUND", "lookup": map[string]interface{}{"email": "ops@example.com"}},
+					}},
+					Extensions: map[string]interface{}{"trace": []interface{}{"resolved ops@example.com"}},
+				}, nil)
+
+			server, err := NewMCPGraphQLServerWithExecutor(mockExecutor, WithRedaction(RedactValues(`[\w.]+@example\.com`)))
+			require.NoError(t, err)
+
+			result, err := server.newToolHandler(facilities, "query")(context.Background(), &mcp.CallToolRequest{
+				Params: &mcp.CallToolParamsRaw{Name: "query_facilities", Arguments: json.RawMessage(`{}`)},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.isError, result.IsError)
+
+			// Neither the content nor the metadata of the result repeats the email address
+			for _, content := range result.Content {
+				assert.NotContains(t, content.(*mcp.TextContent).Text, "ops@example.com")
+			}
+			meta, err := json.Marshal(result.Meta)
+			require.NoError(t, err)
+			assert.NotContains(t, string(meta), "ops@example.com")
+			assert.Contains(t, string(meta), "No manager with email [REDACTED]")
+			assert.Contains(t, string(meta), `"code":"NOT_FOUND"`)
+		})
+	}
+}
+
+func TestMCPGraphQLServer_RedactionInterface(t *testing.T) {
+	testSchema, err := schema.ParseSDL(`
+		interface Contact { name: String email: String }
+		type Person implements Contact { name: String email: String phone: String }
+		type Query { contact: Contact person: Person }
+	`)
+	require.NoError(t, err)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+		Return(&GraphQLResponse{Data: map[string]interface{}{
+			"contact": map[string]interface{}{"__typename": "Person", "name": "Alice", "email": "a@b.c"},
+			"person":  map[string]interface{}{"name": "Bob", "email": "b@b.c", "phone": "555-0100"},
+		}}, nil)
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor, WithRedaction(RedactField("Contact.email", RedactMask)))
+	require.NoError(t, err)
+
+	// Rules naming an interface apply to objects resolved through __typename and to the types
+	// implementing it
+	for _, name := range []string{"contact", "person"} {
+		field := findField(t, server.GetSchema().GetQueries(), name)
+		result, err := server.newToolHandler(field, "query")(context.Background(), &mcp.CallToolRequest{
+			Params: &mcp.CallToolParamsRaw{Name: "query_" + name, Arguments: json.RawMessage(`{}`)},
+		})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		var data map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &data))
+		assert.Equal(t, "[REDACTED]", data[name].(map[string]interface{})["email"], name)
+	}
+}
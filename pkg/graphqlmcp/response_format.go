@@ -0,0 +1,538 @@
+package graphqlmcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ResponseFormatter renders the data of a GraphQL response as tool result text
+type ResponseFormatter interface {
+	Format(data interface{}) (string, error)
+}
+
+// ResponseFormatterFunc adapts an ordinary function to the ResponseFormatter interface
+type ResponseFormatterFunc func(data interface{}) (string, error)
+
+// Format calls f(data)
+func (f ResponseFormatterFunc) Format(data interface{}) (string, error) {
+	return f(data)
+}
+
+// JSONFormatter renders response data as JSON
+// An empty Indent produces compact JSON
+type JSONFormatter struct {
+	Indent string
+}
+
+// Format renders data as JSON
+func (f JSONFormatter) Format(data interface{}) (string, error) {
+	var (
+		out []byte
+		err error
+	)
+	if f.Indent == "" {
+		out, err = json.Marshal(data)
+	} else {
+		out, err = json.MarshalIndent(data, "", f.Indent)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// NewIndentedJSONFormatter returns the default formatter, which renders two-space indented JSON
+func NewIndentedJSONFormatter() ResponseFormatter {
+	return JSONFormatter{Indent: "  "}
+}
+
+// NewCompactJSONFormatter returns a formatter that renders JSON without insignificant whitespace
+func NewCompactJSONFormatter() ResponseFormatter {
+	return JSONFormatter{}
+}
+
+// YAMLFormatter renders response data as YAML-like indented text
+// Object keys are sorted so the output is deterministic
+type YAMLFormatter struct{}
+
+// NewYAMLFormatter returns a formatter that renders YAML-like text
+func NewYAMLFormatter() ResponseFormatter {
+	return YAMLFormatter{}
+}
+
+// Format renders data as YAML-like text
+func (f YAMLFormatter) Format(data interface{}) (string, error) {
+	var sb strings.Builder
+	writeYAMLValue(&sb, normalizeResponseData(data), 0)
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
+
+// MarkdownTableFormatter renders lists of flat objects as Markdown tables
+// Values that are not lists of flat objects are rendered with the Fallback formatter
+type MarkdownTableFormatter struct {
+	// Fallback renders sections that cannot be shown as a table (defaults to YAMLFormatter)
+	Fallback ResponseFormatter
+}
+
+// NewMarkdownTableFormatter returns a formatter that renders lists of flat objects as Markdown tables
+func NewMarkdownTableFormatter() ResponseFormatter {
+	return MarkdownTableFormatter{Fallback: YAMLFormatter{}}
+}
+
+// Format renders data as Markdown
+func (f MarkdownTableFormatter) Format(data interface{}) (string, error) {
+	fallback := f.Fallback
+	if fallback == nil {
+		fallback = YAMLFormatter{}
+	}
+
+	data = normalizeResponseData(data)
+	root, ok := data.(map[string]interface{})
+	if !ok {
+		return f.formatSection(data, fallback)
+	}
+
+	var sb strings.Builder
+	for i, key := range sortedKeys(root) {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		section, err := f.formatSection(root[key], fallback)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString("## ")
+		sb.WriteString(key)
+		sb.WriteString("\n\n")
+		sb.WriteString(section)
+	}
+	return sb.String(), nil
+}
+
+// formatSection renders a single top-level value as a table or with the fallback formatter
+func (f MarkdownTableFormatter) formatSection(value interface{}, fallback ResponseFormatter) (string, error) {
+	if rows, ok := flatObjectList(value); ok {
+		return markdownTable(rows), nil
+	}
+	return fallback.Format(value)
+}
+
+// flatObjectList reports whether value is a non-empty list of objects whose values are all scalars
+func flatObjectList(value interface{}) ([]map[string]interface{}, bool) {
+	list, ok := value.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, false
+	}
+
+	rows := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		for _, v := range obj {
+			switch v.(type) {
+			case map[string]interface{}, []interface{}:
+				return nil, false
+			}
+		}
+		rows = append(rows, obj)
+	}
+	return rows, true
+}
+
+// markdownTable renders rows as a Markdown table whose columns are the union of all keys
+func markdownTable(rows []map[string]interface{}) string {
+	columnSet := make(map[string]bool)
+	for _, row := range rows {
+		for key := range row {
+			columnSet[key] = true
+		}
+	}
+	columns := sortedKeys(columnSet)
+
+	var sb strings.Builder
+	sb.WriteString("| " + strings.Join(columns, " | ") + " |\n")
+	sb.WriteString("|" + strings.Repeat(" --- |", len(columns)) + "\n")
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			if value, ok := row[column]; ok && value != nil {
+				cells[i] = markdownCell(formatScalar(value))
+			}
+		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// markdownCell escapes characters that would break a Markdown table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// writeYAMLValue writes a value at the given indentation level
+func writeYAMLValue(sb *strings.Builder, value interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			sb.WriteString(pad + "{}\n")
+			return
+		}
+		for _, key := range sortedKeys(v) {
+			writeYAMLEntry(sb, pad+yamlString(key)+":", v[key], indent)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			sb.WriteString(pad + "[]\n")
+			return
+		}
+		for _, item := range v {
+			writeYAMLListItem(sb, item, indent)
+		}
+	default:
+		sb.WriteString(pad + yamlScalar(v) + "\n")
+	}
+}
+
+// writeYAMLEntry writes "key: value", placing nested collections on the following lines
+func writeYAMLEntry(sb *strings.Builder, prefix string, value interface{}, indent int) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			sb.WriteString(prefix + " {}\n")
+			return
+		}
+		sb.WriteString(prefix + "\n")
+		writeYAMLValue(sb, v, indent+1)
+	case []interface{}:
+		if len(v) == 0 {
+			sb.WriteString(prefix + " []\n")
+			return
+		}
+		sb.WriteString(prefix + "\n")
+		writeYAMLValue(sb, v, indent+1)
+	default:
+		sb.WriteString(prefix + " " + yamlScalar(v) + "\n")
+	}
+}
+
+// writeYAMLListItem writes a "- " list item, inlining the first key of objects
+func writeYAMLListItem(sb *strings.Builder, item interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+	obj, ok := item.(map[string]interface{})
+	if !ok || len(obj) == 0 {
+		switch v := item.(type) {
+		case []interface{}:
+			if len(v) == 0 {
+				sb.WriteString(pad + "- []\n")
+				return
+			}
+			sb.WriteString(pad + "-\n")
+			writeYAMLValue(sb, v, indent+1)
+		case map[string]interface{}:
+			sb.WriteString(pad + "- {}\n")
+		default:
+			sb.WriteString(pad + "- " + yamlScalar(v) + "\n")
+		}
+		return
+	}
+
+	for i, key := range sortedKeys(obj) {
+		prefix := pad + "  " + yamlString(key) + ":"
+		if i == 0 {
+			prefix = pad + "- " + yamlString(key) + ":"
+		}
+		writeYAMLEntry(sb, prefix, obj[key], indent+1)
+	}
+}
+
+// yamlScalar renders a scalar value
+func yamlScalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return yamlString(v)
+	default:
+		return formatScalar(v)
+	}
+}
+
+// yamlString quotes a string when it would otherwise be ambiguous
+func yamlString(s string) string {
+	if s == "" || strings.TrimSpace(s) != s || strings.ContainsAny(s, ":#\n\r\t\"'{}[],&*!|>%@`") {
+		quoted, _ := json.Marshal(s)
+		return string(quoted)
+	}
+	switch strings.ToLower(s) {
+	case "null", "true", "false", "yes", "no", "on", "off", "~":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "?") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// formatScalar renders a scalar without quoting
+func formatScalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// normalizeResponseData converts arbitrary Go values into the generic JSON shape
+// (maps, slices, strings, float64, bool and nil) so formatters can walk them
+func normalizeResponseData(data interface{}) interface{} {
+	switch data.(type) {
+	case nil, map[string]interface{}, []interface{}, string, float64, bool:
+		return data
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+	var generic interface{}
+	if err := json.Unmarshal(encoded, &generic); err != nil {
+		return data
+	}
+	return generic
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ResponseBudget limits the size of a formatted tool result
+// When both limits are set, the smaller one applies
+type ResponseBudget struct {
+	// MaxBytes is the maximum size of the formatted result in bytes (0 means unlimited)
+	MaxBytes int `yaml:"maxBytes"`
+	// MaxTokens is the maximum estimated number of tokens (0 means unlimited)
+	// Tokens are estimated at bytesPerToken bytes each
+	MaxTokens int `yaml:"maxTokens"`
+}
+
+// bytesPerToken is the rough number of bytes per model token used to estimate token budgets
+const bytesPerToken = 4
+
+// byteLimit returns the effective byte limit of the budget, or 0 if it is unlimited
+func (b ResponseBudget) byteLimit() int {
+	limit := b.MaxBytes
+	if b.MaxTokens > 0 {
+		tokenBytes := b.MaxTokens * bytesPerToken
+		if limit <= 0 || tokenBytes < limit {
+			limit = tokenBytes
+		}
+	}
+	if limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// describe renders the budget for truncation notes
+func (b ResponseBudget) describe() string {
+	if b.MaxTokens > 0 && (b.MaxBytes <= 0 || b.MaxTokens*bytesPerToken < b.MaxBytes) {
+		return fmt.Sprintf("~%d-token", b.MaxTokens)
+	}
+	return fmt.Sprintf("%d-byte", b.MaxBytes)
+}
+
+// formattedResponse is the result of formatting response data within a budget
+type formattedResponse struct {
+	Text string
+	// Note explains what was left out, empty when nothing was truncated
+	Note string
+}
+
+// listTruncation records how many items of the lists at one path were kept
+type listTruncation struct {
+	total int
+	kept  int
+}
+
+// formatWithinBudget formats data and, if the result exceeds the budget, drops list items
+// until it fits, reporting what was left out and how to narrow the query
+func formatWithinBudget(data interface{}, formatter ResponseFormatter, budget ResponseBudget) (*formattedResponse, error) {
+	text, err := formatter.Format(data)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := budget.byteLimit()
+	if limit == 0 || len(text) <= limit {
+		return &formattedResponse{Text: text}, nil
+	}
+
+	data = normalizeResponseData(data)
+	longest := longestList(data)
+
+	// Binary search for the largest per-list item cap that still fits the budget
+	bestText := ""
+	var bestStats map[string]*listTruncation
+	low, high := 0, longest-1
+	for low <= high {
+		itemCap := (low + high) / 2
+		stats := make(map[string]*listTruncation)
+		candidate, err := formatter.Format(capLists(data, itemCap, "", stats))
+		if err != nil {
+			return nil, err
+		}
+		if len(candidate) <= limit {
+			bestText, bestStats = candidate, stats
+			low = itemCap + 1
+		} else {
+			high = itemCap - 1
+		}
+	}
+
+	if bestStats != nil {
+		return &formattedResponse{
+			Text: bestText,
+			Note: truncationNote(bestStats, budget),
+		}, nil
+	}
+
+	// Even without any list items the response is too large, so cut the text itself
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return &formattedResponse{
+		Text: text[:cut],
+		Note: fmt.Sprintf("[Response truncated: output was cut at %d of %d bytes to stay within the %s response budget. "+
+			"Narrow the query with more specific arguments to see the complete result.]", cut, len(text), budget.describe()),
+	}, nil
+}
+
+// longestList returns the length of the longest list anywhere in data
+func longestList(data interface{}) int {
+	longest := 0
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for _, child := range v {
+			if n := longestList(child); n > longest {
+				longest = n
+			}
+		}
+	case []interface{}:
+		longest = len(v)
+		for _, child := range v {
+			if n := longestList(child); n > longest {
+				longest = n
+			}
+		}
+	}
+	return longest
+}
+
+// capLists returns a copy of data in which every list holds at most limit items
+// Truncated lists are recorded in stats keyed by their path
+func capLists(data interface{}, limit int, path string, stats map[string]*listTruncation) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			out[key] = capLists(child, limit, childPath, stats)
+		}
+		return out
+	case []interface{}:
+		kept := v
+		if len(v) > limit {
+			kept = v[:limit]
+		}
+		if path == "" {
+			path = "(root)"
+		}
+		stat, ok := stats[path]
+		if !ok {
+			stat = &listTruncation{}
+			stats[path] = stat
+		}
+		stat.total += len(v)
+		stat.kept += len(kept)
+
+		out := make([]interface{}, len(kept))
+		for i, child := range kept {
+			out[i] = capLists(child, limit, path+"[]", stats)
+		}
+		// Lists inside the dropped items are left out entirely
+		for _, child := range v[len(kept):] {
+			countOmittedLists(child, path+"[]", stats)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// countOmittedLists records the lists in data as left out, at every level below path
+func countOmittedLists(data interface{}, path string, stats map[string]*listTruncation) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			countOmittedLists(child, path+"."+key, stats)
+		}
+	case []interface{}:
+		stat, ok := stats[path]
+		if !ok {
+			stat = &listTruncation{}
+			stats[path] = stat
+		}
+		stat.total += len(v)
+		for _, child := range v {
+			countOmittedLists(child, path+"[]", stats)
+		}
+	}
+}
+
+// truncationNote explains which lists were shortened and how to narrow the query
+// Items of nested lists count too, including those inside list items that were left out
+func truncationNote(stats map[string]*listTruncation, budget ResponseBudget) string {
+	var parts []string
+	omitted := 0
+	for _, path := range sortedKeys(stats) {
+		stat := stats[path]
+		if stat.kept == stat.total {
+			continue
+		}
+		omitted += stat.total - stat.kept
+		parts = append(parts, fmt.Sprintf("%s: showing %d of %d", path, stat.kept, stat.total))
+	}
+
+	return fmt.Sprintf("[Response truncated: %d list item(s) were left out to stay within the %s response budget (%s). "+
+		"To see the rest, narrow the query with filter or pagination arguments, or fetch individual items by ID.]",
+		omitted, budget.describe(), strings.Join(parts, "; "))
+}
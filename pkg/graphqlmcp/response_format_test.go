@@ -0,0 +1,187 @@
+package graphqlmcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/peterbeamish/go-mcp-graphql/pkg/graphqlmcp/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// equipmentListData builds a response shaped like the equipment query with n items
+func equipmentListData(n int) map[string]interface{} {
+	items := make([]interface{}, n)
+	for i := range items {
+		items[i] = map[string]interface{}{
+			"id":     fmt.Sprintf("eq-%d", i),
+			"name":   fmt.Sprintf("Equipment %d", i),
+			"status": "OPERATIONAL",
+		}
+	}
+	return map[string]interface{}{"equipment": items}
+}
+
+func TestResponseFormatters(t *testing.T) {
+	data := map[string]interface{}{
+		"equipment": []interface{}{
+			map[string]interface{}{"id": "1", "name": "Lathe | A", "efficiency": 0.5},
+			map[string]interface{}{"id": "2", "name": "Mill", "efficiency": 1.0},
+		},
+	}
+
+	t.Run("compact JSON", func(t *testing.T) {
+		text, err := NewCompactJSONFormatter().Format(data)
+		require.NoError(t, err)
+		assert.Equal(t, `{"equipment":[{"efficiency":0.5,"id":"1","name":"Lathe | A"},{"efficiency":1,"id":"2","name":"Mill"}]}`, text)
+	})
+
+	t.Run("indented JSON", func(t *testing.T) {
+		text, err := NewIndentedJSONFormatter().Format(data)
+		require.NoError(t, err)
+		assert.Contains(t, text, "\n  \"equipment\": [")
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		text, err := NewYAMLFormatter().Format(data)
+		require.NoError(t, err)
+		expected := strings.Join([]string{
+			"equipment:",
+			"  - efficiency: 0.5",
+			"    id: \"1\"",
+			"    name: \"Lathe | A\"",
+			"  - efficiency: 1",
+			"    id: \"2\"",
+			"    name: Mill",
+		}, "\n")
+		assert.Equal(t, expected, text)
+	})
+
+	t.Run("Markdown table", func(t *testing.T) {
+		text, err := NewMarkdownTableFormatter().Format(data)
+		require.NoError(t, err)
+		expected := strings.Join([]string{
+			"## equipment",
+			"",
+			"| efficiency | id | name |",
+			"| --- | --- | --- |",
+			"| 0.5 | 1 | Lathe \\| A |",
+			"| 1 | 2 | Mill |",
+		}, "\n")
+		assert.Equal(t, expected, text)
+	})
+
+	t.Run("Markdown falls back for nested objects", func(t *testing.T) {
+		text, err := NewMarkdownTableFormatter().Format(map[string]interface{}{
+			"equipmentById": map[string]interface{}{"id": "1", "facility": map[string]interface{}{"name": "Plant"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "## equipmentById\n\nfacility:\n  name: Plant\nid: \"1\"", text)
+	})
+}
+
+func TestFormatWithinBudget(t *testing.T) {
+	formatter := NewCompactJSONFormatter()
+
+	t.Run("fits without truncation", func(t *testing.T) {
+		result, err := formatWithinBudget(equipmentListData(3), formatter, ResponseBudget{MaxBytes: 10000})
+		require.NoError(t, err)
+		assert.Empty(t, result.Note)
+	})
+
+	t.Run("truncates lists to fit", func(t *testing.T) {
+		budget := ResponseBudget{MaxBytes: 500}
+		result, err := formatWithinBudget(equipmentListData(50), formatter, budget)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(result.Text), 500)
+
+		var parsed map[string][]interface{}
+		require.NoError(t, json.Unmarshal([]byte(result.Text), &parsed))
+		kept := len(parsed["equipment"])
+		assert.Greater(t, kept, 0)
+		assert.Contains(t, result.Note, fmt.Sprintf("%d list item(s) were left out", 50-kept))
+		assert.Contains(t, result.Note, fmt.Sprintf("equipment: showing %d of 50", kept))
+		assert.Contains(t, result.Note, "500-byte")
+	})
+
+	t.Run("counts nested lists of omitted items", func(t *testing.T) {
+		facilities := make([]interface{}, 10)
+		for i := range facilities {
+			facilities[i] = map[string]interface{}{"id": fmt.Sprint(i), "equipment": equipmentListData(5)["equipment"]}
+		}
+		result, err := formatWithinBudget(map[string]interface{}{"facilities": facilities}, formatter, ResponseBudget{MaxBytes: 400})
+		require.NoError(t, err)
+
+		var parsed struct {
+			Facilities []struct {
+				Equipment []interface{} `json:"equipment"`
+			} `json:"facilities"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(result.Text), &parsed))
+		keptFacilities, keptEquipment := len(parsed.Facilities), 0
+		for _, facility := range parsed.Facilities {
+			keptEquipment += len(facility.Equipment)
+		}
+		assert.Contains(t, result.Note, fmt.Sprintf("%d list item(s) were left out", 10-keptFacilities+50-keptEquipment))
+		assert.Contains(t, result.Note, fmt.Sprintf("facilities[].equipment: showing %d of 50", keptEquipment))
+	})
+
+	t.Run("token budget", func(t *testing.T) {
+		result, err := formatWithinBudget(equipmentListData(50), formatter, ResponseBudget{MaxTokens: 100})
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(result.Text), 100*bytesPerToken)
+		assert.Contains(t, result.Note, "~100-token")
+	})
+
+	t.Run("cuts text when there are no lists", func(t *testing.T) {
+		data := map[string]interface{}{"description": strings.Repeat("x", 200)}
+		result, err := formatWithinBudget(data, formatter, ResponseBudget{MaxBytes: 50})
+		require.NoError(t, err)
+		assert.Len(t, result.Text, 50)
+		assert.Contains(t, result.Note, "cut at 50")
+	})
+}
+
+func TestMCPGraphQLServer_ResponseBudget(t *testing.T) {
+	testSchema := loadTestSchema(t)
+
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("map[string]interface {}")).
+		Return(&GraphQLResponse{Data: equipmentListData(100)}, nil)
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor,
+		WithResponseFormatter(NewMarkdownTableFormatter()),
+		WithResponseBudget(ResponseBudget{MaxBytes: 100000}),
+		WithToolResponseBudget("query_equipment", ResponseBudget{MaxBytes: 1000}),
+	)
+	require.NoError(t, err)
+
+	equipmentQuery := findField(t, testSchema.GetQueries(), "equipment")
+	result, err := server.executeGraphQLOperation(context.Background(), equipmentQuery, map[string]interface{}{}, "query")
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	require.Len(t, result.Content, 2)
+
+	table := result.Content[0].(*mcp.TextContent).Text
+	assert.True(t, strings.HasPrefix(table, "## equipment\n\n| id | name | status |"))
+	assert.LessOrEqual(t, len(table), 1000)
+	assert.Contains(t, result.Content[1].(*mcp.TextContent).Text, "Response truncated")
+}
+
+// findField returns the root field with the given name
+func findField(t *testing.T, fields []*schema.Field, name string) *schema.Field {
+	t.Helper()
+	for _, field := range fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	t.Fatalf("field %s not found", name)
+	return nil
+}
@@ -37,11 +37,12 @@ func convertASTToType(astDef *ast.Definition) *Type {
 				Name:        astArg.Name,
 				Description: astArg.Description,
 				Type:        ConvertTypeFromAST(astArg.Type),
+				ASTType:     astArg.Type,
 			}
 
 			// Copy default value if present
 			if astArg.DefaultValue != nil {
-				arg.DefaultValue = astArg.DefaultValue.Raw
+				arg.DefaultValue = FormatValueLiteral(astArg.DefaultValue)
 			}
 
 			field.Args = append(field.Args, arg)
@@ -92,6 +93,32 @@ func ConvertTypeFromAST(astType *ast.Type) *TypeRef {
 	return typ
 }
 
+// ConvertTypeRefToAST converts a legacy TypeRef back to a gqlparser AST Type
+func ConvertTypeRefToAST(typeRef *TypeRef) *ast.Type {
+	if typeRef == nil {
+		return nil
+	}
+
+	switch typeRef.Kind {
+	case "NON_NULL":
+		inner := ConvertTypeRefToAST(typeRef.OfType)
+		if inner == nil {
+			return nil
+		}
+		if inner.NamedType != "" {
+			return ast.NonNullNamedType(inner.NamedType, nil)
+		}
+		return &ast.Type{NonNull: true, Elem: inner}
+	case "LIST":
+		return ast.ListType(ConvertTypeRefToAST(typeRef.OfType), nil)
+	default:
+		if typeRef.Name == "" {
+			return ast.NamedType("String", nil)
+		}
+		return ast.NamedType(typeRef.Name, nil)
+	}
+}
+
 // convertKindFromAST converts gqlparser AST DefinitionKind to string
 func convertKindFromAST(kind ast.DefinitionKind) string {
 	return convertKindToString(kind)
@@ -0,0 +1,334 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// Default cost settings used when CostOptions leaves them unset
+const (
+	DefaultObjectFieldCost = 1
+	DefaultListSize        = 10
+)
+
+// DefaultListSizeArguments are the arguments that bound the length of a list field
+var DefaultListSizeArguments = []string{"first", "last", "limit"}
+
+// CostOptions configures query cost analysis and the limits operations must stay within
+type CostOptions struct {
+	// FieldCosts overrides the cost of individual fields, keyed by "Type.field"
+	FieldCosts map[string]int
+	// ObjectFieldCost is the cost of a field that returns an object, interface or union
+	// Scalar and enum fields cost nothing unless FieldCosts says otherwise. Defaults to 1
+	ObjectFieldCost int
+	// ListSizeArguments name the arguments that bound the length of a list field
+	// Defaults to first, last and limit
+	ListSizeArguments []string
+	// DefaultListSize is the assumed length of a list field without a size argument. Defaults to 10
+	DefaultListSize int
+
+	// MaxCost limits the estimated cost of an operation; 0 means unlimited
+	MaxCost int
+	// MaxDepth limits how deeply an operation nests fields; 0 means unlimited
+	MaxDepth int
+	// MaxNodes limits the estimated number of objects an operation returns; 0 means unlimited
+	MaxNodes int
+}
+
+// CostAnalysis is the estimated cost of a GraphQL operation
+type CostAnalysis struct {
+	// Cost is the sum of all field costs, multiplied by the sizes of the lists they appear in
+	Cost int
+	// Depth is the deepest field nesting in the operation
+	Depth int
+	// Nodes is the estimated number of objects the operation returns
+	Nodes int
+	// ListFields are the list fields of the operation, most expensive first
+	ListFields []ListFieldCost
+}
+
+// ListFieldCost is the contribution of one list field to the cost of an operation
+type ListFieldCost struct {
+	// Path is the dotted response path of the field, e.g. "facilities.equipment"
+	Path string
+	// Size is the list length used for the estimate
+	Size int
+	// SizeArgument is the argument the size came from, or empty when the default was assumed
+	SizeArgument string
+	// Cost is the estimated cost of the field including its selections
+	Cost int
+}
+
+// CostLimitError reports an operation whose estimated cost exceeds the configured limits
+type CostLimitError struct {
+	Analysis *CostAnalysis
+	// Limit describes the exceeded limit, e.g. "cost 2400 exceeds the limit of 1000"
+	Limit string
+}
+
+// Error describes the exceeded limit and how to narrow the request
+func (e *CostLimitError) Error() string {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "the operation is too expensive: %s.", e.Limit)
+
+	var hints []string
+	for _, list := range e.Analysis.ListFields {
+		if len(hints) == 3 {
+			break
+		}
+		if list.SizeArgument != "" {
+			hints = append(hints, fmt.Sprintf("%s (%s: %d)", list.Path, list.SizeArgument, list.Size))
+		} else {
+			hints = append(hints, fmt.Sprintf("%s (assumed %d items)", list.Path, list.Size))
+		}
+	}
+	if len(hints) > 0 {
+		fmt.Fprintf(&msg, " The most expensive lists are %s.", strings.Join(hints, ", "))
+	}
+	msg.WriteString(" Narrow the request by passing smaller page sizes such as first or limit, or by filtering" +
+		" on more specific arguments, for example fetching a single item by ID instead of a list.")
+	return msg.String()
+}
+
+// AnalyzeCost estimates the cost, depth and size of a GraphQL operation without executing it
+// Variables provide the values of list size arguments that are passed as variables
+func (s *Schema) AnalyzeCost(document string, variables map[string]interface{}, opts CostOptions) (*CostAnalysis, error) {
+	if s == nil || s.typeRegistry == nil {
+		return nil, fmt.Errorf("schema or type registry is nil")
+	}
+
+	doc, err := parser.ParseQuery(&ast.Source{Input: document})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GraphQL operation: %w", err)
+	}
+
+	analyzer := &costAnalyzer{
+		schema:    s,
+		opts:      opts.withDefaults(),
+		fragments: doc.Fragments,
+		variables: variables,
+		analysis:  &CostAnalysis{},
+	}
+	for _, operation := range doc.Operations {
+		root := s.rootType(operation.Operation)
+		if root == nil {
+			return nil, fmt.Errorf("the schema does not support %s operations", operation.Operation)
+		}
+		cost, nodes := analyzer.selectionSet(operation.SelectionSet, root, "", 1, map[string]bool{})
+		analyzer.analysis.Cost = saturatingAdd(analyzer.analysis.Cost, cost)
+		analyzer.analysis.Nodes = saturatingAdd(analyzer.analysis.Nodes, nodes)
+	}
+
+	sort.SliceStable(analyzer.analysis.ListFields, func(i, j int) bool {
+		return analyzer.analysis.ListFields[i].Cost > analyzer.analysis.ListFields[j].Cost
+	})
+	return analyzer.analysis, nil
+}
+
+// Check returns a *CostLimitError if the analysis exceeds any limit of the options
+func (opts CostOptions) Check(analysis *CostAnalysis) error {
+	switch {
+	case opts.MaxCost > 0 && analysis.Cost > opts.MaxCost:
+		return &CostLimitError{Analysis: analysis, Limit: fmt.Sprintf("estimated cost %d exceeds the limit of %d", analysis.Cost, opts.MaxCost)}
+	case opts.MaxNodes > 0 && analysis.Nodes > opts.MaxNodes:
+		return &CostLimitError{Analysis: analysis, Limit: fmt.Sprintf("an estimated %d returned objects exceed the limit of %d", analysis.Nodes, opts.MaxNodes)}
+	case opts.MaxDepth > 0 && analysis.Depth > opts.MaxDepth:
+		return &CostLimitError{Analysis: analysis, Limit: fmt.Sprintf("depth %d exceeds the limit of %d", analysis.Depth, opts.MaxDepth)}
+	}
+	return nil
+}
+
+// withDefaults fills in the unset cost settings
+func (opts CostOptions) withDefaults() CostOptions {
+	if opts.ObjectFieldCost == 0 {
+		opts.ObjectFieldCost = DefaultObjectFieldCost
+	}
+	if opts.ListSizeArguments == nil {
+		opts.ListSizeArguments = DefaultListSizeArguments
+	}
+	if opts.DefaultListSize == 0 {
+		opts.DefaultListSize = DefaultListSize
+	}
+	return opts
+}
+
+// rootType returns the root type definition for an operation type
+func (s *Schema) rootType(operation ast.Operation) *ast.Definition {
+	if s.parsedSchema == nil {
+		return nil
+	}
+	switch operation {
+	case ast.Query:
+		return s.parsedSchema.Query
+	case ast.Mutation:
+		return s.parsedSchema.Mutation
+	case ast.Subscription:
+		return s.parsedSchema.Subscription
+	}
+	return nil
+}
+
+// costAnalyzer walks the selection sets of an operation
+type costAnalyzer struct {
+	schema    *Schema
+	opts      CostOptions
+	fragments ast.FragmentDefinitionList
+	variables map[string]interface{}
+	analysis  *CostAnalysis
+}
+
+// selectionSet returns the cost and node count of a selection set on a parent type
+// Fragments on abstract types are all counted, so the estimate is an upper bound
+func (a *costAnalyzer) selectionSet(selections ast.SelectionSet, parent *ast.Definition, path string, depth int, fragmentsSeen map[string]bool) (int, int) {
+	cost, nodes := 0, 0
+	for _, selection := range selections {
+		var selectionCost, selectionNodes int
+		switch sel := selection.(type) {
+		case *ast.Field:
+			selectionCost, selectionNodes = a.field(sel, parent, path, depth, fragmentsSeen)
+		case *ast.InlineFragment:
+			typeDef := parent
+			if sel.TypeCondition != "" {
+				typeDef = a.schema.GetTypeDefinition(sel.TypeCondition)
+			}
+			if typeDef != nil {
+				selectionCost, selectionNodes = a.selectionSet(sel.SelectionSet, typeDef, path, depth, fragmentsSeen)
+			}
+		case *ast.FragmentSpread:
+			fragment := a.fragments.ForName(sel.Name)
+			if fragment == nil || fragmentsSeen[sel.Name] {
+				continue
+			}
+			typeDef := a.schema.GetTypeDefinition(fragment.TypeCondition)
+			if typeDef != nil {
+				fragmentsSeen[sel.Name] = true
+				selectionCost, selectionNodes = a.selectionSet(fragment.SelectionSet, typeDef, path, depth, fragmentsSeen)
+				delete(fragmentsSeen, sel.Name)
+			}
+		}
+		cost = saturatingAdd(cost, selectionCost)
+		nodes = saturatingAdd(nodes, selectionNodes)
+	}
+	return cost, nodes
+}
+
+// field returns the cost and node count of a field including its selections
+func (a *costAnalyzer) field(field *ast.Field, parent *ast.Definition, path string, depth int, fragmentsSeen map[string]bool) (int, int) {
+	if strings.HasPrefix(field.Name, "__") {
+		return 0, 0
+	}
+	if depth > a.analysis.Depth {
+		a.analysis.Depth = depth
+	}
+
+	definition := parent.Fields.ForName(field.Name)
+	if definition == nil {
+		return 0, 0
+	}
+	fieldPath := field.Alias
+	if fieldPath == "" {
+		fieldPath = field.Name
+	}
+	if path != "" {
+		fieldPath = path + "." + fieldPath
+	}
+
+	typeDef := a.schema.GetTypeDefinition(GetASTTypeName(definition.Type))
+	composite := typeDef != nil && (typeDef.Kind == ast.Object || typeDef.Kind == ast.Interface || typeDef.Kind == ast.Union)
+
+	fieldCost, ok := a.opts.FieldCosts[parent.Name+"."+field.Name]
+	if !ok && composite {
+		fieldCost = a.opts.ObjectFieldCost
+	}
+
+	childCost, childNodes := 0, 0
+	if composite {
+		childCost, childNodes = a.selectionSet(field.SelectionSet, typeDef, fieldPath, depth+1, fragmentsSeen)
+	}
+	if !isListType(definition.Type) {
+		nodes := childNodes
+		if composite {
+			nodes = saturatingAdd(nodes, 1)
+		}
+		return saturatingAdd(fieldCost, childCost), nodes
+	}
+
+	size, sizeArgument := a.listSize(field, definition)
+	cost := saturatingMul(size, saturatingAdd(fieldCost, childCost))
+	nodes := 0
+	if composite {
+		nodes = saturatingMul(size, saturatingAdd(childNodes, 1))
+	}
+	a.analysis.ListFields = append(a.analysis.ListFields, ListFieldCost{Path: fieldPath, Size: size, SizeArgument: sizeArgument, Cost: cost})
+	return cost, nodes
+}
+
+// listSize returns the length of a list field from its size arguments, from the defaults of
+// those arguments in the schema when the call leaves them out, or the default size
+func (a *costAnalyzer) listSize(field *ast.Field, definition *ast.FieldDefinition) (int, string) {
+	for _, name := range a.opts.ListSizeArguments {
+		argument := field.Arguments.ForName(name)
+		if argument == nil || argument.Value == nil {
+			continue
+		}
+		if size, ok := a.intValue(argument.Value); ok && size >= 0 {
+			return size, name
+		}
+	}
+	for _, name := range a.opts.ListSizeArguments {
+		argument := definition.Arguments.ForName(name)
+		if argument == nil || argument.DefaultValue == nil {
+			continue
+		}
+		if size, ok := a.intValue(argument.DefaultValue); ok && size >= 0 {
+			return size, name
+		}
+	}
+	return a.opts.DefaultListSize, ""
+}
+
+// intValue resolves an integer literal or variable
+func (a *costAnalyzer) intValue(value *ast.Value) (int, bool) {
+	switch value.Kind {
+	case ast.IntValue:
+		n, err := strconv.Atoi(value.Raw)
+		return n, err == nil
+	case ast.Variable:
+		switch v := a.variables[value.Raw].(type) {
+		case int:
+			return v, true
+		case int64:
+			return int(v), true
+		case float64:
+			return int(v), v == math.Trunc(v)
+		}
+	}
+	return 0, false
+}
+
+// isListType reports whether a type is a list, ignoring non-null wrappers
+func isListType(t *ast.Type) bool {
+	return t != nil && t.Elem != nil
+}
+
+// saturatingAdd adds two non-negative ints without overflowing
+func saturatingAdd(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+// saturatingMul multiplies two non-negative ints without overflowing
+func saturatingMul(a, b int) int {
+	if a != 0 && b > math.MaxInt/a {
+		return math.MaxInt
+	}
+	return a * b
+}
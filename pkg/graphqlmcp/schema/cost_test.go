@@ -0,0 +1,230 @@
+package schema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// parseTestAST builds an AST schema from SDL the way ParseIntrospectionResponse builds one
+func parseTestAST(t *testing.T, sdl string) *ast.Schema {
+	t.Helper()
+	doc, err := parser.ParseSchema(&ast.Source{Input: sdl})
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v", err)
+	}
+	// Introspection results include the built-in scalars
+	astSchema := &ast.Schema{Types: make(map[string]*ast.Definition, len(doc.Definitions))}
+	for _, name := range []string{"Int", "Float", "String", "Boolean", "ID"} {
+		astSchema.Types[name] = &ast.Definition{Kind: ast.Scalar, Name: name, BuiltIn: true}
+	}
+	for _, def := range doc.Definitions {
+		astSchema.Types[def.Name] = def
+	}
+	astSchema.Query = astSchema.Types["Query"]
+	astSchema.Mutation = astSchema.Types["Mutation"]
+	return astSchema
+}
+
+// costTestSchema builds a schema with nested paginated lists
+func costTestSchema(t *testing.T) *Schema {
+	t.Helper()
+	parsed := parseTestAST(t, `
+		type Query {
+			facilities(first: Int): [Facility!]!
+			facility(id: ID!): Facility
+			search(limit: Int): [SearchResult!]!
+			count: Int!
+		}
+		type Facility {
+			id: ID!
+			name: String!
+			equipment(first: Int): [Equipment!]!
+		}
+		type Equipment {
+			id: ID!
+			readings(limit: Int): [Reading!]!
+		}
+		type Reading {
+			value: Float!
+		}
+		union SearchResult = Facility | Equipment
+	`)
+	return &Schema{parsedSchema: parsed, typeRegistry: parsed.Types, QueryType: convertASTToType(parsed.Query)}
+}
+
+func TestSchema_AnalyzeCost(t *testing.T) {
+	s := costTestSchema(t)
+
+	tests := []struct {
+		name      string
+		document  string
+		variables map[string]interface{}
+		opts      CostOptions
+		cost      int
+		depth     int
+		nodes     int
+		topList   string
+	}{
+		{
+			name:     "scalar field",
+			document: "{ count }",
+			cost:     0,
+			depth:    1,
+			nodes:    0,
+		},
+		{
+			name:     "single object",
+			document: `{ facility(id: "1") { id name } }`,
+			cost:     1,
+			depth:    2,
+			nodes:    1,
+		},
+		{
+			// 5 facilities * (1 + 3 equipment * (1 + 10 default readings * 1))
+			name:     "nested lists with literal and default sizes",
+			document: "{ facilities(first: 5) { name equipment(first: 3) { id readings { value } } } }",
+			cost:     5 * (1 + 3*(1+10*1)),
+			depth:    4,
+			nodes:    5 * (1 + 3*(1+10)),
+			topList:  "facilities",
+		},
+		{
+			name:      "sizes from variables",
+			document:  "query($n: Int) { facilities(first: $n) { equipment(first: 2) { id } } }",
+			variables: map[string]interface{}{"n": 4},
+			cost:      4 * (1 + 2*1),
+			depth:     3,
+			nodes:     4 * (1 + 2),
+		},
+		{
+			name:     "field cost overrides and default list size",
+			document: "{ facilities { name } }",
+			opts:     CostOptions{FieldCosts: map[string]int{"Facility.name": 2}, DefaultListSize: 50},
+			cost:     50 * (1 + 2),
+			depth:    2,
+			nodes:    50,
+		},
+		{
+			name:     "union fragments and aliases",
+			document: "{ results: search(limit: 2) { __typename ... on Facility { id } ... on Equipment { readings(limit: 1) { value } } } }",
+			cost:     2 * (1 + 1*1),
+			depth:    3,
+			nodes:    2 * (1 + 1),
+			topList:  "results",
+		},
+		{
+			name:     "named fragments",
+			document: "query { facilities(first: 2) { ...F } } fragment F on Facility { equipment(first: 2) { id } }",
+			cost:     2 * (1 + 2),
+			depth:    3,
+			nodes:    2 * (1 + 2),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis, err := s.AnalyzeCost(tt.document, tt.variables, tt.opts)
+			if err != nil {
+				t.Fatalf("AnalyzeCost() error = %v", err)
+			}
+			if analysis.Cost != tt.cost || analysis.Depth != tt.depth || analysis.Nodes != tt.nodes {
+				t.Errorf("AnalyzeCost() = cost %d, depth %d, nodes %d; want cost %d, depth %d, nodes %d",
+					analysis.Cost, analysis.Depth, analysis.Nodes, tt.cost, tt.depth, tt.nodes)
+			}
+			if tt.topList != "" && (len(analysis.ListFields) == 0 || analysis.ListFields[0].Path != tt.topList) {
+				t.Errorf("most expensive list = %+v, want %s", analysis.ListFields, tt.topList)
+			}
+		})
+	}
+
+	if _, err := s.AnalyzeCost("{ facilities {", nil, CostOptions{}); err == nil {
+		t.Error("AnalyzeCost() expected a parse error")
+	}
+	if _, err := s.AnalyzeCost("mutation { x }", nil, CostOptions{}); err == nil || !strings.Contains(err.Error(), "does not support mutation operations") {
+		t.Errorf("AnalyzeCost() error = %v, want unsupported mutation", err)
+	}
+}
+
+func TestSchema_AnalyzeCost_ArgumentDefaults(t *testing.T) {
+	parsed := parseTestAST(t, `
+		type Query {
+			items(first: Int = 500): [Item!]!
+		}
+		type Item {
+			id: ID!
+		}
+	`)
+	s := &Schema{parsedSchema: parsed, typeRegistry: parsed.Types, QueryType: convertASTToType(parsed.Query)}
+
+	// Calls without a size argument, or with an unset variable, get the default of the schema
+	tests := []struct {
+		document string
+		cost     int
+	}{
+		{document: "{ items { id } }", cost: 500},
+		{document: "query($n: Int) { items(first: $n) { id } }", cost: 500},
+		{document: "{ items(first: 5) { id } }", cost: 5},
+	}
+	for _, tt := range tests {
+		analysis, err := s.AnalyzeCost(tt.document, nil, CostOptions{})
+		if err != nil {
+			t.Fatalf("AnalyzeCost(%q) error = %v", tt.document, err)
+		}
+		if analysis.Cost != tt.cost {
+			t.Errorf("AnalyzeCost(%q) cost = %d, want %d", tt.document, analysis.Cost, tt.cost)
+		}
+	}
+
+	analysis, err := s.AnalyzeCost("{ items { id } }", nil, CostOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeCost() error = %v", err)
+	}
+	if err := (CostOptions{MaxCost: 100}).Check(analysis); err == nil || !strings.Contains(err.Error(), "items (first: 500)") {
+		t.Errorf("Check() error = %v, want the default size of items to exceed the limit", err)
+	}
+}
+
+func TestCostOptions_Check(t *testing.T) {
+	s := costTestSchema(t)
+	analysis, err := s.AnalyzeCost("{ facilities(first: 100) { equipment { readings(limit: 20) { value } } } }", nil, CostOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeCost() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     CostOptions
+		expected string
+	}{
+		{name: "within limits", opts: CostOptions{MaxCost: 1_000_000, MaxDepth: 10, MaxNodes: 1_000_000}},
+		{name: "cost", opts: CostOptions{MaxCost: 1000}, expected: "estimated cost 21100 exceeds the limit of 1000"},
+		{name: "nodes", opts: CostOptions{MaxNodes: 500}, expected: "an estimated 21100 returned objects exceed the limit of 500"},
+		{name: "depth", opts: CostOptions{MaxDepth: 2}, expected: "depth 4 exceeds the limit of 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Check(analysis)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Check() error = %v", err)
+				}
+				return
+			}
+			var limitErr *CostLimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("Check() error = %v, want *CostLimitError", err)
+			}
+			msg := err.Error()
+			for _, want := range []string{tt.expected, "facilities (first: 100)", "facilities.equipment (assumed 10 items)", "Narrow the request"} {
+				if !strings.Contains(msg, want) {
+					t.Errorf("Check() error = %q, want it to contain %q", msg, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package schema
+
+import (
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// EnumStyle selects how enum values and their descriptions are rendered in JSON schemas
+type EnumStyle string
+
+const (
+	// EnumStyleExtension renders "enum" with the value names plus an "x-enumDescriptions"
+	// object mapping each documented value to its description
+	EnumStyleExtension EnumStyle = "extension"
+	// EnumStyleOneOf renders one {"const", "description"} branch per value under "oneOf"
+	EnumStyleOneOf EnumStyle = "oneOf"
+)
+
+// defaultDeprecationReason is the reason GraphQL assumes for @deprecated without arguments
+const defaultDeprecationReason = "No longer supported"
+
+// deprecatedDirective creates the @deprecated directive for introspected deprecations
+func deprecatedDirective(reason string) *ast.Directive {
+	directive := &ast.Directive{Name: "deprecated", Location: ast.LocationEnumValue}
+	if reason != "" {
+		directive.Arguments = ast.ArgumentList{
+			{Name: "reason", Value: &ast.Value{Kind: ast.StringValue, Raw: reason}},
+		}
+	}
+	return directive
+}
+
+// deprecationReason reports whether a definition is deprecated and why
+func deprecationReason(directives ast.DirectiveList) (string, bool) {
+	directive := directives.ForName("deprecated")
+	if directive == nil {
+		return "", false
+	}
+	if reason := directive.Arguments.ForName("reason"); reason != nil && reason.Value != nil && reason.Value.Raw != "" {
+		return reason.Value.Raw, true
+	}
+	return defaultDeprecationReason, true
+}
+
+// enumValueDescription returns the description of an enum value, flagging deprecated values
+func enumValueDescription(enumValue *ast.EnumValueDefinition) string {
+	reason, deprecated := deprecationReason(enumValue.Directives)
+	if !deprecated {
+		return enumValue.Description
+	}
+
+	note := "Deprecated: " + reason
+	if enumValue.Description != "" {
+		return note + ". " + enumValue.Description
+	}
+	return note
+}
+
+// enumSchema adds the values of an enum type to a JSON schema, leaving out deprecated values
+// unless they are requested and documenting each value according to the enum style
+func (b *jsonSchemaBuilder) enumSchema(schema map[string]interface{}, typeDef *ast.Definition) {
+	var values []*ast.EnumValueDefinition
+	for _, enumValue := range typeDef.EnumValues {
+		if _, deprecated := deprecationReason(enumValue.Directives); deprecated && !b.opts.IncludeDeprecatedEnumValues {
+			continue
+		}
+		values = append(values, enumValue)
+	}
+	if len(values) == 0 {
+		return
+	}
+
+	if typeDef.Description != "" {
+		schema["description"] = typeDef.Description
+	}
+
+	// Strict schemas reject unknown keywords, so value descriptions are written into the text
+	if b.opts.strict() {
+		names := make([]string, len(values))
+		var lines []string
+		for i, enumValue := range values {
+			names[i] = enumValue.Name
+			if description := enumValueDescription(enumValue); description != "" {
+				lines = append(lines, "- "+enumValue.Name+": "+description)
+			}
+		}
+		schema["enum"] = names
+		if len(lines) > 0 {
+			appendDescription(schema, strings.Join(lines, "\n"))
+		}
+		return
+	}
+
+	if b.opts.EnumStyle == EnumStyleOneOf {
+		branches := make([]interface{}, len(values))
+		for i, enumValue := range values {
+			branch := map[string]interface{}{"const": enumValue.Name}
+			if description := enumValueDescription(enumValue); description != "" {
+				branch["description"] = description
+			}
+			if _, deprecated := deprecationReason(enumValue.Directives); deprecated {
+				branch["deprecated"] = true
+			}
+			branches[i] = branch
+		}
+		schema["oneOf"] = branches
+		return
+	}
+
+	names := make([]string, len(values))
+	descriptions := make(map[string]interface{})
+	for i, enumValue := range values {
+		names[i] = enumValue.Name
+		if description := enumValueDescription(enumValue); description != "" {
+			descriptions[enumValue.Name] = description
+		}
+	}
+	schema["enum"] = names
+	if len(descriptions) > 0 {
+		schema["x-enumDescriptions"] = descriptions
+	}
+}
+
+// appendDescription adds a paragraph to the description of a schema
+func appendDescription(schema map[string]interface{}, text string) {
+	if existing, ok := schema["description"].(string); ok && existing != "" {
+		text = existing + "\n" + text
+	}
+	schema["description"] = text
+}
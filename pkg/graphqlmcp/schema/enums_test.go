@@ -0,0 +1,146 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// enumIntrospection builds an introspection response with a documented, partly deprecated enum
+func enumIntrospection() map[string]interface{} {
+	return map[string]interface{}{
+		"__schema": map[string]interface{}{
+			"queryType": map[string]interface{}{"name": "Query"},
+			"types": []interface{}{
+				map[string]interface{}{
+					"name": "Query",
+					"kind": "OBJECT",
+					"fields": []interface{}{
+						map[string]interface{}{
+							"name": "equipment",
+							"type": map[string]interface{}{"kind": "SCALAR", "name": "String"},
+							"args": []interface{}{
+								map[string]interface{}{
+									"name":        "status",
+									"description": `Filter by "status"`,
+									"type":        map[string]interface{}{"kind": "ENUM", "name": "EquipmentStatus"},
+								},
+							},
+						},
+					},
+				},
+				map[string]interface{}{
+					"name":        "EquipmentStatus",
+					"kind":        "ENUM",
+					"description": "Operational state of equipment",
+					"enumValues": []interface{}{
+						map[string]interface{}{"name": "OPERATIONAL", "description": `Running "normally"`},
+						map[string]interface{}{"name": "MAINTENANCE", "description": "Down for service\nby a technician"},
+						map[string]interface{}{"name": "OFFLINE"},
+						map[string]interface{}{"name": "BROKEN", "isDeprecated": true, "deprecationReason": "Use OFFLINE"},
+					},
+				},
+				map[string]interface{}{"name": "String", "kind": "SCALAR"},
+			},
+		},
+	}
+}
+
+func TestEnumSchemas(t *testing.T) {
+	s, err := ParseIntrospectionResponse(enumIntrospection())
+	if err != nil {
+		t.Fatalf("ParseIntrospectionResponse() error = %v", err)
+	}
+	field := s.GetQueries()[0]
+
+	statusSchema := func(opts JSONSchemaOptions) map[string]interface{} {
+		return s.CreateInputSchemaWithOptions(field, opts)["properties"].(map[string]interface{})["status"].(map[string]interface{})
+	}
+
+	t.Run("extension", func(t *testing.T) {
+		status := statusSchema(JSONSchemaOptions{})
+		if !reflect.DeepEqual(status["enum"], []string{"OPERATIONAL", "MAINTENANCE", "OFFLINE"}) {
+			t.Errorf("enum = %v, want deprecated value left out", status["enum"])
+		}
+		expected := map[string]interface{}{
+			"OPERATIONAL": `Running "normally"`,
+			"MAINTENANCE": "Down for service\nby a technician",
+		}
+		if !reflect.DeepEqual(status["x-enumDescriptions"], expected) {
+			t.Errorf("x-enumDescriptions = %v, want %v", status["x-enumDescriptions"], expected)
+		}
+		if status["description"] != "Filter by \"status\"\nOperational state of equipment" {
+			t.Errorf("description = %q", status["description"])
+		}
+	})
+
+	t.Run("oneOf with deprecated values", func(t *testing.T) {
+		status := statusSchema(JSONSchemaOptions{EnumStyle: EnumStyleOneOf, IncludeDeprecatedEnumValues: true})
+		if _, ok := status["enum"]; ok {
+			t.Error("oneOf style should not emit enum")
+		}
+		branches := status["oneOf"].([]interface{})
+		if len(branches) != 4 {
+			t.Fatalf("oneOf has %d branches, want 4", len(branches))
+		}
+		expected := map[string]interface{}{"const": "BROKEN", "description": "Deprecated: Use OFFLINE", "deprecated": true}
+		if !reflect.DeepEqual(branches[3], expected) {
+			t.Errorf("deprecated branch = %v, want %v", branches[3], expected)
+		}
+		if !reflect.DeepEqual(branches[2], map[string]interface{}{"const": "OFFLINE"}) {
+			t.Errorf("undocumented branch = %v", branches[2])
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		status := statusSchema(JSONSchemaOptions{Dialect: JSONSchemaStrict})
+		if _, ok := status["x-enumDescriptions"]; ok {
+			t.Error("strict schemas should not contain extension keywords")
+		}
+		if !reflect.DeepEqual(status["enum"], []interface{}{"OPERATIONAL", "MAINTENANCE", "OFFLINE", nil}) {
+			t.Errorf("enum = %v, want nullable enum", status["enum"])
+		}
+		if !strings.Contains(status["description"].(string), "- OPERATIONAL: Running \"normally\"") {
+			t.Errorf("description = %q, want value descriptions", status["description"])
+		}
+	})
+}
+
+func TestEnumSDL(t *testing.T) {
+	s, err := ParseIntrospectionResponse(enumIntrospection())
+	if err != nil {
+		t.Fatalf("ParseIntrospectionResponse() error = %v", err)
+	}
+
+	sdl := s.GetSchemaSDL()
+	for _, expected := range []string{
+		`"Operational state of equipment"` + "\nenum EquipmentStatus {",
+		`  "Running \"normally\""` + "\n  OPERATIONAL\n",
+		"  \"\"\"\n  Down for service\n  by a technician\n  \"\"\"\n  MAINTENANCE\n",
+		`  BROKEN @deprecated(reason: "Use OFFLINE")`,
+		`equipment("Filter by \"status\"" status: EquipmentStatus): String`,
+	} {
+		if !strings.Contains(sdl, expected) {
+			t.Errorf("GetSchemaSDL() missing %q in:\n%s", expected, sdl)
+		}
+	}
+
+	// The generated SDL must parse, and round-trip the descriptions
+	doc, err := parser.ParseSchema(&ast.Source{Input: sdl})
+	if err != nil {
+		t.Fatalf("generated SDL does not parse: %v\n%s", err, sdl)
+	}
+	enumDef := doc.Definitions.ForName("EquipmentStatus")
+	if enumDef == nil {
+		t.Fatal("EquipmentStatus missing from parsed SDL")
+	}
+	if got := enumDef.EnumValues.ForName("MAINTENANCE").Description; got != "Down for service\nby a technician" {
+		t.Errorf("MAINTENANCE description = %q", got)
+	}
+	if got := enumDef.EnumValues.ForName("OPERATIONAL").Description; got != `Running "normally"` {
+		t.Errorf("OPERATIONAL description = %q", got)
+	}
+}
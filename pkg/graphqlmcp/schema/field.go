@@ -61,14 +61,19 @@ func (f *Field) generateOperationString(schema *Schema, operationType string) (s
 	}
 
 	// Add selection set based on the return type
-	operation.WriteString(" {\n    ")
-
 	selectionSet, err := f.generateSelectionSetFromAST(schema)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate selection set: %w", err)
 	}
-	operation.WriteString(selectionSet)
 
+	// Scalar and enum results are selected without a selection set
+	if selectionSet == "" {
+		operation.WriteString("\n}")
+		return operation.String(), nil
+	}
+
+	operation.WriteString(" {\n    ")
+	operation.WriteString(selectionSet)
 	operation.WriteString("\n  }\n}")
 
 	return operation.String(), nil
@@ -115,7 +120,7 @@ func (f *Field) generateInterfaceSelectionSet(interfaceDef *ast.Definition, sche
 
 	// Add interface fields
 	for _, field := range interfaceDef.Fields {
-		if f.shouldIncludeFieldInInterface(field) {
+		if f.shouldIncludeFieldInInterface(field) && !schema.IsFieldExcluded(interfaceDef.Name, field.Name) {
 			// Check if this field is an object type that needs subfields
 			fieldTypeName := GetASTTypeName(field.Type)
 			fieldTypeDef := schema.GetTypeDefinition(fieldTypeName)
@@ -164,7 +169,7 @@ func (f *Field) generateInterfaceSelectionSet(interfaceDef *ast.Definition, sche
 			}
 
 			// Skip fields that shouldn't be included
-			if !f.shouldIncludeFieldInInterface(field) {
+			if !f.shouldIncludeFieldInInterface(field) || schema.IsFieldExcluded(impl.Name, field.Name) {
 				continue
 			}
 
@@ -256,7 +261,7 @@ func (f *Field) generateSelectionSetForTypeWithVisited(typeDef *ast.Definition,
 	case ast.Object, ast.Interface:
 		// For objects and interfaces, select their fields
 		for _, field := range typeDef.Fields {
-			if f.shouldIncludeField(field) {
+			if f.shouldIncludeField(field) && !schema.IsFieldExcluded(typeDef.Name, field.Name) {
 				// Skip fields that return the same type as the current type being processed to avoid self-referencing
 				// This prevents infinite recursion while allowing legitimate cross-references
 				fieldTypeName := GetASTTypeName(field.Type)
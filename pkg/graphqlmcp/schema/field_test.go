@@ -1,6 +1,7 @@
 package schema
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/vektah/gqlparser/v2/ast"
@@ -494,3 +495,29 @@ func TestField_GenerateQueryStringWithSchema_Personnel(t *testing.T) {
 		t.Errorf("Query should have proper structure, but got: %s", query)
 	}
 }
+
+func TestField_GenerateQueryStringWithSchema_ExcludedFields(t *testing.T) {
+	s := costTestSchema(t)
+	s.ExcludedFields = map[string]bool{"Facility.name": true}
+
+	var facilities *Field
+	for _, query := range s.GetQueries() {
+		if query.Name == "facilities" {
+			facilities = query
+		}
+	}
+	if facilities == nil {
+		t.Fatal("facilities query not found")
+	}
+
+	query, err := facilities.GenerateQueryStringWithSchema(s)
+	if err != nil {
+		t.Fatalf("GenerateQueryStringWithSchema() error = %v", err)
+	}
+	if strings.Contains(query, "name") {
+		t.Errorf("excluded field Facility.name was selected:\n%s", query)
+	}
+	if !strings.Contains(query, "id") {
+		t.Errorf("expected the other fields to be selected:\n%s", query)
+	}
+}
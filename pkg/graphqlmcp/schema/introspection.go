@@ -2,7 +2,9 @@ package schema
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/vektah/gqlparser/v2"
 	"github.com/vektah/gqlparser/v2/ast"
 )
 
@@ -48,6 +50,34 @@ func ParseIntrospectionResponse(data map[string]interface{}) (*Schema, error) {
 		}
 	}
 
+	return newSchemaFromAST(astSchema), nil
+}
+
+// ParseSDL parses a schema in GraphQL SDL format, as an alternative to introspection
+func ParseSDL(sdl string) (*Schema, error) {
+	astSchema, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: sdl})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	// gqlparser adds the introspection fields to the query type; they are not operations
+	if astSchema.Query != nil {
+		query := *astSchema.Query
+		query.Fields = make(ast.FieldList, 0, len(astSchema.Query.Fields))
+		for _, field := range astSchema.Query.Fields {
+			if !strings.HasPrefix(field.Name, "__") {
+				query.Fields = append(query.Fields, field)
+			}
+		}
+		astSchema.Query = &query
+		astSchema.Types[query.Name] = &query
+	}
+
+	return newSchemaFromAST(astSchema), nil
+}
+
+// newSchemaFromAST creates a schema from a gqlparser AST schema
+func newSchemaFromAST(astSchema *ast.Schema) *Schema {
 	// Create the schema with the parsed AST
 	schema := &Schema{
 		parsedSchema: astSchema,
@@ -67,7 +97,7 @@ func ParseIntrospectionResponse(data map[string]interface{}) (*Schema, error) {
 		}
 	}
 
-	return schema, nil
+	return schema
 }
 
 // parseTypeToAST converts introspection data to gqlparser AST Definition
@@ -88,6 +118,11 @@ func parseTypeToAST(data map[string]interface{}) (*ast.Definition, error) {
 		Description: getString(data, "description"),
 	}
 
+	// Record @oneOf input objects as a directive so SDL and introspection schemas look alike
+	if isOneOf, ok := data["isOneOf"].(bool); ok && isOneOf {
+		astDef.Directives = append(astDef.Directives, &ast.Directive{Name: oneOfDirective, Location: ast.LocationInputObject})
+	}
+
 	// Parse fields (for objects, interfaces, etc.)
 	if fieldsData, ok := data["fields"].([]interface{}); ok {
 		astDef.Fields = make([]*ast.FieldDefinition, 0, len(fieldsData))
@@ -127,6 +162,9 @@ func parseTypeToAST(data map[string]interface{}) (*ast.Definition, error) {
 					Name:        getString(enumValueMap, "name"),
 					Description: getString(enumValueMap, "description"),
 				}
+				if isDeprecated, ok := enumValueMap["isDeprecated"].(bool); ok && isDeprecated {
+					enumValue.Directives = append(enumValue.Directives, deprecatedDirective(getString(enumValueMap, "deprecationReason")))
+				}
 				astDef.EnumValues = append(astDef.EnumValues, enumValue)
 			}
 		}
@@ -209,10 +247,7 @@ func parseFieldDefinition(data map[string]interface{}, includeArgs bool) (*ast.F
 	// Parse default value (for input fields)
 	if !includeArgs {
 		if defaultValue, ok := data["defaultValue"]; ok && defaultValue != nil {
-			field.DefaultValue = &ast.Value{
-				Kind: ast.StringValue,
-				Raw:  fmt.Sprintf("%v", defaultValue),
-			}
+			field.DefaultValue = parseDefaultValue(defaultValue)
 		}
 	}
 
@@ -242,10 +277,7 @@ func parseArgumentToAST(data map[string]interface{}) (*ast.ArgumentDefinition, e
 
 	// Parse default value
 	if defaultValue, ok := data["defaultValue"]; ok && defaultValue != nil {
-		arg.DefaultValue = &ast.Value{
-			Kind: ast.StringValue,
-			Raw:  fmt.Sprintf("%v", defaultValue),
-		}
+		arg.DefaultValue = parseDefaultValue(defaultValue)
 	}
 
 	return arg, nil
@@ -3,6 +3,7 @@ package schema
 import (
 	"encoding/json"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -1066,3 +1067,42 @@ func countInputTypes(types []*Type) int {
 	}
 	return count
 }
+
+func TestParseSDL(t *testing.T) {
+	s, err := ParseSDL(`
+		type Query {
+			equipment(first: Int = 10): [Equipment!]!
+		}
+		type Mutation {
+			deleteEquipment(id: ID!): Boolean!
+		}
+		type Equipment {
+			id: ID!
+			name: String!
+		}
+	`)
+	if err != nil {
+		t.Fatalf("ParseSDL() error = %v", err)
+	}
+
+	if len(s.GetQueries()) != 1 || s.GetQueries()[0].Name != "equipment" {
+		t.Errorf("GetQueries() = %+v, want only equipment", s.GetQueries())
+	}
+	if len(s.GetMutations()) != 1 {
+		t.Errorf("GetMutations() returned %d fields, want 1", len(s.GetMutations()))
+	}
+	if got := s.GetQueries()[0].Args[0].DefaultValue; got != "10" {
+		t.Errorf("default value = %q, want 10", got)
+	}
+	query, err := s.GetQueries()[0].GenerateQueryStringWithSchema(s)
+	if err != nil {
+		t.Fatalf("GenerateQueryStringWithSchema() error = %v", err)
+	}
+	if !strings.Contains(query, "name") {
+		t.Errorf("query %q does not select name", query)
+	}
+
+	if _, err := ParseSDL("type Query { equipment: Missing }"); err == nil || !strings.Contains(err.Error(), "failed to parse schema") {
+		t.Errorf("ParseSDL() error = %v, want a parse error", err)
+	}
+}
@@ -2,63 +2,136 @@ package schema
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/vektah/gqlparser/v2/ast"
 )
 
-// CreateInputObjectSchema creates a detailed JSON schema for an input object type
-func (s *Schema) CreateInputObjectSchema(typeName string) map[string]interface{} {
-	return s.createInputObjectSchemaWithDepth(typeName, make(map[string]bool), 0)
+// maxInlineDepth bounds how deeply recursive input objects are inlined
+const maxInlineDepth = 10
+
+// JSONSchemaDialect selects how nullability, requiredness, defaults and references are rendered
+type JSONSchemaDialect string
+
+const (
+	// JSONSchemaDraft2020 renders JSON Schema 2020-12, the dialect MCP assumes by default
+	JSONSchemaDraft2020 JSONSchemaDialect = "draft2020"
+	// JSONSchemaDraft07 renders JSON Schema draft-07, using definitions instead of $defs
+	JSONSchemaDraft07 JSONSchemaDialect = "draft07"
+	// JSONSchemaStrict renders "strict" function-calling schemas: every object has
+	// additionalProperties false and lists every property as required, optional values are
+	// nullable types, and defaults are described in text because they are not supported
+	JSONSchemaStrict JSONSchemaDialect = "strict"
+)
+
+// JSONSchemaOptions controls how JSON schemas are generated for tool inputs
+type JSONSchemaOptions struct {
+	// InlineInputObjects inlines every input object instead of referencing shared $defs,
+	// for clients that cannot resolve $ref; recursive types are cut off at maxInlineDepth
+	InlineInputObjects bool
+	// Dialect selects the JSON Schema dialect; the zero value renders Draft 2020-12
+	Dialect JSONSchemaDialect
+	// EnumStyle selects how enum value descriptions are rendered; the zero value uses EnumStyleExtension
+	EnumStyle EnumStyle
+	// IncludeDeprecatedEnumValues keeps deprecated enum values, flagged as deprecated, instead of leaving them out
+	IncludeDeprecatedEnumValues bool
+	// Scalars maps custom scalar names to the schema of their values; unmapped custom scalars
+	// are plain strings
+	Scalars map[string]ScalarSchema
 }
 
-// createInputObjectSchemaWithDepth creates a JSON schema with recursion depth tracking
-func (s *Schema) createInputObjectSchemaWithDepth(typeName string, visited map[string]bool, depth int) map[string]interface{} {
-	// Check recursion depth to prevent stack overflow
-	const maxDepth = 10
-	if depth > maxDepth {
-		return map[string]interface{}{
-			"type":        "object",
-			"description": fmt.Sprintf("Recursive type %s (max depth %d exceeded)", typeName, maxDepth),
-			"properties":  map[string]interface{}{},
-		}
+// draft07SchemaURI identifies draft-07 schemas, which JSON Schema validators do not assume by default
+const draft07SchemaURI = "http://json-schema.org/draft-07/schema#"
+
+// definitionsKey returns the keyword under which shared definitions are emitted
+func (opts JSONSchemaOptions) definitionsKey() string {
+	if opts.Dialect == JSONSchemaDraft07 {
+		return "definitions"
 	}
+	return "$defs"
+}
 
-	// Check for circular references
-	if visited[typeName] {
-		return map[string]interface{}{
-			"type":        "object",
-			"description": fmt.Sprintf("Circular reference to %s", typeName),
-			"properties":  map[string]interface{}{},
-		}
+// strict reports whether strict function-calling schemas are generated
+func (opts JSONSchemaOptions) strict() bool {
+	return opts.Dialect == JSONSchemaStrict
+}
+
+// jsonSchemaBuilder generates JSON schemas for GraphQL input types and collects the
+// input object definitions that are referenced with $ref
+type jsonSchemaBuilder struct {
+	schema  *Schema
+	opts    JSONSchemaOptions
+	defs    map[string]interface{}
+	visited map[string]bool
+}
+
+// newJSONSchemaBuilder creates a builder for a single root schema
+func (s *Schema) newJSONSchemaBuilder(opts JSONSchemaOptions) *jsonSchemaBuilder {
+	return &jsonSchemaBuilder{
+		schema:  s,
+		opts:    opts,
+		defs:    make(map[string]interface{}),
+		visited: make(map[string]bool),
 	}
+}
+
+// inlineOptions are the options used by the schema helpers that return a single inlined schema
+var inlineOptions = JSONSchemaOptions{InlineInputObjects: true}
 
-	// Get the type definition from the schema
+// CreateInputObjectSchema creates a detailed JSON schema for an input object type
+func (s *Schema) CreateInputObjectSchema(typeName string) map[string]interface{} {
 	typeDef := s.GetTypeDefinition(typeName)
-	if typeDef == nil {
+	if typeDef == nil || typeDef.Kind != ast.InputObject {
 		return nil
 	}
 
-	// Only handle input object types
-	if typeDef.Kind != "INPUT_OBJECT" {
-		return nil
-	}
+	b := s.newJSONSchemaBuilder(inlineOptions)
+	b.visited[typeName] = true
+	return b.inputObjectSchema(typeDef, 0)
+}
+
+// CreateInputFieldSchemaFromAST creates a JSON schema for an input field from AST
+func (s *Schema) CreateInputFieldSchemaFromAST(field *ast.FieldDefinition) map[string]interface{} {
+	return s.newJSONSchemaBuilder(inlineOptions).typeSchema(field.Type, field.Description, field.DefaultValue, 0)
+}
+
+// createItemSchemaFromAST creates a JSON schema for list items without adding array wrappers
+func (s *Schema) createItemSchemaFromAST(astType *ast.Type, defaultValue *ast.Value) map[string]interface{} {
+	return s.newJSONSchemaBuilder(inlineOptions).itemSchema(astType, 0)
+}
+
+// CreateTypeRefSchema creates a JSON schema for a TypeRef
+func (s *Schema) CreateTypeRefSchema(typeRef *TypeRef, description string) map[string]interface{} {
+	return s.newJSONSchemaBuilder(inlineOptions).typeSchema(ConvertTypeRefToAST(typeRef), description, nil, 0)
+}
 
-	// Mark this type as visited
-	visited[typeName] = true
-	defer delete(visited, typeName) // Clean up when done
+// CreateArgumentSchema creates a JSON schema for a GraphQL argument
+func (s *Schema) CreateArgumentSchema(arg *Argument) map[string]interface{} {
+	return s.newJSONSchemaBuilder(inlineOptions).argumentSchema(arg)
+}
+
+// CreateInputSchema creates a JSON schema for the tool input with input objects inlined
+func (s *Schema) CreateInputSchema(field *Field) map[string]interface{} {
+	return s.CreateInputSchemaWithOptions(field, inlineOptions)
+}
+
+// CreateInputSchemaWithOptions creates a JSON schema for the tool input. Unless input objects
+// are inlined, each input object type is emitted once under $defs and referenced with $ref,
+// which also represents recursive input types exactly.
+func (s *Schema) CreateInputSchemaWithOptions(field *Field, opts JSONSchemaOptions) map[string]interface{} {
+	b := s.newJSONSchemaBuilder(opts)
 
 	properties := make(map[string]interface{})
 	required := []string{}
+	names := make([]string, 0, len(field.Args))
 
-	// Process each field in the input object
-	for _, field := range typeDef.Fields {
-		fieldSchema := s.createInputFieldSchemaFromASTWithDepth(field, visited, depth+1)
-		properties[field.Name] = fieldSchema
+	// Add arguments as properties
+	for _, arg := range field.Args {
+		properties[arg.Name] = b.argumentSchema(arg)
+		names = append(names, arg.Name)
 
 		// Add to required if it's non-null and has no default value
-		if IsASTTypeNonNull(field.Type) && (field.DefaultValue == nil || field.DefaultValue.Raw == "") {
-			required = append(required, field.Name)
+		if arg.Type.IsNonNull() && arg.DefaultValue == "" {
+			required = append(required, arg.Name)
 		}
 	}
 
@@ -70,202 +143,200 @@ func (s *Schema) createInputObjectSchemaWithDepth(typeName string, visited map[s
 	if len(required) > 0 {
 		schema["required"] = required
 	}
+	b.closeObject(schema, names)
 
-	// Add description if available
-	if typeDef.Description != "" {
-		schema["description"] = typeDef.Description
+	if len(b.defs) > 0 {
+		schema[opts.definitionsKey()] = b.defs
+	}
+
+	if opts.Dialect == JSONSchemaDraft07 {
+		schema["$schema"] = draft07SchemaURI
 	}
 
 	return schema
 }
 
-// CreateInputFieldSchemaFromAST creates a JSON schema for an input field from AST
-func (s *Schema) CreateInputFieldSchemaFromAST(field *ast.FieldDefinition) map[string]interface{} {
-	return s.createBaseSchemaFromAST(field.Type, field.Description, field.DefaultValue)
-}
+// argumentSchema creates the JSON schema for a field argument
+func (b *jsonSchemaBuilder) argumentSchema(arg *Argument) map[string]interface{} {
+	argType := arg.ASTType
+	if argType == nil {
+		argType = ConvertTypeRefToAST(arg.Type)
+	}
 
-// createInputFieldSchemaFromASTWithDepth creates a JSON schema for an input field with depth tracking
-func (s *Schema) createInputFieldSchemaFromASTWithDepth(field *ast.FieldDefinition, visited map[string]bool, depth int) map[string]interface{} {
-	return s.createBaseSchemaFromASTWithDepth(field.Type, field.Description, field.DefaultValue, visited, depth)
-}
+	var defaultValue *ast.Value
+	if arg.DefaultValue != "" {
+		defaultValue = parseDefaultValue(arg.DefaultValue)
+	}
 
-// createBaseSchemaFromAST creates a base JSON schema from AST type with common logic
-func (s *Schema) createBaseSchemaFromAST(astType *ast.Type, description string, defaultValue *ast.Value) map[string]interface{} {
-	return s.createBaseSchemaFromASTWithDepth(astType, description, defaultValue, make(map[string]bool), 0)
+	return b.typeSchema(argType, arg.Description, defaultValue, 0)
 }
 
-// createBaseSchemaFromASTWithDepth creates a base JSON schema with depth tracking
-func (s *Schema) createBaseSchemaFromASTWithDepth(astType *ast.Type, description string, defaultValue *ast.Value, visited map[string]bool, depth int) map[string]interface{} {
-	schema := map[string]interface{}{
-		"type": ASTTypeToJSONSchemaTypeWithSchema(astType, s),
-	}
-
-	// Add description if available
-	if description != "" {
-		schema["description"] = description
-	}
+// typeSchema creates the JSON schema for a value of the given GraphQL input type
+func (b *jsonSchemaBuilder) typeSchema(astType *ast.Type, description string, defaultValue *ast.Value, depth int) map[string]interface{} {
+	astType = canonicalASTType(astType)
 
-	// Handle list types
+	var schema map[string]interface{}
 	if IsASTTypeList(astType) {
-		schema["type"] = "array"
-		// For list items, use a different function that doesn't add array wrappers
-		itemSchema := s.createItemSchemaFromASTWithDepth(astType.Elem, defaultValue, visited, depth+1)
-		schema["items"] = itemSchema
-		return schema
+		schema = map[string]interface{}{
+			"type":  "array",
+			"items": b.itemSchema(astType.Elem, depth+1),
+		}
+	} else {
+		schema = b.namedTypeSchema(astType, depth)
 	}
 
-	// Handle enum types
-	typeName := GetASTTypeName(astType)
-	if typeName != "" && !isBuiltinType(typeName) {
-		// Check if it's an enum type
-		if typeDef := s.GetTypeDefinition(typeName); typeDef != nil {
-			if typeDef.Kind == ast.Enum {
-				// Add enum values to the schema
-				enumValues := GetEnumValuesFromAST(astType, s)
-				if len(enumValues) > 0 {
-					schema["enum"] = enumValues
-				}
-			}
-		}
+	// Strict schemas require every property, so optional values must accept null instead
+	if b.opts.strict() && (astType == nil || !astType.NonNull || defaultValue != nil) {
+		schema = nullableSchema(schema)
+	}
 
-		// Check for input object types
-		if inputObjectSchema := s.createInputObjectSchemaWithDepth(typeName, visited, depth+1); inputObjectSchema != nil {
-			// Handle nested input object types
-			// Merge the input object schema with the current schema
-			for key, value := range inputObjectSchema {
-				schema[key] = value
-			}
+	// Add description if available, keeping the description of an inlined input object after it
+	if description != "" {
+		if typeDescription, ok := schema["description"].(string); ok && typeDescription != "" {
+			description += "\n" + typeDescription
 		}
+		schema["description"] = description
 	}
 
 	// Add default value if available
 	if defaultValue != nil {
-		schema["default"] = convertDefaultValue(defaultValue, astType)
+		if b.opts.strict() {
+			appendDescription(schema, "Defaults to "+FormatValueLiteral(defaultValue)+" when null.")
+		} else {
+			schema["default"] = b.schema.convertDefaultValue(defaultValue, astType)
+		}
 	}
 
-	return schema
-}
-
-// createItemSchemaFromAST creates a JSON schema for list items without adding array wrappers
-func (s *Schema) createItemSchemaFromAST(astType *ast.Type, defaultValue *ast.Value) map[string]interface{} {
-	return s.createItemSchemaFromASTWithDepth(astType, defaultValue, make(map[string]bool), 0)
-}
-
-// createItemSchemaFromASTWithDepth creates a JSON schema for list items with depth tracking
-func (s *Schema) createItemSchemaFromASTWithDepth(astType *ast.Type, defaultValue *ast.Value, visited map[string]bool, depth int) map[string]interface{} {
-	if astType == nil {
-		return map[string]interface{}{"type": "string"}
+	// Draft-07 ignores keywords next to $ref, so the reference is moved into allOf
+	if ref, ok := schema["$ref"]; ok && len(schema) > 1 && b.opts.Dialect == JSONSchemaDraft07 {
+		delete(schema, "$ref")
+		schema["allOf"] = []interface{}{map[string]interface{}{"$ref": ref}}
 	}
 
-	// Handle the innermost type (scalar or named type)
-	if astType.NamedType != "" {
-		schema := map[string]interface{}{
-			"type": ASTTypeToJSONSchemaTypeWithSchema(astType, s),
-		}
+	return schema
+}
 
-		// Handle enum types for list items
-		typeName := GetASTTypeName(astType)
-		if typeName != "" && !isBuiltinType(typeName) {
-			if typeDef := s.GetTypeDefinition(typeName); typeDef != nil && typeDef.Kind == "ENUM" {
-				// Add enum values to the schema
-				enumValues := GetEnumValuesFromAST(astType, s)
-				if len(enumValues) > 0 {
-					schema["enum"] = enumValues
-				}
-			}
+// nullableSchema allows null in addition to the values accepted by a schema
+func nullableSchema(schema map[string]interface{}) map[string]interface{} {
+	if _, ok := schema["$ref"]; ok {
+		return map[string]interface{}{
+			"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}},
 		}
-
-		return schema
 	}
 
-	// Handle wrapper types (NON_NULL)
-	if astType.NonNull {
-		return s.createItemSchemaFromASTWithDepth(astType.Elem, defaultValue, visited, depth+1)
+	if typeName, ok := schema["type"].(string); ok {
+		schema["type"] = []string{typeName, "null"}
+	}
+	if enumValues, ok := schema["enum"].([]string); ok {
+		values := make([]interface{}, 0, len(enumValues)+1)
+		for _, value := range enumValues {
+			values = append(values, value)
+		}
+		schema["enum"] = append(values, nil)
 	}
+	return schema
+}
 
-	// Handle LIST types - this shouldn't happen in normal cases, but handle it
-	if astType.Elem != nil {
-		return s.createItemSchemaFromASTWithDepth(astType.Elem, defaultValue, visited, depth+1)
+// closeObject applies the object rules of strict schemas: no additional properties, and every
+// property listed in required in field order
+func (b *jsonSchemaBuilder) closeObject(schema map[string]interface{}, propertyNames []string) {
+	if !b.opts.strict() {
+		return
 	}
 
-	return map[string]interface{}{"type": "string"}
+	schema["additionalProperties"] = false
+	schema["required"] = propertyNames
 }
 
-// CreateTypeRefSchema creates a JSON schema for a TypeRef
-func (s *Schema) CreateTypeRefSchema(typeRef *TypeRef, description string) map[string]interface{} {
-	return s.createBaseSchemaFromTypeRef(typeRef, description, "")
+// itemSchema creates the JSON schema for the items of a list type
+func (b *jsonSchemaBuilder) itemSchema(astType *ast.Type, depth int) map[string]interface{} {
+	if astType == nil {
+		return map[string]interface{}{"type": "string"}
+	}
+	return b.typeSchema(astType, "", nil, depth)
 }
 
-// createBaseSchemaFromTypeRef creates a base JSON schema from TypeRef with common logic
-func (s *Schema) createBaseSchemaFromTypeRef(typeRef *TypeRef, description, defaultValue string) map[string]interface{} {
-	schema := map[string]interface{}{
-		"type": typeRef.ToJSONSchemaTypeWithSchema(s),
+// namedTypeSchema creates the JSON schema for a scalar, enum or input object type
+func (b *jsonSchemaBuilder) namedTypeSchema(astType *ast.Type, depth int) map[string]interface{} {
+	typeName := GetASTTypeName(astType)
+	if typeName != "" && !isBuiltinType(typeName) {
+		if typeDef := b.schema.GetTypeDefinition(typeName); typeDef != nil && typeDef.Kind == ast.InputObject {
+			return b.inputObjectReference(typeDef, depth)
+		}
 	}
 
-	// Add description if available
-	if description != "" {
-		schema["description"] = description
+	schema := map[string]interface{}{
+		"type": ASTTypeToJSONSchemaTypeWithSchema(astType, b.schema),
 	}
 
-	// Handle list types
-	if typeRef.IsList() {
-		schema["type"] = "array"
-		itemSchema := s.createBaseSchemaFromTypeRef(typeRef.OfType, description, defaultValue)
-		schema["items"] = itemSchema
-		return schema
-	}
-
-	// Handle enum types and input object types
-	if typeRef.GetTypeName() != "" && !isBuiltinType(typeRef.GetTypeName()) {
-		// Check if it's an enum type
-		if typeDef := s.GetTypeDefinition(typeRef.GetTypeName()); typeDef != nil {
-			if typeDef.Kind == ast.Enum {
-				// Add enum values to the schema
-				enumValues := GetEnumValuesFromTypeRef(typeRef, s)
-				if len(enumValues) > 0 {
-					schema["enum"] = enumValues
-				}
+	// Add enum values, or the mapping of a custom scalar, to the schema
+	if typeDef := b.schema.GetTypeDefinition(typeName); typeDef != nil {
+		switch typeDef.Kind {
+		case ast.Enum:
+			b.enumSchema(schema, typeDef)
+		case ast.Scalar:
+			if scalar, ok := b.opts.Scalars[typeName]; ok && !isBuiltinType(typeName) {
+				scalar.apply(schema)
 			}
 		}
+	}
 
-		// Check for input object types
-		if inputObjectSchema := s.CreateInputObjectSchema(typeRef.GetTypeName()); inputObjectSchema != nil {
-			// Handle input object types - but don't flatten them when creating argument schemas
-			// For input object types, we want to reference the schema, not flatten it
-			// This preserves the proper nesting structure for arguments
-			schema["properties"] = inputObjectSchema["properties"]
-			if required, ok := inputObjectSchema["required"].([]string); ok && len(required) > 0 {
-				schema["required"] = required
-			}
+	return schema
+}
+
+// inputObjectReference returns a $ref to the shared definition of an input object, creating
+// the definition on first use, or the inlined object schema when references are disabled
+func (b *jsonSchemaBuilder) inputObjectReference(typeDef *ast.Definition, depth int) map[string]interface{} {
+	if !b.opts.InlineInputObjects {
+		if _, exists := b.defs[typeDef.Name]; !exists {
+			// Reserve the name first so that recursive references resolve to it
+			b.defs[typeDef.Name] = map[string]interface{}{}
+			b.defs[typeDef.Name] = b.inputObjectSchema(typeDef, 0)
 		}
+		return map[string]interface{}{"$ref": "#/" + b.opts.definitionsKey() + "/" + typeDef.Name}
 	}
 
-	// Add default value if available
-	if defaultValue != "" {
-		schema["default"] = convertDefaultValueFromString(defaultValue, typeRef)
+	// Check recursion depth to prevent unbounded schemas
+	if depth > maxInlineDepth {
+		stub := map[string]interface{}{
+			"type":        "object",
+			"description": fmt.Sprintf("Recursive type %s (max depth %d exceeded)", typeDef.Name, maxInlineDepth),
+			"properties":  map[string]interface{}{},
+		}
+		b.closeObject(stub, []string{})
+		return stub
 	}
 
-	return schema
-}
+	// Check for circular references
+	if b.visited[typeDef.Name] {
+		stub := map[string]interface{}{
+			"type":        "object",
+			"description": fmt.Sprintf("Circular reference to %s", typeDef.Name),
+			"properties":  map[string]interface{}{},
+		}
+		b.closeObject(stub, []string{})
+		return stub
+	}
 
-// CreateArgumentSchema creates a JSON schema for a GraphQL argument
-func (s *Schema) CreateArgumentSchema(arg *Argument) map[string]interface{} {
-	return s.createBaseSchemaFromTypeRef(arg.Type, arg.Description, arg.DefaultValue)
+	b.visited[typeDef.Name] = true
+	defer delete(b.visited, typeDef.Name)
+	return b.inputObjectSchema(typeDef, depth+1)
 }
 
-// CreateInputSchema creates a JSON schema for the tool input
-func (s *Schema) CreateInputSchema(field *Field) map[string]interface{} {
+// inputObjectSchema creates the object schema for the fields of an input object type
+func (b *jsonSchemaBuilder) inputObjectSchema(typeDef *ast.Definition, depth int) map[string]interface{} {
 	properties := make(map[string]interface{})
 	required := []string{}
+	names := make([]string, 0, len(typeDef.Fields))
 
-	// Add arguments as properties
-	for _, arg := range field.Args {
-		argSchema := s.CreateArgumentSchema(arg)
-		properties[arg.Name] = argSchema
+	// Process each field in the input object
+	for _, field := range typeDef.Fields {
+		properties[field.Name] = b.typeSchema(field.Type, field.Description, field.DefaultValue, depth+1)
+		names = append(names, field.Name)
 
 		// Add to required if it's non-null and has no default value
-		if arg.Type.IsNonNull() && arg.DefaultValue == "" {
-			required = append(required, arg.Name)
+		if IsASTTypeNonNull(field.Type) && field.DefaultValue == nil {
+			required = append(required, field.Name)
 		}
 	}
 
@@ -278,92 +349,24 @@ func (s *Schema) CreateInputSchema(field *Field) map[string]interface{} {
 		schema["required"] = required
 	}
 
-	return schema
-}
-
-// convertDefaultValue converts a GraphQL default value to the appropriate JSON type
-func convertDefaultValue(defaultValue *ast.Value, astType *ast.Type) interface{} {
-	if defaultValue == nil {
-		return nil
+	// Add description if available
+	if typeDef.Description != "" {
+		schema["description"] = typeDef.Description
 	}
 
-	// Get the base type name
-	baseType := GetASTTypeName(astType)
-
-	// Convert based on the GraphQL type
-	switch baseType {
-	case "Boolean":
-		if defaultValue.Raw == "true" {
-			return true
-		} else if defaultValue.Raw == "false" {
-			return false
-		}
-		// Fallback to string if not a valid boolean
-		return defaultValue.Raw
-	case "Int":
-		if intVal, err := strconv.Atoi(defaultValue.Raw); err == nil {
-			return intVal
-		}
-		// Fallback to string if not a valid int
-		return defaultValue.Raw
-	case "Float":
-		if floatVal, err := strconv.ParseFloat(defaultValue.Raw, 64); err == nil {
-			return floatVal
-		}
-		// Fallback to string if not a valid float
-		return defaultValue.Raw
-	case "String", "ID":
-		// Remove quotes if present
-		raw := defaultValue.Raw
-		if len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"' {
-			return raw[1 : len(raw)-1]
-		}
-		return raw
-	default:
-		// For other types (enums, objects, etc.), return as string
-		return defaultValue.Raw
+	if IsOneOfInputObject(typeDef) {
+		applyOneOfSchema(schema, typeDef, b.opts)
 	}
+	b.closeObject(schema, names)
+
+	return schema
 }
 
-// convertDefaultValueFromString converts a string default value to the appropriate JSON type based on TypeRef
-func convertDefaultValueFromString(defaultValue string, typeRef *TypeRef) interface{} {
-	if defaultValue == "" {
+// convertDefaultValue converts a GraphQL default value to the appropriate JSON value,
+// including objects, lists and enum values
+func (s *Schema) convertDefaultValue(defaultValue *ast.Value, astType *ast.Type) interface{} {
+	if defaultValue == nil {
 		return nil
 	}
-
-	// Get the base type name
-	baseType := typeRef.GetTypeName()
-
-	// Convert based on the GraphQL type
-	switch baseType {
-	case "Boolean":
-		if defaultValue == "true" {
-			return true
-		} else if defaultValue == "false" {
-			return false
-		}
-		// Fallback to string if not a valid boolean
-		return defaultValue
-	case "Int":
-		if intVal, err := strconv.Atoi(defaultValue); err == nil {
-			return intVal
-		}
-		// Fallback to string if not a valid int
-		return defaultValue
-	case "Float":
-		if floatVal, err := strconv.ParseFloat(defaultValue, 64); err == nil {
-			return floatVal
-		}
-		// Fallback to string if not a valid float
-		return defaultValue
-	case "String", "ID":
-		// Remove quotes if present
-		if len(defaultValue) >= 2 && defaultValue[0] == '"' && defaultValue[len(defaultValue)-1] == '"' {
-			return defaultValue[1 : len(defaultValue)-1]
-		}
-		return defaultValue
-	default:
-		// For other types (enums, objects, etc.), return as string
-		return defaultValue
-	}
+	return s.valueToJSON(defaultValue, astType)
 }
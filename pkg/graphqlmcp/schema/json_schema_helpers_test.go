@@ -303,6 +303,181 @@ func TestSchema_CreateInputObjectSchema_RealData(t *testing.T) {
 	}
 }
 
+// refsTestSchema builds a schema with a shared input type and a recursive filter input
+func refsTestSchema() (*Schema, *Field) {
+	s := &Schema{
+		typeRegistry: map[string]*ast.Definition{
+			"AddressInput": {
+				Name: "AddressInput",
+				Kind: ast.InputObject,
+				Fields: []*ast.FieldDefinition{
+					{Name: "street", Type: ast.NonNullNamedType("String", nil)},
+				},
+			},
+			"FacilityFilter": {
+				Name:        "FacilityFilter",
+				Kind:        ast.InputObject,
+				Description: "Filter tree for facilities",
+				Fields: []*ast.FieldDefinition{
+					{Name: "name", Type: ast.NamedType("String", nil)},
+					{Name: "address", Type: ast.NamedType("AddressInput", nil)},
+					{Name: "and", Type: ast.ListType(ast.NonNullNamedType("FacilityFilter", nil), nil)},
+				},
+			},
+		},
+	}
+
+	field := &Field{
+		Name: "facilities",
+		Args: []*Argument{
+			{Name: "where", Type: ConvertTypeFromAST(ast.NamedType("FacilityFilter", nil))},
+			{Name: "near", Type: ConvertTypeFromAST(ast.NamedType("AddressInput", nil))},
+		},
+	}
+	return s, field
+}
+
+func TestSchema_CreateInputSchemaWithOptions_Refs(t *testing.T) {
+	s, field := refsTestSchema()
+	result := s.CreateInputSchemaWithOptions(field, JSONSchemaOptions{})
+
+	properties := result["properties"].(map[string]interface{})
+	if !mapsEqual(properties["where"], map[string]interface{}{"$ref": "#/$defs/FacilityFilter"}) {
+		t.Errorf("where = %v, want $ref to FacilityFilter", properties["where"])
+	}
+	if !mapsEqual(properties["near"], map[string]interface{}{"$ref": "#/$defs/AddressInput"}) {
+		t.Errorf("near = %v, want $ref to AddressInput", properties["near"])
+	}
+
+	defs, ok := result["$defs"].(map[string]interface{})
+	if !ok || len(defs) != 2 {
+		t.Fatalf("$defs = %v, want AddressInput and FacilityFilter", result["$defs"])
+	}
+
+	filter := defs["FacilityFilter"].(map[string]interface{})
+	if filter["description"] != "Filter tree for facilities" {
+		t.Errorf("FacilityFilter description = %v", filter["description"])
+	}
+	filterProps := filter["properties"].(map[string]interface{})
+	expectedAnd := map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"$ref": "#/$defs/FacilityFilter"},
+	}
+	if !mapsEqual(filterProps["and"], expectedAnd) {
+		t.Errorf("FacilityFilter.and = %v, want %v", filterProps["and"], expectedAnd)
+	}
+	if !mapsEqual(filterProps["address"], map[string]interface{}{"$ref": "#/$defs/AddressInput"}) {
+		t.Errorf("FacilityFilter.address = %v, want $ref to AddressInput", filterProps["address"])
+	}
+
+	// The result must be plain JSON
+	if _, err := json.Marshal(result); err != nil {
+		t.Errorf("json.Marshal() error = %v", err)
+	}
+}
+
+func TestSchema_CreateInputSchemaWithOptions_Inline(t *testing.T) {
+	s, field := refsTestSchema()
+	result := s.CreateInputSchemaWithOptions(field, JSONSchemaOptions{InlineInputObjects: true})
+
+	if _, ok := result["$defs"]; ok {
+		t.Error("inlined schema should not contain $defs")
+	}
+
+	near := result["properties"].(map[string]interface{})["near"].(map[string]interface{})
+	if near["type"] != "object" || !mapsEqual(near["required"], []string{"street"}) {
+		t.Errorf("near = %v, want inlined AddressInput", near)
+	}
+
+	where := result["properties"].(map[string]interface{})["where"].(map[string]interface{})
+	and := where["properties"].(map[string]interface{})["and"].(map[string]interface{})
+	items := and["items"].(map[string]interface{})
+	if items["description"] != "Circular reference to FacilityFilter" {
+		t.Errorf("recursive items = %v, want circular reference stub", items)
+	}
+}
+
+func TestSchema_CreateInputSchemaWithOptions_Dialects(t *testing.T) {
+	s, field := refsTestSchema()
+	field.Args[1].Description = "Reference address"
+	field.Args = append(field.Args, &Argument{
+		Name:         "limit",
+		Type:         ConvertTypeFromAST(ast.NamedType("Int", nil)),
+		DefaultValue: "10",
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		result := s.CreateInputSchemaWithOptions(field, JSONSchemaOptions{Dialect: JSONSchemaStrict})
+
+		if result["additionalProperties"] != false || !mapsEqual(result["required"], []string{"where", "near", "limit"}) {
+			t.Errorf("root object = %v, want closed object requiring every argument", result)
+		}
+
+		properties := result["properties"].(map[string]interface{})
+		expectedWhere := map[string]interface{}{
+			"anyOf": []interface{}{
+				map[string]interface{}{"$ref": "#/$defs/FacilityFilter"},
+				map[string]interface{}{"type": "null"},
+			},
+		}
+		if !mapsEqual(properties["where"], expectedWhere) {
+			t.Errorf("where = %v, want %v", properties["where"], expectedWhere)
+		}
+
+		limit := properties["limit"].(map[string]interface{})
+		if _, ok := limit["default"]; ok {
+			t.Error("strict schemas should not contain default")
+		}
+		if limit["description"] != "Defaults to 10 when null." {
+			t.Errorf("limit description = %v", limit["description"])
+		}
+		if !mapsEqual(limit["type"], []string{"integer", "null"}) {
+			t.Errorf("limit type = %v, want [integer null]", limit["type"])
+		}
+
+		filter := result["$defs"].(map[string]interface{})["FacilityFilter"].(map[string]interface{})
+		if filter["additionalProperties"] != false || !mapsEqual(filter["required"], []string{"name", "address", "and"}) {
+			t.Errorf("FacilityFilter = %v, want closed object requiring every field", filter)
+		}
+		name := filter["properties"].(map[string]interface{})["name"].(map[string]interface{})
+		if !mapsEqual(name["type"], []string{"string", "null"}) {
+			t.Errorf("FacilityFilter.name type = %v, want [string null]", name["type"])
+		}
+
+		address := result["$defs"].(map[string]interface{})["AddressInput"].(map[string]interface{})
+		street := address["properties"].(map[string]interface{})["street"].(map[string]interface{})
+		if street["type"] != "string" {
+			t.Errorf("non-null AddressInput.street type = %v, want string", street["type"])
+		}
+	})
+
+	t.Run("draft-07", func(t *testing.T) {
+		result := s.CreateInputSchemaWithOptions(field, JSONSchemaOptions{Dialect: JSONSchemaDraft07})
+
+		if result["$schema"] != draft07SchemaURI {
+			t.Errorf("$schema = %v, want %s", result["$schema"], draft07SchemaURI)
+		}
+		if _, ok := result["definitions"].(map[string]interface{})["FacilityFilter"]; !ok {
+			t.Errorf("definitions = %v, want FacilityFilter", result["definitions"])
+		}
+
+		properties := result["properties"].(map[string]interface{})
+		if !mapsEqual(properties["where"], map[string]interface{}{"$ref": "#/definitions/FacilityFilter"}) {
+			t.Errorf("where = %v, want $ref to definitions", properties["where"])
+		}
+		expectedNear := map[string]interface{}{
+			"description": "Reference address",
+			"allOf":       []interface{}{map[string]interface{}{"$ref": "#/definitions/AddressInput"}},
+		}
+		if !mapsEqual(properties["near"], expectedNear) {
+			t.Errorf("near = %v, want %v", properties["near"], expectedNear)
+		}
+		if properties["limit"].(map[string]interface{})["default"] != 10 {
+			t.Errorf("limit default = %v, want 10", properties["limit"])
+		}
+	})
+}
+
 func TestSchema_createItemSchemaFromAST(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,58 @@
+package schema
+
+import (
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// oneOfDirective is the directive that marks an input object where exactly one field must be set
+const oneOfDirective = "oneOf"
+
+// IsOneOfInputObject reports whether a type definition is a @oneOf input object
+func IsOneOfInputObject(typeDef *ast.Definition) bool {
+	return typeDef != nil && typeDef.Kind == ast.InputObject && typeDef.Directives.ForName(oneOfDirective) != nil
+}
+
+// applyOneOfSchema constrains an input object JSON schema to exactly one of its fields,
+// with one branch per field. Strict schemas cannot express the constraint, so they only
+// describe it and rely on input validation.
+func applyOneOfSchema(schema map[string]interface{}, typeDef *ast.Definition, opts JSONSchemaOptions) {
+	if !opts.strict() {
+		branches := make([]interface{}, 0, len(typeDef.Fields))
+		for _, field := range typeDef.Fields {
+			branches = append(branches, map[string]interface{}{
+				"required": []string{field.Name},
+			})
+		}
+
+		schema["oneOf"] = branches
+		schema["minProperties"] = 1
+		schema["maxProperties"] = 1
+	}
+	delete(schema, "required")
+
+	note := "Exactly one of " + fieldNames(typeDef) + " must be provided."
+	if opts.strict() {
+		note = "Exactly one of " + fieldNames(typeDef) + " must be non-null."
+	}
+	if description, ok := schema["description"].(string); ok && description != "" {
+		schema["description"] = description + "\n" + note
+	} else {
+		schema["description"] = note
+	}
+}
+
+// validateOneOf enforces that exactly one non-null field of a @oneOf input object is provided
+func validateOneOf(obj map[string]interface{}, typeDef *ast.Definition, path string, errs *InputErrors) {
+	var provided []string
+	for _, name := range sortedMapKeys(obj) {
+		if typeDef.Fields.ForName(name) != nil {
+			provided = append(provided, name)
+		}
+	}
+	switch {
+	case len(provided) != 1:
+		errs.add(path, "%s requires exactly one of: %s; got %d", typeDef.Name, fieldNames(typeDef), len(provided))
+	case obj[provided[0]] == nil:
+		errs.add(path+"."+provided[0], "the field set on %s must not be null", typeDef.Name)
+	}
+}
@@ -0,0 +1,125 @@
+package schema
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// oneOfIntrospection builds an introspection response with a @oneOf lookup input
+func oneOfIntrospection() map[string]interface{} {
+	named := func(kind, name string) map[string]interface{} {
+		return map[string]interface{}{"kind": kind, "name": name}
+	}
+
+	return map[string]interface{}{
+		"__schema": map[string]interface{}{
+			"queryType": map[string]interface{}{"name": "Query"},
+			"types": []interface{}{
+				map[string]interface{}{
+					"name": "Query",
+					"kind": "OBJECT",
+					"fields": []interface{}{
+						map[string]interface{}{
+							"name": "equipment",
+							"type": named("SCALAR", "String"),
+							"args": []interface{}{
+								map[string]interface{}{
+									"name": "by",
+									"type": map[string]interface{}{"kind": "NON_NULL", "ofType": named("INPUT_OBJECT", "EquipmentLookup")},
+								},
+							},
+						},
+					},
+				},
+				map[string]interface{}{
+					"name":    "EquipmentLookup",
+					"kind":    "INPUT_OBJECT",
+					"isOneOf": true,
+					"inputFields": []interface{}{
+						map[string]interface{}{"name": "id", "type": named("SCALAR", "ID")},
+						map[string]interface{}{"name": "serialNumber", "type": named("SCALAR", "String")},
+						map[string]interface{}{"name": "assetTag", "type": named("SCALAR", "String")},
+					},
+				},
+				map[string]interface{}{"name": "String", "kind": "SCALAR"},
+				map[string]interface{}{"name": "ID", "kind": "SCALAR"},
+			},
+		},
+	}
+}
+
+func TestOneOfInputObject(t *testing.T) {
+	s, err := ParseIntrospectionResponse(oneOfIntrospection())
+	if err != nil {
+		t.Fatalf("ParseIntrospectionResponse() error = %v", err)
+	}
+
+	if !IsOneOfInputObject(s.GetTypeDefinition("EquipmentLookup")) {
+		t.Fatal("EquipmentLookup should be detected as @oneOf")
+	}
+
+	field := s.GetQueries()[0]
+
+	t.Run("JSON schema", func(t *testing.T) {
+		by := s.CreateInputSchema(field)["properties"].(map[string]interface{})["by"].(map[string]interface{})
+
+		expectedBranches := []interface{}{
+			map[string]interface{}{"required": []string{"id"}},
+			map[string]interface{}{"required": []string{"serialNumber"}},
+			map[string]interface{}{"required": []string{"assetTag"}},
+		}
+		if !reflect.DeepEqual(by["oneOf"], expectedBranches) {
+			t.Errorf("oneOf = %#v, want %#v", by["oneOf"], expectedBranches)
+		}
+		if by["minProperties"] != 1 || by["maxProperties"] != 1 {
+			t.Errorf("minProperties/maxProperties = %v/%v, want 1/1", by["minProperties"], by["maxProperties"])
+		}
+		if _, ok := by["required"]; ok {
+			t.Error("@oneOf input should not list required fields")
+		}
+		if !strings.Contains(by["description"].(string), "Exactly one of id, serialNumber, assetTag") {
+			t.Errorf("description = %q, want the oneOf note", by["description"])
+		}
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			by       map[string]interface{}
+			expected string
+		}{
+			{name: "exactly one", by: map[string]interface{}{"serialNumber": "SN-1"}},
+			{name: "none", by: map[string]interface{}{}, expected: "by: EquipmentLookup requires exactly one of: id, serialNumber, assetTag; got 0"},
+			{name: "several", by: map[string]interface{}{"id": "1", "assetTag": "A"}, expected: "by: EquipmentLookup requires exactly one of: id, serialNumber, assetTag; got 2"},
+			{name: "null", by: map[string]interface{}{"id": nil}, expected: "by.id: the field set on EquipmentLookup must not be null"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := s.CoerceArguments(field, map[string]interface{}{"by": tt.by})
+				if tt.expected == "" {
+					if err != nil {
+						t.Errorf("CoerceArguments() error = %v", err)
+					}
+					return
+				}
+
+				var inputErrs InputErrors
+				if !errors.As(err, &inputErrs) || len(inputErrs) != 1 {
+					t.Fatalf("CoerceArguments() error = %v, want one InputError", err)
+				}
+				if inputErrs[0].Error() != tt.expected {
+					t.Errorf("CoerceArguments() error = %q, want %q", inputErrs[0].Error(), tt.expected)
+				}
+			})
+		}
+	})
+
+	t.Run("SDL", func(t *testing.T) {
+		if sdl := s.GetSchemaSDL(); !strings.Contains(sdl, "input EquipmentLookup @oneOf {") {
+			t.Errorf("GetSchemaSDL() missing @oneOf directive:\n%s", sdl)
+		}
+	})
+}
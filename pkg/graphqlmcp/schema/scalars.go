@@ -0,0 +1,67 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ScalarSchema describes the JSON schema of the values of a custom scalar, such as DateTime
+type ScalarSchema struct {
+	// Type is the JSON type of the values: "string" (the default), "integer", "number",
+	// "boolean", "object" or "array"
+	Type string `yaml:"type"`
+	// Format is a JSON Schema format such as "date-time", "uuid" or "uri"
+	Format string `yaml:"format"`
+	// Pattern is a regular expression string values must match
+	Pattern string `yaml:"pattern"`
+	// Description explains the values, e.g. "An ISO 8601 timestamp"
+	Description string `yaml:"description"`
+}
+
+// scalarJSONTypes are the JSON types a custom scalar can be mapped to
+var scalarJSONTypes = map[string]bool{
+	"string": true, "integer": true, "number": true, "boolean": true, "object": true, "array": true,
+}
+
+// ValidateScalars checks that the scalar mappings name custom scalars and valid JSON types
+// and patterns
+func (opts JSONSchemaOptions) ValidateScalars() error {
+	for name, scalar := range opts.Scalars {
+		if isBuiltinType(name) {
+			return fmt.Errorf("invalid scalar mapping %s: built-in scalars cannot be mapped", name)
+		}
+		if err := scalar.Validate(); err != nil {
+			return fmt.Errorf("invalid scalar mapping %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the JSON type and pattern of the mapping
+func (s ScalarSchema) Validate() error {
+	if s.Type != "" && !scalarJSONTypes[s.Type] {
+		return fmt.Errorf("unknown JSON type %q", s.Type)
+	}
+	if s.Pattern != "" {
+		if _, err := regexp.Compile(s.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	return nil
+}
+
+// apply sets the type, format, pattern and description of the mapping on a schema
+func (s ScalarSchema) apply(schema map[string]interface{}) {
+	if s.Type != "" {
+		schema["type"] = s.Type
+	}
+	if s.Format != "" {
+		schema["format"] = s.Format
+	}
+	if s.Pattern != "" {
+		schema["pattern"] = s.Pattern
+	}
+	if s.Description != "" {
+		schema["description"] = s.Description
+	}
+}
@@ -0,0 +1,77 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchema_CreateInputSchemaWithOptions_Scalars(t *testing.T) {
+	s, err := ParseSDL(`
+scalar DateTime
+scalar JSON
+
+type Query {
+  events(since: DateTime, until: DateTime!, filter: JSON, id: ID): [String]
+}
+`)
+	if err != nil {
+		t.Fatalf("ParseSDL() error = %v", err)
+	}
+	field := s.GetQueries()[0]
+
+	opts := JSONSchemaOptions{Scalars: map[string]ScalarSchema{
+		"DateTime": {Format: "date-time", Description: "An ISO 8601 timestamp"},
+		"JSON":     {Type: "object"},
+	}}
+	field.Args[0].Description = "Start of the range"
+	properties := s.CreateInputSchemaWithOptions(field, opts)["properties"].(map[string]interface{})
+
+	expectedSince := map[string]interface{}{
+		"type":        "string",
+		"format":      "date-time",
+		"description": "Start of the range\nAn ISO 8601 timestamp",
+	}
+	if !mapsEqual(properties["since"], expectedSince) {
+		t.Errorf("since = %v, want %v", properties["since"], expectedSince)
+	}
+	if !mapsEqual(properties["filter"], map[string]interface{}{"type": "object"}) {
+		t.Errorf("filter = %v, want object", properties["filter"])
+	}
+	if !mapsEqual(properties["id"], map[string]interface{}{"type": "string"}) {
+		t.Errorf("id = %v, want plain string", properties["id"])
+	}
+
+	strict := s.CreateInputSchemaWithOptions(field, JSONSchemaOptions{Dialect: JSONSchemaStrict, Scalars: opts.Scalars})
+	filter := strict["properties"].(map[string]interface{})["filter"].(map[string]interface{})
+	if !mapsEqual(filter["type"], []string{"object", "null"}) {
+		t.Errorf("strict filter type = %v, want [object null]", filter["type"])
+	}
+}
+
+func TestJSONSchemaOptions_ValidateScalars(t *testing.T) {
+	tests := []struct {
+		name     string
+		scalars  map[string]ScalarSchema
+		expected string
+	}{
+		{name: "valid", scalars: map[string]ScalarSchema{"DateTime": {Type: "string", Pattern: "^\\d{4}-"}}},
+		{name: "built-in", scalars: map[string]ScalarSchema{"Int": {Type: "string"}}, expected: "invalid scalar mapping Int: built-in scalars cannot be mapped"},
+		{name: "unknown type", scalars: map[string]ScalarSchema{"Money": {Type: "decimal"}}, expected: `invalid scalar mapping Money: unknown JSON type "decimal"`},
+		{name: "invalid pattern", scalars: map[string]ScalarSchema{"Code": {Pattern: "["}}, expected: "invalid scalar mapping Code: invalid pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := JSONSchemaOptions{Scalars: tt.scalars}.ValidateScalars()
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("ValidateScalars() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("ValidateScalars() error = %v, want %q", err, tt.expected)
+			}
+		})
+	}
+}
@@ -188,3 +188,8 @@ func (s *Schema) GetMaxDepth() int {
 	}
 	return s.MaxDepth
 }
+
+// IsFieldExcluded reports whether a field of a type is left out of generated selection sets
+func (s *Schema) IsFieldExcluded(typeName, fieldName string) bool {
+	return s.ExcludedFields[typeName+"."+fieldName]
+}
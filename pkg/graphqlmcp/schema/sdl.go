@@ -13,7 +13,7 @@ func (s *Schema) generateTypeSDL(typeDef *ast.Definition) string {
 
 	// Add description if present
 	if typeDef.Description != "" {
-		sdl.WriteString(fmt.Sprintf("\"%s\"\n", typeDef.Description))
+		sdl.WriteString(formatDescriptionSDL(typeDef.Description, ""))
 	}
 
 	// Generate type definition based on kind
@@ -47,14 +47,22 @@ func (s *Schema) generateTypeSDL(typeDef *ast.Definition) string {
 		sdl.WriteString(fmt.Sprintf("enum %s {\n", typeDef.Name))
 		for _, enumValue := range typeDef.EnumValues {
 			if enumValue.Description != "" {
-				sdl.WriteString(fmt.Sprintf("  \"%s\"\n", enumValue.Description))
+				sdl.WriteString(formatDescriptionSDL(enumValue.Description, "  "))
 			}
-			sdl.WriteString(fmt.Sprintf("  %s\n", enumValue.Name))
+			sdl.WriteString(fmt.Sprintf("  %s", enumValue.Name))
+			if reason, deprecated := deprecationReason(enumValue.Directives); deprecated {
+				sdl.WriteString(fmt.Sprintf(" @deprecated(reason: %s)", quoteStringSDL(reason)))
+			}
+			sdl.WriteString("\n")
 		}
 		sdl.WriteString("}")
 
 	case ast.InputObject:
-		sdl.WriteString(fmt.Sprintf("input %s {\n", typeDef.Name))
+		sdl.WriteString(fmt.Sprintf("input %s", typeDef.Name))
+		if IsOneOfInputObject(typeDef) {
+			sdl.WriteString(" @oneOf")
+		}
+		sdl.WriteString(" {\n")
 		for _, field := range typeDef.Fields {
 			sdl.WriteString(s.generateFieldSDL(field))
 		}
@@ -73,7 +81,7 @@ func (s *Schema) generateFieldSDL(field *ast.FieldDefinition) string {
 
 	// Add description if present
 	if field.Description != "" {
-		sdl.WriteString(fmt.Sprintf("  \"%s\"\n", field.Description))
+		sdl.WriteString(formatDescriptionSDL(field.Description, "  "))
 	}
 
 	// Add field name
@@ -92,7 +100,13 @@ func (s *Schema) generateFieldSDL(field *ast.FieldDefinition) string {
 	}
 
 	// Add return type
-	sdl.WriteString(fmt.Sprintf(": %s\n", s.generateTypeRefSDL(field.Type)))
+	sdl.WriteString(fmt.Sprintf(": %s", s.generateTypeRefSDL(field.Type)))
+
+	// Add default value if present (input fields)
+	if field.DefaultValue != nil {
+		sdl.WriteString(fmt.Sprintf(" = %s", FormatValueLiteral(field.DefaultValue)))
+	}
+	sdl.WriteString("\n")
 
 	return sdl.String()
 }
@@ -103,7 +117,7 @@ func (s *Schema) generateArgumentSDL(arg *ast.ArgumentDefinition) string {
 
 	// Add description if present
 	if arg.Description != "" {
-		sdl.WriteString(fmt.Sprintf("\"%s\" ", arg.Description))
+		sdl.WriteString(quoteStringSDL(arg.Description) + " ")
 	}
 
 	// Add argument name and type
@@ -111,7 +125,7 @@ func (s *Schema) generateArgumentSDL(arg *ast.ArgumentDefinition) string {
 
 	// Add default value if present
 	if arg.DefaultValue != nil {
-		sdl.WriteString(fmt.Sprintf(" = %s", arg.DefaultValue.Raw))
+		sdl.WriteString(fmt.Sprintf(" = %s", FormatValueLiteral(arg.DefaultValue)))
 	}
 
 	return sdl.String()
@@ -123,13 +137,52 @@ func (s *Schema) generateTypeRefSDL(astType *ast.Type) string {
 		return "String"
 	}
 
-	if astType.NonNull {
-		return s.generateTypeRefSDL(astType.Elem) + "!"
+	return canonicalASTType(astType).String()
+}
+
+// formatDescriptionSDL renders a description on its own line, using a block string for
+// multi-line text
+func formatDescriptionSDL(description, indent string) string {
+	if !strings.Contains(description, "\n") {
+		return indent + quoteStringSDL(description) + "\n"
 	}
 
-	if astType.Elem != nil {
-		return "[" + s.generateTypeRefSDL(astType.Elem) + "]"
+	var sdl strings.Builder
+	sdl.WriteString(indent + "\"\"\"\n")
+	for _, line := range strings.Split(strings.ReplaceAll(description, `"""`, `\"""`), "\n") {
+		if line != "" {
+			sdl.WriteString(indent + line)
+		}
+		sdl.WriteString("\n")
 	}
+	sdl.WriteString(indent + "\"\"\"\n")
+	return sdl.String()
+}
 
-	return astType.NamedType
+// quoteStringSDL renders text as a single-line GraphQL string literal
+func quoteStringSDL(text string) string {
+	var sdl strings.Builder
+	sdl.WriteByte('"')
+	for _, r := range text {
+		switch r {
+		case '"':
+			sdl.WriteString(`\"`)
+		case '\\':
+			sdl.WriteString(`\\`)
+		case '\n':
+			sdl.WriteString(`\n`)
+		case '\r':
+			sdl.WriteString(`\r`)
+		case '\t':
+			sdl.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				sdl.WriteString(fmt.Sprintf(`\u%04x`, r))
+			} else {
+				sdl.WriteRune(r)
+			}
+		}
+	}
+	sdl.WriteByte('"')
+	return sdl.String()
 }
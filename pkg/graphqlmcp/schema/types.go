@@ -16,6 +16,9 @@ type Schema struct {
 
 	// MaxDepth controls the maximum depth for query generation
 	MaxDepth int `json:"maxDepth"`
+
+	// ExcludedFields lists the fields left out of generated selection sets, keyed by "Type.field"
+	ExcludedFields map[string]bool `json:"-"`
 }
 
 // Type represents a GraphQL type
@@ -47,7 +50,10 @@ type Argument struct {
 	Name         string   `json:"name"`
 	Description  string   `json:"description"`
 	Type         *TypeRef `json:"type"`
-	DefaultValue string   `json:"defaultValue"`
+	DefaultValue string   `json:"defaultValue"` // GraphQL literal, e.g. {limit: 10, sort: ASC}
+
+	// AST type information for input validation and coercion
+	ASTType *ast.Type `json:"-"`
 }
 
 // TypeRef represents a GraphQL type reference
@@ -0,0 +1,388 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// InputError describes a single invalid value in tool input
+type InputError struct {
+	// Path addresses the offending value, e.g. "input.specifications.dimensions.length" or "tags[2]"
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+func (e *InputError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// InputErrors collects every problem found while validating tool input
+type InputErrors []*InputError
+
+// Error implements the error interface
+func (e InputErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// add records a new input error
+func (e *InputErrors) add(path, format string, args ...interface{}) {
+	*e = append(*e, &InputError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// CoerceArguments validates tool input against the arguments of a field and applies GraphQL
+// input coercion to safe cases: numeric strings become Int or Float, enum values are matched
+// case-insensitively, and single values are wrapped in a list where a list is expected.
+// It returns a coerced copy of the input, or InputErrors describing every invalid value.
+func (s *Schema) CoerceArguments(field *Field, input map[string]interface{}) (map[string]interface{}, error) {
+	var errs InputErrors
+	coerced := make(map[string]interface{}, len(input))
+
+	known := make(map[string]bool, len(field.Args))
+	for _, arg := range field.Args {
+		known[arg.Name] = true
+		argType := arg.ASTType
+		if argType == nil {
+			argType = ConvertTypeRefToAST(arg.Type)
+		}
+		argType = canonicalASTType(argType)
+
+		value, present := input[arg.Name]
+		if !present {
+			if argType.NonNull && arg.DefaultValue == "" {
+				errs.add(arg.Name, "missing required argument of type %s", argType.String())
+			}
+			continue
+		}
+		coerced[arg.Name] = s.coerceInputValue(value, argType, arg.Name, &errs)
+	}
+
+	for _, name := range sortedMapKeys(input) {
+		if !known[name] {
+			errs.add(name, "unknown argument; expected one of: %s", argumentNames(field))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return coerced, nil
+}
+
+// coerceInputValue coerces a single value to the given GraphQL input type
+func (s *Schema) coerceInputValue(value interface{}, astType *ast.Type, path string, errs *InputErrors) interface{} {
+	astType = canonicalASTType(astType)
+	if astType == nil {
+		return value
+	}
+
+	if value == nil {
+		if astType.NonNull {
+			errs.add(path, "expected non-null %s, got null", astType.String())
+		}
+		return nil
+	}
+
+	// Lists accept either a list or a single value, which is wrapped per GraphQL input coercion
+	if astType.Elem != nil {
+		items, ok := value.([]interface{})
+		if !ok {
+			return []interface{}{s.coerceInputValue(value, astType.Elem, path+"[0]", errs)}
+		}
+		result := make([]interface{}, len(items))
+		for i, item := range items {
+			result[i] = s.coerceInputValue(item, astType.Elem, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+		return result
+	}
+
+	switch astType.NamedType {
+	case "Int":
+		return coerceInt(value, path, errs)
+	case "Float":
+		return coerceFloat(value, path, errs)
+	case "String":
+		if str, ok := value.(string); ok {
+			return str
+		}
+		errs.add(path, "expected String, got %s", describeJSONValue(value))
+		return value
+	case "ID":
+		return coerceID(value, path, errs)
+	case "Boolean":
+		return coerceBoolean(value, path, errs)
+	}
+
+	typeDef := s.GetTypeDefinition(astType.NamedType)
+	if typeDef == nil {
+		return value
+	}
+
+	switch typeDef.Kind {
+	case ast.Enum:
+		return coerceEnum(value, typeDef, path, errs)
+	case ast.InputObject:
+		return s.coerceInputObject(value, typeDef, path, errs)
+	default:
+		// Custom scalars are passed through unchanged for the server to interpret
+		return value
+	}
+}
+
+// coerceInputObject validates an input object value and coerces its fields
+func (s *Schema) coerceInputObject(value interface{}, typeDef *ast.Definition, path string, errs *InputErrors) interface{} {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		errs.add(path, "expected %s object, got %s", typeDef.Name, describeJSONValue(value))
+		return value
+	}
+
+	result := make(map[string]interface{}, len(obj))
+	known := make(map[string]bool, len(typeDef.Fields))
+	for _, field := range typeDef.Fields {
+		known[field.Name] = true
+		fieldPath := path + "." + field.Name
+
+		fieldValue, present := obj[field.Name]
+		if !present {
+			if field.Type != nil && field.Type.NonNull && field.DefaultValue == nil {
+				errs.add(fieldPath, "missing required field of type %s", canonicalASTType(field.Type).String())
+			}
+			continue
+		}
+		result[field.Name] = s.coerceInputValue(fieldValue, field.Type, fieldPath, errs)
+	}
+
+	for _, name := range sortedMapKeys(obj) {
+		if !known[name] {
+			errs.add(path+"."+name, "unknown field of %s; expected one of: %s", typeDef.Name, fieldNames(typeDef))
+		}
+	}
+
+	if IsOneOfInputObject(typeDef) {
+		validateOneOf(obj, typeDef, path, errs)
+	}
+
+	return result
+}
+
+// coerceInt accepts integral numbers and numeric strings within the 32-bit range of GraphQL Int
+func coerceInt(value interface{}, path string, errs *InputErrors) interface{} {
+	var f float64
+	switch v := value.(type) {
+	case int:
+		f = float64(v)
+	case int32:
+		f = float64(v)
+	case int64:
+		f = float64(v)
+	case float64:
+		f = v
+	case json.Number:
+		parsed, err := v.Float64()
+		if err != nil {
+			errs.add(path, "expected Int, got %q", v.String())
+			return value
+		}
+		f = parsed
+	case string:
+		parsed, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			errs.add(path, "expected Int, got string %q", v)
+			return value
+		}
+		f = float64(parsed)
+	default:
+		errs.add(path, "expected Int, got %s", describeJSONValue(value))
+		return value
+	}
+
+	if f != math.Trunc(f) {
+		errs.add(path, "expected Int, got non-integer %v", f)
+		return value
+	}
+	if f > math.MaxInt32 || f < math.MinInt32 {
+		errs.add(path, "Int value %v is outside the 32-bit range", f)
+		return value
+	}
+	return int(f)
+}
+
+// coerceFloat accepts numbers and numeric strings
+func coerceFloat(value interface{}, path string, errs *InputErrors) interface{} {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case json.Number:
+		if parsed, err := v.Float64(); err == nil {
+			return parsed
+		}
+	case string:
+		if parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && !math.IsInf(parsed, 0) && !math.IsNaN(parsed) {
+			return parsed
+		}
+		errs.add(path, "expected Float, got string %q", v)
+		return value
+	}
+	errs.add(path, "expected Float, got %s", describeJSONValue(value))
+	return value
+}
+
+// coerceID accepts strings and integers, which are serialized as strings
+func coerceID(value interface{}, path string, errs *InputErrors) interface{} {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		if v == math.Trunc(v) {
+			return strconv.FormatInt(int64(v), 10)
+		}
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case json.Number:
+		if parsed, err := v.Int64(); err == nil {
+			return strconv.FormatInt(parsed, 10)
+		}
+	}
+	errs.add(path, "expected ID (string or integer), got %s", describeJSONValue(value))
+	return value
+}
+
+// coerceBoolean accepts booleans and the strings "true" and "false"
+func coerceBoolean(value interface{}, path string, errs *InputErrors) interface{} {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+	}
+	errs.add(path, "expected Boolean, got %s", describeJSONValue(value))
+	return value
+}
+
+// coerceEnum matches enum values exactly, falling back to a unique case-insensitive match
+func coerceEnum(value interface{}, typeDef *ast.Definition, path string, errs *InputErrors) interface{} {
+	str, ok := value.(string)
+	if !ok {
+		errs.add(path, "expected %s enum value, got %s", typeDef.Name, describeJSONValue(value))
+		return value
+	}
+
+	// Without known values (e.g. a partial introspection result) the server decides
+	if len(typeDef.EnumValues) == 0 {
+		return str
+	}
+
+	var folded []string
+	for _, enumValue := range typeDef.EnumValues {
+		if enumValue.Name == str {
+			return str
+		}
+		if strings.EqualFold(enumValue.Name, strings.TrimSpace(str)) {
+			folded = append(folded, enumValue.Name)
+		}
+	}
+	if len(folded) == 1 {
+		return folded[0]
+	}
+
+	names := make([]string, len(typeDef.EnumValues))
+	for i, enumValue := range typeDef.EnumValues {
+		names[i] = enumValue.Name
+	}
+	errs.add(path, "invalid %s value %q; expected one of: %s", typeDef.Name, str, strings.Join(names, ", "))
+	return value
+}
+
+// canonicalASTType rewrites the wrapper form of non-null lists produced by introspection parsing
+// ({NonNull, Elem: [T]}) into gqlparser's form ({NonNull, Elem: T}) throughout a type
+func canonicalASTType(astType *ast.Type) *ast.Type {
+	if astType == nil || astType.NamedType != "" || astType.Elem == nil {
+		return astType
+	}
+
+	// A non-null wrapper never wraps another non-null type or a named type directly,
+	// so only a nullable list element indicates the wrapper form
+	inner := astType.Elem
+	if astType.NonNull && !inner.NonNull && inner.NamedType == "" && inner.Elem != nil {
+		return &ast.Type{NonNull: true, Elem: canonicalASTType(inner.Elem), Position: astType.Position}
+	}
+	return &ast.Type{NonNull: astType.NonNull, Elem: canonicalASTType(inner), Position: astType.Position}
+}
+
+// describeJSONValue names the JSON type of a value for error messages
+func describeJSONValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case bool:
+		return fmt.Sprintf("boolean %v", v)
+	case float64, int, int32, int64, json.Number:
+		return fmt.Sprintf("number %v", v)
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// argumentNames lists the argument names of a field for error messages
+func argumentNames(field *Field) string {
+	if len(field.Args) == 0 {
+		return "(none)"
+	}
+	names := make([]string, len(field.Args))
+	for i, arg := range field.Args {
+		names[i] = arg.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// fieldNames lists the field names of an input object for error messages
+func fieldNames(typeDef *ast.Definition) string {
+	names := make([]string, len(typeDef.Fields))
+	for i, field := range typeDef.Fields {
+		names[i] = field.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// sortedMapKeys returns the keys of a map in sorted order
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,190 @@
+package schema
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// validationTestSchema builds a schema with an enum and a nested input object
+func validationTestSchema() *Schema {
+	return &Schema{
+		typeRegistry: map[string]*ast.Definition{
+			"EquipmentStatus": {
+				Kind: ast.Enum,
+				Name: "EquipmentStatus",
+				EnumValues: ast.EnumValueList{
+					{Name: "OPERATIONAL"},
+					{Name: "MAINTENANCE"},
+					{Name: "OFFLINE"},
+				},
+			},
+			"DimensionsInput": {
+				Kind: ast.InputObject,
+				Name: "DimensionsInput",
+				Fields: ast.FieldList{
+					{Name: "length", Type: ast.NonNullNamedType("Float", nil)},
+					{Name: "width", Type: ast.NamedType("Float", nil)},
+				},
+			},
+			"EquipmentInput": {
+				Kind: ast.InputObject,
+				Name: "EquipmentInput",
+				Fields: ast.FieldList{
+					{Name: "name", Type: ast.NonNullNamedType("String", nil)},
+					{Name: "status", Type: ast.NamedType("EquipmentStatus", nil)},
+					{Name: "dimensions", Type: ast.NamedType("DimensionsInput", nil)},
+					// Wrapper form produced by introspection parsing for [String]!
+					{Name: "tags", Type: &ast.Type{NonNull: true, Elem: &ast.Type{Elem: ast.NamedType("String", nil)}}},
+				},
+			},
+		},
+	}
+}
+
+func TestCoerceArguments(t *testing.T) {
+	s := validationTestSchema()
+	field := &Field{
+		Name: "createEquipment",
+		Args: []*Argument{
+			{Name: "input", ASTType: ast.NonNullNamedType("EquipmentInput", nil)},
+			{Name: "limit", ASTType: ast.NamedType("Int", nil)},
+			{Name: "ids", ASTType: ast.ListType(ast.NonNullNamedType("ID", nil), nil)},
+			{Name: "active", ASTType: ast.NamedType("Boolean", nil)},
+		},
+	}
+
+	input := map[string]interface{}{
+		"input": map[string]interface{}{
+			"name":       "Lathe",
+			"status":     "operational",
+			"dimensions": map[string]interface{}{"length": "2.5"},
+			"tags":       "cnc",
+		},
+		"limit":  "10",
+		"ids":    float64(42),
+		"active": "true",
+	}
+
+	coerced, err := s.CoerceArguments(field, input)
+	if err != nil {
+		t.Fatalf("CoerceArguments() error = %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"input": map[string]interface{}{
+			"name":       "Lathe",
+			"status":     "OPERATIONAL",
+			"dimensions": map[string]interface{}{"length": 2.5},
+			"tags":       []interface{}{"cnc"},
+		},
+		"limit":  10,
+		"ids":    []interface{}{"42"},
+		"active": true,
+	}
+	if !reflect.DeepEqual(coerced, expected) {
+		t.Errorf("CoerceArguments() = %#v, want %#v", coerced, expected)
+	}
+}
+
+func TestCoerceArguments_Errors(t *testing.T) {
+	s := validationTestSchema()
+	field := &Field{
+		Name: "createEquipment",
+		Args: []*Argument{
+			{Name: "input", ASTType: ast.NonNullNamedType("EquipmentInput", nil)},
+			{Name: "limit", ASTType: ast.NamedType("Int", nil)},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		input    map[string]interface{}
+		expected []string
+	}{
+		{
+			name:     "missing required argument",
+			input:    map[string]interface{}{},
+			expected: []string{"input: missing required argument of type EquipmentInput!"},
+		},
+		{
+			name: "unknown argument",
+			input: map[string]interface{}{
+				"input": map[string]interface{}{"name": "Lathe", "tags": []interface{}{}},
+				"first": 5,
+			},
+			expected: []string{"first: unknown argument; expected one of: input, limit"},
+		},
+		{
+			name: "nested errors are reported with paths",
+			input: map[string]interface{}{
+				"input": map[string]interface{}{
+					"status":     "BROKEN",
+					"dimensions": map[string]interface{}{"width": 1.0, "height": 2.0},
+					"tags":       []interface{}{"a", 3.0},
+				},
+				"limit": 1.5,
+			},
+			expected: []string{
+				"input.name: missing required field of type String!",
+				`input.status: invalid EquipmentStatus value "BROKEN"; expected one of: OPERATIONAL, MAINTENANCE, OFFLINE`,
+				"input.dimensions.length: missing required field of type Float!",
+				"input.dimensions.height: unknown field of DimensionsInput; expected one of: length, width",
+				"input.tags[1]: expected String, got number 3",
+				"limit: expected Int, got non-integer 1.5",
+			},
+		},
+		{
+			name: "null for non-null list",
+			input: map[string]interface{}{
+				"input": map[string]interface{}{"name": "Lathe", "tags": nil},
+			},
+			expected: []string{"input.tags: expected non-null [String]!, got null"},
+		},
+		{
+			name: "Int out of range",
+			input: map[string]interface{}{
+				"input": map[string]interface{}{"name": "Lathe", "tags": []interface{}{}},
+				"limit": "3000000000",
+			},
+			expected: []string{"limit: Int value 3e+09 is outside the 32-bit range"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.CoerceArguments(field, tt.input)
+			if err == nil {
+				t.Fatal("CoerceArguments() expected error, got nil")
+			}
+
+			var inputErrs InputErrors
+			if !errors.As(err, &inputErrs) {
+				t.Fatalf("CoerceArguments() error type = %T, want InputErrors", err)
+			}
+
+			actual := make([]string, len(inputErrs))
+			for i, inputErr := range inputErrs {
+				actual[i] = inputErr.Error()
+			}
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("CoerceArguments() errors =\n%s\nwant\n%s", strings.Join(actual, "\n"), strings.Join(tt.expected, "\n"))
+			}
+		})
+	}
+}
+
+func TestCanonicalASTType(t *testing.T) {
+	wrapper := &ast.Type{NonNull: true, Elem: &ast.Type{Elem: &ast.Type{NamedType: "String", NonNull: true}}}
+	if got := canonicalASTType(wrapper).String(); got != "[String!]!" {
+		t.Errorf("canonicalASTType() = %s, want [String!]!", got)
+	}
+
+	native := ast.NonNullListType(ast.NamedType("Int", nil), nil)
+	if got := canonicalASTType(native).String(); got != "[Int]!" {
+		t.Errorf("canonicalASTType() = %s, want [Int]!", got)
+	}
+}
@@ -0,0 +1,170 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// ParseValueLiteral parses a GraphQL value literal such as `10`, `"abc"`, `ASC`, `[1, 2]` or
+// `{limit: 10, sort: ASC}` into a typed AST value, as found in introspection defaultValue strings
+func ParseValueLiteral(raw string) (*ast.Value, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("empty value literal")
+	}
+
+	// gqlparser does not expose its value parser, so the literal is parsed as an argument value
+	doc, err := parser.ParseQuery(&ast.Source{Input: "{f(v: " + raw + ")}"})
+	if err != nil {
+		return nil, fmt.Errorf("invalid value literal %q: %w", raw, err)
+	}
+
+	if len(doc.Operations) != 1 || len(doc.Operations[0].SelectionSet) != 1 {
+		return nil, fmt.Errorf("invalid value literal %q", raw)
+	}
+	field, ok := doc.Operations[0].SelectionSet[0].(*ast.Field)
+	if !ok || len(field.Arguments) != 1 {
+		return nil, fmt.Errorf("invalid value literal %q", raw)
+	}
+
+	value := field.Arguments[0].Value
+	if containsVariable(value) {
+		return nil, fmt.Errorf("invalid value literal %q: variables are not allowed", raw)
+	}
+	return value, nil
+}
+
+// parseDefaultValue converts an introspection defaultValue into a typed AST value,
+// keeping the raw text as a string value when it is not a valid literal
+func parseDefaultValue(defaultValue interface{}) *ast.Value {
+	raw, ok := defaultValue.(string)
+	if !ok {
+		raw = fmt.Sprintf("%v", defaultValue)
+	}
+
+	if value, err := ParseValueLiteral(raw); err == nil {
+		return value
+	}
+	return &ast.Value{Kind: ast.StringValue, Raw: raw}
+}
+
+// containsVariable reports whether a value references a variable anywhere
+func containsVariable(value *ast.Value) bool {
+	if value == nil {
+		return false
+	}
+	if value.Kind == ast.Variable {
+		return true
+	}
+	for _, child := range value.Children {
+		if containsVariable(child.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatValueLiteral renders an AST value as a GraphQL literal, e.g. `{limit: 10, sort: ASC}`
+func FormatValueLiteral(value *ast.Value) string {
+	if value == nil {
+		return "null"
+	}
+
+	switch value.Kind {
+	case ast.StringValue, ast.BlockValue:
+		return quoteStringSDL(value.Raw)
+	case ast.ListValue:
+		items := make([]string, len(value.Children))
+		for i, child := range value.Children {
+			items[i] = FormatValueLiteral(child.Value)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case ast.ObjectValue:
+		fields := make([]string, len(value.Children))
+		for i, child := range value.Children {
+			fields[i] = child.Name + ": " + FormatValueLiteral(child.Value)
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	case ast.Variable:
+		return "$" + value.Raw
+	case ast.NullValue:
+		return "null"
+	default:
+		return value.Raw
+	}
+}
+
+// valueToJSON converts a typed AST value into the equivalent JSON value for the given GraphQL
+// type, applying input coercion so that e.g. a single default for a list type becomes a list
+func (s *Schema) valueToJSON(value *ast.Value, astType *ast.Type) interface{} {
+	if value == nil || value.Kind == ast.NullValue {
+		return nil
+	}
+
+	astType = canonicalASTType(astType)
+	if astType != nil && astType.Elem != nil {
+		if value.Kind != ast.ListValue {
+			return []interface{}{s.valueToJSON(value, astType.Elem)}
+		}
+		items := make([]interface{}, len(value.Children))
+		for i, child := range value.Children {
+			items[i] = s.valueToJSON(child.Value, astType.Elem)
+		}
+		return items
+	}
+
+	switch value.Kind {
+	case ast.IntValue:
+		if astType != nil && astType.NamedType == "Float" {
+			if f, err := strconv.ParseFloat(value.Raw, 64); err == nil {
+				return f
+			}
+		}
+		if astType != nil && astType.NamedType == "ID" {
+			return value.Raw
+		}
+		if i, err := strconv.Atoi(value.Raw); err == nil {
+			return i
+		}
+		return value.Raw
+	case ast.FloatValue:
+		if f, err := strconv.ParseFloat(value.Raw, 64); err == nil {
+			return f
+		}
+		return value.Raw
+	case ast.BooleanValue:
+		if b, err := strconv.ParseBool(value.Raw); err == nil {
+			return b
+		}
+		return value.Raw
+	case ast.ListValue:
+		items := make([]interface{}, len(value.Children))
+		for i, child := range value.Children {
+			items[i] = s.valueToJSON(child.Value, nil)
+		}
+		return items
+	case ast.ObjectValue:
+		var typeDef *ast.Definition
+		if s != nil && astType != nil {
+			typeDef = s.GetTypeDefinition(astType.NamedType)
+		}
+		obj := make(map[string]interface{}, len(value.Children))
+		for _, child := range value.Children {
+			var fieldType *ast.Type
+			if typeDef != nil {
+				if fieldDef := typeDef.Fields.ForName(child.Name); fieldDef != nil {
+					fieldType = fieldDef.Type
+				}
+			}
+			obj[child.Name] = s.valueToJSON(child.Value, fieldType)
+		}
+		return obj
+	default:
+		// Strings, enum values and unparsed literals
+		return value.Raw
+	}
+}
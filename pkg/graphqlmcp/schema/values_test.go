@@ -0,0 +1,209 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestParseValueLiteral(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		kind      ast.ValueKind
+		formatted string
+		wantErr   bool
+	}{
+		{name: "int", raw: "10", kind: ast.IntValue, formatted: "10"},
+		{name: "float", raw: "1.5", kind: ast.FloatValue, formatted: "1.5"},
+		{name: "string", raw: `"a \"b\""`, kind: ast.StringValue, formatted: `"a \"b\""`},
+		{name: "boolean", raw: "true", kind: ast.BooleanValue, formatted: "true"},
+		{name: "null", raw: "null", kind: ast.NullValue, formatted: "null"},
+		{name: "enum", raw: "ASC", kind: ast.EnumValue, formatted: "ASC"},
+		{name: "list", raw: "[1,2]", kind: ast.ListValue, formatted: "[1, 2]"},
+		{name: "object", raw: "{limit:10,sort:ASC,tags:[\"x\"]}", kind: ast.ObjectValue, formatted: `{limit: 10, sort: ASC, tags: ["x"]}`},
+		{name: "variable", raw: "$limit", wantErr: true},
+		{name: "invalid", raw: "{limit:", wantErr: true},
+		{name: "empty", raw: " ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := ParseValueLiteral(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseValueLiteral(%q) expected error, got %v", tt.raw, value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseValueLiteral(%q) error = %v", tt.raw, err)
+			}
+			if value.Kind != tt.kind {
+				t.Errorf("ParseValueLiteral(%q) kind = %v, want %v", tt.raw, value.Kind, tt.kind)
+			}
+			if got := FormatValueLiteral(value); got != tt.formatted {
+				t.Errorf("FormatValueLiteral() = %s, want %s", got, tt.formatted)
+			}
+		})
+	}
+}
+
+// defaultsIntrospection builds an introspection response with object, list and enum defaults
+func defaultsIntrospection() map[string]interface{} {
+	named := func(kind, name string) map[string]interface{} {
+		return map[string]interface{}{"kind": kind, "name": name}
+	}
+
+	return map[string]interface{}{
+		"__schema": map[string]interface{}{
+			"queryType": map[string]interface{}{"name": "Query"},
+			"types": []interface{}{
+				map[string]interface{}{
+					"name": "Query",
+					"kind": "OBJECT",
+					"fields": []interface{}{
+						map[string]interface{}{
+							"name": "equipment",
+							"type": named("SCALAR", "String"),
+							"args": []interface{}{
+								map[string]interface{}{
+									"name":         "page",
+									"type":         named("INPUT_OBJECT", "PageInput"),
+									"defaultValue": "{limit: 10, sort: DESC}",
+								},
+								map[string]interface{}{
+									"name":         "tags",
+									"type":         map[string]interface{}{"kind": "LIST", "ofType": named("SCALAR", "String")},
+									"defaultValue": `"active"`,
+								},
+								map[string]interface{}{
+									"name":         "minEfficiency",
+									"type":         named("SCALAR", "Float"),
+									"defaultValue": "1",
+								},
+							},
+						},
+					},
+				},
+				map[string]interface{}{
+					"name": "PageInput",
+					"kind": "INPUT_OBJECT",
+					"inputFields": []interface{}{
+						map[string]interface{}{
+							"name":         "limit",
+							"type":         named("SCALAR", "Int"),
+							"defaultValue": "20",
+						},
+						map[string]interface{}{
+							"name":         "sort",
+							"type":         named("ENUM", "SortOrder"),
+							"defaultValue": "ASC",
+						},
+						map[string]interface{}{
+							"name":         "weights",
+							"type":         map[string]interface{}{"kind": "LIST", "ofType": named("SCALAR", "Float")},
+							"defaultValue": "[1, 2.5]",
+						},
+					},
+				},
+				map[string]interface{}{
+					"name": "SortOrder",
+					"kind": "ENUM",
+					"enumValues": []interface{}{
+						map[string]interface{}{"name": "ASC"},
+						map[string]interface{}{"name": "DESC"},
+					},
+				},
+				map[string]interface{}{"name": "String", "kind": "SCALAR"},
+				map[string]interface{}{"name": "Int", "kind": "SCALAR"},
+				map[string]interface{}{"name": "Float", "kind": "SCALAR"},
+			},
+		},
+	}
+}
+
+func TestTypedDefaultValues(t *testing.T) {
+	s, err := ParseIntrospectionResponse(defaultsIntrospection())
+	if err != nil {
+		t.Fatalf("ParseIntrospectionResponse() error = %v", err)
+	}
+
+	queries := s.GetQueries()
+	if len(queries) != 1 {
+		t.Fatalf("expected 1 query, got %d", len(queries))
+	}
+
+	inputSchema := s.CreateInputSchema(queries[0])
+	properties := inputSchema["properties"].(map[string]interface{})
+
+	page := properties["page"].(map[string]interface{})
+	if expected := map[string]interface{}{"limit": 10, "sort": "DESC"}; !reflect.DeepEqual(page["default"], expected) {
+		t.Errorf("page default = %#v, want %#v", page["default"], expected)
+	}
+
+	pageFields := page["properties"].(map[string]interface{})
+	if got := pageFields["sort"].(map[string]interface{})["default"]; got != "ASC" {
+		t.Errorf("page.sort default = %#v, want ASC", got)
+	}
+	if got := pageFields["weights"].(map[string]interface{})["default"]; !reflect.DeepEqual(got, []interface{}{1.0, 2.5}) {
+		t.Errorf("page.weights default = %#v, want [1 2.5]", got)
+	}
+
+	tags := properties["tags"].(map[string]interface{})
+	if !reflect.DeepEqual(tags["default"], []interface{}{"active"}) {
+		t.Errorf("tags default = %#v, want [active]", tags["default"])
+	}
+	if _, ok := tags["items"].(map[string]interface{})["default"]; ok {
+		t.Error("tags items should not carry the list default")
+	}
+
+	if got := properties["minEfficiency"].(map[string]interface{})["default"]; got != 1.0 {
+		t.Errorf("minEfficiency default = %#v, want 1.0", got)
+	}
+
+	sdl := s.GetSchemaSDL()
+	for _, expected := range []string{
+		`equipment(page: PageInput = {limit: 10, sort: DESC}, tags: [String] = "active", minEfficiency: Float = 1): String`,
+		"  limit: Int = 20\n",
+		"  sort: SortOrder = ASC\n",
+		"  weights: [Float] = [1, 2.5]\n",
+	} {
+		if !strings.Contains(sdl, expected) {
+			t.Errorf("GetSchemaSDL() missing %q in:\n%s", expected, sdl)
+		}
+	}
+}
+
+func TestNonNullInputFieldsWithDefaults(t *testing.T) {
+	s := newSchemaFromAST(parseTestAST(t, `
+		input PageInput {
+			limit: Int
+		}
+
+		input SearchInput {
+			term: String!
+			page: PageInput! = {limit: 10}
+			tags: [String!]! = ["active"]
+			empty: [String!]! = []
+		}
+
+		type Query {
+			search(input: SearchInput!): String
+		}
+	`))
+
+	queries := s.GetQueries()
+	if len(queries) != 1 {
+		t.Fatalf("expected 1 query, got %d", len(queries))
+	}
+
+	// Object and list defaults leave Raw empty, but still make the field optional
+	inputSchema := s.CreateInputSchema(queries[0])
+	input := inputSchema["properties"].(map[string]interface{})["input"].(map[string]interface{})
+	if !reflect.DeepEqual(input["required"], []string{"term"}) {
+		t.Errorf("SearchInput required = %#v, want [term]", input["required"])
+	}
+}
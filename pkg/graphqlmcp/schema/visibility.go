@@ -0,0 +1,242 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// VisibilityFilter lists the types and fields that are hidden from everything derived from a schema
+type VisibilityFilter struct {
+	// HiddenTypes names the types to hide, e.g. "AuditEntry"
+	HiddenTypes []string
+	// HiddenFields names the fields to hide as "Type.field", e.g. "Equipment.internalCostCenter"
+	HiddenFields []string
+}
+
+// IsEmpty reports whether the filter hides nothing
+func (f VisibilityFilter) IsEmpty() bool {
+	return len(f.HiddenTypes) == 0 && len(f.HiddenFields) == 0
+}
+
+// Prune returns a view of the schema without the hidden types and fields. The schema itself is
+// not modified. Hiding can cascade:
+//   - fields that return a hidden type are hidden
+//   - fields with a required argument of a hidden type are hidden, optional ones lose the argument
+//   - input objects that lose a required field, and types left without fields or members, are hidden
+//   - fields hidden on an interface are hidden on its implementations, and fields hidden on every
+//     implementation of an interface are hidden on the interface; hiding an interface field on only
+//     some implementations is an error
+//
+// Root operations are fields of the query and mutation types, so operations that need a hidden
+// input type are dropped as well
+func (s *Schema) Prune(filter VisibilityFilter) (*Schema, error) {
+	if s == nil || s.parsedSchema == nil || filter.IsEmpty() {
+		return s, nil
+	}
+
+	hiddenTypes := make(map[string]bool, len(filter.HiddenTypes))
+	for _, name := range filter.HiddenTypes {
+		if s.typeRegistry[name] == nil {
+			return nil, fmt.Errorf("cannot hide type %s: the schema has no such type", name)
+		}
+		if isBuiltinType(name) || isIntrospectionType(name) {
+			return nil, fmt.Errorf("cannot hide built-in type %s", name)
+		}
+		if s.parsedSchema.Query != nil && name == s.parsedSchema.Query.Name {
+			return nil, fmt.Errorf("cannot hide the query type %s", name)
+		}
+		hiddenTypes[name] = true
+	}
+
+	hiddenFields := make(map[string]bool, len(filter.HiddenFields))
+	for _, typeField := range filter.HiddenFields {
+		typeName, fieldName, ok := strings.Cut(typeField, ".")
+		if !ok || typeName == "" || fieldName == "" {
+			return nil, fmt.Errorf("cannot hide field %s: expected Type.field", typeField)
+		}
+		typeDef := s.typeRegistry[typeName]
+		if typeDef == nil {
+			return nil, fmt.Errorf("cannot hide field %s: the schema has no type %s", typeField, typeName)
+		}
+		if typeDef.Fields.ForName(fieldName) == nil {
+			return nil, fmt.Errorf("cannot hide field %s: %s has no field %s", typeField, typeName, fieldName)
+		}
+		hiddenFields[typeField] = true
+	}
+	s.propagateHiddenFields(hiddenFields, hiddenTypes)
+	// A field hidden on some implementations only would still be selected through the interface
+	for _, typeField := range filter.HiddenFields {
+		typeName, fieldName, _ := strings.Cut(typeField, ".")
+		for _, interfaceName := range s.typeRegistry[typeName].Interfaces {
+			interfaceDef := s.typeRegistry[interfaceName]
+			if interfaceDef == nil || interfaceDef.Fields.ForName(fieldName) == nil {
+				continue
+			}
+			if !hiddenTypes[interfaceName] && !hiddenFields[interfaceName+"."+fieldName] {
+				return nil, fmt.Errorf("cannot hide field %s: interface %s still exposes it; hide %s.%s or the field on every implementation",
+					typeField, interfaceName, interfaceName, fieldName)
+			}
+		}
+	}
+
+	// Hiding a type can leave other types without fields or members, so prune until nothing changes
+	var types map[string]*ast.Definition
+	for {
+		types = make(map[string]*ast.Definition, len(s.typeRegistry))
+		changed := false
+		for name, typeDef := range s.typeRegistry {
+			if hiddenTypes[name] {
+				continue
+			}
+			pruned, unusable := pruneDefinition(typeDef, hiddenTypes, hiddenFields)
+			if unusable && typeDef != s.parsedSchema.Query {
+				hiddenTypes[name] = true
+				changed = true
+				continue
+			}
+			types[name] = pruned
+		}
+		if !changed {
+			break
+		}
+	}
+
+	astSchema := &ast.Schema{
+		Types:      types,
+		Directives: s.parsedSchema.Directives,
+	}
+	if s.parsedSchema.Query != nil {
+		astSchema.Query = types[s.parsedSchema.Query.Name]
+	}
+	if s.parsedSchema.Mutation != nil {
+		astSchema.Mutation = types[s.parsedSchema.Mutation.Name]
+	}
+	if s.parsedSchema.Subscription != nil {
+		astSchema.Subscription = types[s.parsedSchema.Subscription.Name]
+	}
+
+	pruned := newSchemaFromAST(astSchema)
+	pruned.MaxDepth = s.MaxDepth
+	pruned.ExcludedFields = s.ExcludedFields
+	return pruned, nil
+}
+
+// propagateHiddenFields hides the fields of interfaces on their implementations, and the fields of
+// interfaces that every visible implementation hides, so that no selection through an interface
+// or inline fragment reaches a hidden field
+func (s *Schema) propagateHiddenFields(hiddenFields, hiddenTypes map[string]bool) {
+	for changed := true; changed; {
+		changed = false
+		for _, typeDef := range s.typeRegistry {
+			if typeDef.Kind != ast.Interface || hiddenTypes[typeDef.Name] {
+				continue
+			}
+			implementations := s.implementationsOf(typeDef.Name, hiddenTypes)
+			for _, field := range typeDef.Fields {
+				key := typeDef.Name + "." + field.Name
+				if hiddenFields[key] {
+					for _, impl := range implementations {
+						if implKey := impl.Name + "." + field.Name; !hiddenFields[implKey] {
+							hiddenFields[implKey] = true
+							changed = true
+						}
+					}
+					continue
+				}
+				hiddenEverywhere := len(implementations) > 0
+				for _, impl := range implementations {
+					hiddenEverywhere = hiddenEverywhere && hiddenFields[impl.Name+"."+field.Name]
+				}
+				if hiddenEverywhere {
+					hiddenFields[key] = true
+					changed = true
+				}
+			}
+		}
+	}
+}
+
+// implementationsOf returns the visible object and interface types that implement an interface
+func (s *Schema) implementationsOf(interfaceName string, hiddenTypes map[string]bool) []*ast.Definition {
+	var implementations []*ast.Definition
+	for _, typeDef := range s.typeRegistry {
+		if hiddenTypes[typeDef.Name] || (typeDef.Kind != ast.Object && typeDef.Kind != ast.Interface) {
+			continue
+		}
+		for _, name := range typeDef.Interfaces {
+			if name == interfaceName {
+				implementations = append(implementations, typeDef)
+				break
+			}
+		}
+	}
+	return implementations
+}
+
+// pruneDefinition returns a copy of a type definition without hidden fields and members, and
+// whether the type is unusable because it lost all of them or a required input field
+func pruneDefinition(typeDef *ast.Definition, hiddenTypes, hiddenFields map[string]bool) (*ast.Definition, bool) {
+	pruned := *typeDef
+	pruned.Interfaces = visibleTypeNames(typeDef.Interfaces, hiddenTypes)
+	pruned.Types = visibleTypeNames(typeDef.Types, hiddenTypes)
+
+	switch typeDef.Kind {
+	case ast.Union:
+		return &pruned, len(pruned.Types) == 0
+
+	case ast.Object, ast.Interface, ast.InputObject:
+		pruned.Fields = make(ast.FieldList, 0, len(typeDef.Fields))
+		for _, field := range typeDef.Fields {
+			if hiddenFields[typeDef.Name+"."+field.Name] || hiddenTypes[GetASTTypeName(field.Type)] {
+				if typeDef.Kind == ast.InputObject && field.Type.NonNull && field.DefaultValue == nil {
+					return nil, true
+				}
+				continue
+			}
+			if field, ok := pruneArguments(field, hiddenTypes); ok {
+				pruned.Fields = append(pruned.Fields, field)
+			}
+		}
+		return &pruned, len(typeDef.Fields) > 0 && len(pruned.Fields) == 0
+	}
+
+	return &pruned, false
+}
+
+// pruneArguments returns a copy of a field without arguments of hidden types, or false when a
+// required argument has a hidden type
+func pruneArguments(field *ast.FieldDefinition, hiddenTypes map[string]bool) (*ast.FieldDefinition, bool) {
+	arguments := make(ast.ArgumentDefinitionList, 0, len(field.Arguments))
+	for _, arg := range field.Arguments {
+		if !hiddenTypes[GetASTTypeName(arg.Type)] {
+			arguments = append(arguments, arg)
+			continue
+		}
+		if arg.Type.NonNull && arg.DefaultValue == nil {
+			return nil, false
+		}
+	}
+	if len(arguments) == len(field.Arguments) {
+		return field, true
+	}
+
+	pruned := *field
+	pruned.Arguments = arguments
+	return &pruned, true
+}
+
+// visibleTypeNames returns the type names that are not hidden
+func visibleTypeNames(names []string, hiddenTypes map[string]bool) []string {
+	if names == nil {
+		return nil
+	}
+	visible := make([]string, 0, len(names))
+	for _, name := range names {
+		if !hiddenTypes[name] {
+			visible = append(visible, name)
+		}
+	}
+	return visible
+}
@@ -0,0 +1,251 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+// visibilityTestSchema builds a schema with internal types reachable from outputs and inputs
+func visibilityTestSchema(t *testing.T) *Schema {
+	t.Helper()
+	parsed := parseTestAST(t, `
+		type Query {
+			equipment(filter: EquipmentFilter, audit: AuditFilter): [Equipment!]!
+			auditLog(filter: AuditFilter!): [AuditEntry!]!
+			search(term: String!): [SearchResult!]!
+			node(id: ID!): Node
+		}
+		type Mutation {
+			updateEquipment(id: ID!, input: UpdateEquipmentInput!): Equipment!
+			recordAudit(input: AuditInput!): Boolean!
+		}
+		interface Node {
+			id: ID!
+		}
+		type Equipment implements Node {
+			id: ID!
+			name: String!
+			internalCostCenter: String
+			lastAudit: AuditEntry
+		}
+		type AuditEntry implements Node {
+			id: ID!
+			actor: String!
+		}
+		union SearchResult = Equipment | AuditEntry
+		input EquipmentFilter {
+			name: String
+		}
+		input AuditFilter {
+			actor: String
+		}
+		input AuditInput {
+			actor: String!
+			filter: AuditFilter!
+		}
+		input UpdateEquipmentInput {
+			name: String
+			costCenter: String!
+		}
+	`)
+	return newSchemaFromAST(parsed)
+}
+
+// fieldNamesOf returns the names of the fields, without the introspection fields gqlparser adds
+func fieldNamesOf(fields []*Field) string {
+	var names []string
+	for _, field := range fields {
+		if !strings.HasPrefix(field.Name, "__") {
+			names = append(names, field.Name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func TestSchema_Prune(t *testing.T) {
+	s := visibilityTestSchema(t)
+	s.MaxDepth = 3
+
+	pruned, err := s.Prune(VisibilityFilter{
+		HiddenTypes:  []string{"AuditFilter"},
+		HiddenFields: []string{"Equipment.internalCostCenter", "UpdateEquipmentInput.costCenter", "Equipment.lastAudit"},
+	})
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+
+	// Required hidden inputs drop the operation; optional ones drop the argument
+	if got := fieldNamesOf(pruned.GetQueries()); got != "equipment,search,node" {
+		t.Errorf("queries = %s, want equipment,search,node", got)
+	}
+	if got := len(pruned.GetQueries()[0].Args); got != 1 {
+		t.Errorf("equipment has %d arguments, want 1", got)
+	}
+	// UpdateEquipmentInput lost a required field and AuditInput a required input type
+	if pruned.MutationType != nil {
+		t.Errorf("mutations = %s, want none", fieldNamesOf(pruned.GetMutations()))
+	}
+	for _, name := range []string{"AuditFilter", "AuditInput", "UpdateEquipmentInput", "Mutation"} {
+		if pruned.GetTypeDefinition(name) != nil {
+			t.Errorf("type %s is visible", name)
+		}
+	}
+
+	query, err := pruned.GetQueries()[0].GenerateQueryStringWithSchema(pruned)
+	if err != nil {
+		t.Fatalf("GenerateQueryStringWithSchema() error = %v", err)
+	}
+	for _, hidden := range []string{"internalCostCenter", "lastAudit", "audit:"} {
+		if strings.Contains(query, hidden) {
+			t.Errorf("query %q selects hidden %s", query, hidden)
+		}
+	}
+
+	sdl := pruned.GetSchemaSDL()
+	for _, hidden := range []string{"internalCostCenter", "AuditFilter", "costCenter", "recordAudit"} {
+		if strings.Contains(sdl, hidden) {
+			t.Errorf("SDL contains hidden %s", hidden)
+		}
+	}
+	if pruned.MaxDepth != 3 {
+		t.Errorf("MaxDepth = %d, want 3", pruned.MaxDepth)
+	}
+
+	// The original schema is unchanged
+	if s.GetTypeDefinition("Equipment").Fields.ForName("internalCostCenter") == nil || len(s.GetMutations()) != 2 {
+		t.Error("Prune() modified the original schema")
+	}
+}
+
+func TestSchema_PruneAbstractTypes(t *testing.T) {
+	s := visibilityTestSchema(t)
+
+	pruned, err := s.Prune(VisibilityFilter{HiddenTypes: []string{"AuditEntry"}})
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+
+	if got := fieldNamesOf(pruned.GetQueries()); got != "equipment,search,node" {
+		t.Errorf("queries = %s, want equipment,search,node", got)
+	}
+	if got := pruned.GetTypeDefinition("SearchResult").Types; len(got) != 1 || got[0] != "Equipment" {
+		t.Errorf("SearchResult members = %v, want [Equipment]", got)
+	}
+	if got := pruned.GetImplementations("Node"); len(got) != 1 || got[0].Name != "Equipment" {
+		t.Errorf("Node implementations = %v, want Equipment", got)
+	}
+
+	query, err := pruned.GetQueries()[1].GenerateQueryStringWithSchema(pruned)
+	if err != nil {
+		t.Fatalf("GenerateQueryStringWithSchema() error = %v", err)
+	}
+	if strings.Contains(query, "AuditEntry") || strings.Contains(query, "actor") {
+		t.Errorf("query %q selects the hidden type", query)
+	}
+
+	// Hiding every member hides the union and the fields returning it
+	pruned, err = s.Prune(VisibilityFilter{HiddenTypes: []string{"AuditEntry", "Equipment"}})
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if got := fieldNamesOf(pruned.GetQueries()); got != "node" {
+		t.Errorf("queries = %s, want node", got)
+	}
+}
+
+func TestSchema_PruneInterfaceFields(t *testing.T) {
+	parsed := parseTestAST(t, `
+		type Query {
+			people: [Personnel!]!
+			managers: [Manager!]!
+		}
+		interface Personnel {
+			id: ID!
+			name: String!
+			email: String
+		}
+		type Manager implements Personnel {
+			id: ID!
+			name: String!
+			email: String
+			level: Int!
+		}
+		type Associate implements Personnel {
+			id: ID!
+			name: String!
+			email: String
+			shift: String!
+		}
+	`)
+	s := newSchemaFromAST(parsed)
+
+	// Hiding a field on the interface or on every implementation hides it on all of them
+	tests := []struct {
+		name   string
+		hidden []string
+	}{
+		{name: "interface field", hidden: []string{"Personnel.email"}},
+		{name: "field of every implementation", hidden: []string{"Manager.email", "Associate.email"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pruned, err := s.Prune(VisibilityFilter{HiddenFields: tt.hidden})
+			if err != nil {
+				t.Fatalf("Prune() error = %v", err)
+			}
+			for _, field := range pruned.GetQueries() {
+				if strings.HasPrefix(field.Name, "__") {
+					continue
+				}
+				query, err := field.GenerateQueryStringWithSchema(pruned)
+				if err != nil {
+					t.Fatalf("GenerateQueryStringWithSchema() error = %v", err)
+				}
+				if strings.Contains(query, "email") {
+					t.Errorf("query %q selects the hidden email", query)
+				}
+				if !strings.Contains(query, "name") {
+					t.Errorf("query %q does not select name", query)
+				}
+			}
+			if sdl := pruned.GetSchemaSDL(); strings.Contains(sdl, "email") {
+				t.Errorf("SDL contains the hidden email:\n%s", sdl)
+			}
+		})
+	}
+
+	// A field hidden on some implementations would still be selected through the interface
+	_, err := s.Prune(VisibilityFilter{HiddenFields: []string{"Manager.email"}})
+	if err == nil || !strings.Contains(err.Error(), "interface Personnel still exposes it") {
+		t.Errorf("Prune() error = %v, want the filter to be rejected", err)
+	}
+}
+
+func TestSchema_PruneInvalid(t *testing.T) {
+	s := visibilityTestSchema(t)
+
+	tests := []struct {
+		filter   VisibilityFilter
+		expected string
+	}{
+		{filter: VisibilityFilter{HiddenTypes: []string{"Secret"}}, expected: "cannot hide type Secret: the schema has no such type"},
+		{filter: VisibilityFilter{HiddenTypes: []string{"String"}}, expected: "cannot hide built-in type String"},
+		{filter: VisibilityFilter{HiddenTypes: []string{"Query"}}, expected: "cannot hide the query type Query"},
+		{filter: VisibilityFilter{HiddenFields: []string{"internalCostCenter"}}, expected: "cannot hide field internalCostCenter: expected Type.field"},
+		{filter: VisibilityFilter{HiddenFields: []string{"Equipment.owner"}}, expected: "cannot hide field Equipment.owner: Equipment has no field owner"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			_, err := s.Prune(tt.filter)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("Prune() error = %v, want %q", err, tt.expected)
+			}
+		})
+	}
+
+	if pruned, err := s.Prune(VisibilityFilter{}); err != nil || pruned != s {
+		t.Errorf("Prune() with an empty filter = %p, %v; want the schema itself", pruned, err)
+	}
+}
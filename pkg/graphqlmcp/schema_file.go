@@ -0,0 +1,60 @@
+package graphqlmcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/peterbeamish/go-mcp-graphql/pkg/graphqlmcp/schema"
+)
+
+// LoadSchemaFile loads a schema from a file instead of introspecting the GraphQL server
+// Files ending in .json hold an introspection result, with or without the "data" envelope;
+// any other file is read as SDL
+func LoadSchemaFile(path string) (*schema.Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file: %w", err)
+	}
+
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		s, err := schema.ParseSDL(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema file %s: %w", path, err)
+		}
+		return s, nil
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid schema file %s: %w", path, err)
+	}
+	if envelope, ok := result["data"].(map[string]interface{}); ok {
+		result = envelope
+	}
+	s, err := schema.ParseIntrospectionResponse(result)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema file %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// staticSchemaExecutor executes operations with another executor but never introspects
+type staticSchemaExecutor struct {
+	GraphQLExecutor
+	schema *schema.Schema
+}
+
+// NewStaticSchemaExecutor wraps an executor so that it reports a fixed schema, such as one
+// loaded with LoadSchemaFile, for GraphQL servers with introspection disabled
+func NewStaticSchemaExecutor(executor GraphQLExecutor, s *schema.Schema) GraphQLExecutor {
+	return &staticSchemaExecutor{GraphQLExecutor: executor, schema: s}
+}
+
+// IntrospectSchema returns the fixed schema
+func (e *staticSchemaExecutor) IntrospectSchema(ctx context.Context) (*schema.Schema, error) {
+	return e.schema, nil
+}
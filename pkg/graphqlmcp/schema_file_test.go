@@ -0,0 +1,70 @@
+package graphqlmcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSchemaFile(t *testing.T) {
+	introspection, err := os.ReadFile("testdata/real_introspection_response.json")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "schema.json")
+	require.NoError(t, os.WriteFile(jsonPath, introspection, 0o600))
+	sdlPath := filepath.Join(dir, "schema.graphql")
+	require.NoError(t, os.WriteFile(sdlPath, []byte(`
+type Query {
+  "Look up a widget"
+  widget(id: ID!): Widget
+}
+
+type Mutation {
+  renameWidget(id: ID!, name: String!): Widget
+}
+
+type Widget {
+  id: ID!
+  name: String
+}
+`), 0o600))
+
+	fromJSON, err := LoadSchemaFile(jsonPath)
+	require.NoError(t, err)
+	assert.NotNil(t, findField(t, fromJSON.GetQueries(), "equipment"))
+
+	fromSDL, err := LoadSchemaFile(sdlPath)
+	require.NoError(t, err)
+	require.Len(t, fromSDL.GetQueries(), 1)
+	assert.Equal(t, "Look up a widget", fromSDL.GetQueries()[0].Description)
+	assert.NotNil(t, findField(t, fromSDL.GetMutations(), "renameWidget"))
+
+	_, err = LoadSchemaFile(filepath.Join(dir, "missing.graphql"))
+	assert.ErrorContains(t, err, "failed to read schema file")
+
+	invalidPath := filepath.Join(dir, "invalid.graphql")
+	require.NoError(t, os.WriteFile(invalidPath, []byte("type Query {"), 0o600))
+	_, err = LoadSchemaFile(invalidPath)
+	assert.ErrorContains(t, err, "invalid schema file "+invalidPath)
+}
+
+func TestNewStaticSchemaExecutor(t *testing.T) {
+	testSchema := loadTestSchema(t)
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.Anything, mock.Anything).Return(&GraphQLResponse{Data: map[string]interface{}{}}, nil)
+
+	server, err := NewMCPGraphQLServerWithExecutor(NewStaticSchemaExecutor(mockExecutor, testSchema))
+	require.NoError(t, err)
+	assert.NotEmpty(t, server.GetSchema().GetQueries())
+
+	_, err = server.GetExecutor().ExecuteQuery(context.Background(), "{ equipment { id } }", nil)
+	require.NoError(t, err)
+	mockExecutor.AssertNotCalled(t, "IntrospectSchema", mock.Anything)
+	mockExecutor.AssertNumberOfCalls(t, "ExecuteQuery", 1)
+}
@@ -0,0 +1,41 @@
+package graphqlmcp
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// RunStdio serves MCP over stdin and stdout until the client disconnects or ctx is cancelled
+// There are no inbound HTTP requests over stdio, so header stands in for them: the passthru
+// rules are applied to it once for the whole session, and its bearer token is used for token
+// exchange if one of the authenticators verifies it as a JWT
+func (s *MCPGraphQLServer) RunStdio(ctx context.Context, header http.Header) error {
+	return s.run(ctx, &mcp.StdioTransport{}, header)
+}
+
+// run serves a single MCP session over the transport with the given stand-in request headers
+func (s *MCPGraphQLServer) run(ctx context.Context, transport mcp.Transport, header http.Header) error {
+	ctx = AddPassthruHeaderValuesToContext(ctx, s.passthruHeaderValuesFrom(header))
+	if token := s.standInSubjectToken(ctx, header); token != "" {
+		ctx = ContextWithSubjectToken(ctx, token)
+	}
+	return s.GetMCPServer().Run(ctx, transport)
+}
+
+// standInSubjectToken verifies the bearer token of the stand-in headers with the authenticators
+// and returns it if it was verified as a JWT
+func (s *MCPGraphQLServer) standInSubjectToken(ctx context.Context, header http.Header) string {
+	token := bearerToken(header.Get("Authorization"))
+	if token == "" || len(s.options.Authenticators) == 0 {
+		return ""
+	}
+
+	tokenInfo, err := s.verifyToken(ctx, token, &http.Request{Header: header})
+	if err != nil {
+		s.logger.Info("Bearer token of the stdio session is not used for token exchange", "error", err)
+		return ""
+	}
+	return subjectTokenFromTokenInfo(tokenInfo)
+}
@@ -0,0 +1,65 @@
+package graphqlmcp
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPGraphQLServer_RunWithStandInHeaders(t *testing.T) {
+	testSchema := loadTestSchema(t)
+	mockExecutor := new(MockGraphQLExecutor)
+	mockExecutor.On("IntrospectSchema", mock.Anything).Return(testSchema, nil)
+
+	var upstream http.Header
+	var subjectToken string
+	mockExecutor.On("ExecuteQuery", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			upstream = GetPassthruHeaderValues(ctx)
+			subjectToken = SubjectTokenFromContext(ctx)
+		}).
+		Return(&GraphQLResponse{Data: map[string]interface{}{"equipment": []interface{}{}}}, nil)
+
+	jwtAuthenticator, key := newTestJWTAuthenticator(t)
+	callerToken := signJWT(t, "key-1", key, validClaims())
+
+	server, err := NewMCPGraphQLServerWithExecutor(mockExecutor,
+		WithAuthenticators(jwtAuthenticator),
+		WithPassthruHeaders([]string{"X-Tenant-ID"}),
+		WithPassthruRules(PassthruRename("X-User-Token", "Authorization", "Bearer {value}")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	done := make(chan error, 1)
+	go func() {
+		done <- server.run(ctx, serverTransport, http.Header{
+			"X-Tenant-Id":   {"acme"},
+			"X-User-Token":  {"user-token"},
+			"Authorization": {"Bearer " + callerToken},
+			"X-Unrelated":   {"x"},
+		})
+	}()
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(context.Background(), clientTransport, nil)
+	require.NoError(t, err)
+
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "query_equipment"})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	assert.Equal(t, http.Header{"X-Tenant-Id": {"acme"}, "Authorization": {"Bearer user-token"}}, upstream)
+	assert.Equal(t, callerToken, subjectToken)
+
+	cancel()
+	require.NoError(t, session.Close())
+	<-done
+}